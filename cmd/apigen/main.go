@@ -0,0 +1,385 @@
+// apigen 根据 pkg/const/actions.yaml 生成 Action 常量、WSServer 方法、BotAPI 接口、
+// Context 便捷方法和 TestServer 方法，新增 API 时只需修改 actions.yaml（以及 types.go 中的参数和响应类型）
+//
+// 用法（在 pkg/const 下）：
+//
+//	go generate
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/goccy/go-yaml"
+)
+
+// Spec actions.yaml 的内容
+type Spec struct {
+	Groups []Group `yaml:"groups"`
+}
+
+// Group 一组 action，对应常量块和 WSServer 中的分段注释
+type Group struct {
+	Name    string   `yaml:"name"`
+	Actions []Action `yaml:"actions"`
+}
+
+// Action 一个 OneBot action
+type Action struct {
+	Action   string       `yaml:"action"`
+	Name     string       `yaml:"name"`
+	Doc      string       `yaml:"doc"`
+	Params   string       `yaml:"params"`
+	Args     []string     `yaml:"args"`
+	Response string       `yaml:"response"`
+	Server   string       `yaml:"server"`
+	Test     string       `yaml:"test"`
+	Context  *ContextSpec `yaml:"context"`
+
+	args []arg
+}
+
+// ContextSpec Context 便捷方法
+type ContextSpec struct {
+	Name  string            `yaml:"name"`
+	Doc   string            `yaml:"doc"`
+	Fixed map[string]string `yaml:"fixed"`
+}
+
+// arg 方法参数，field 为参数结构体中的字段名
+type arg struct {
+	name, typ, field string
+}
+
+const custom = "custom"
+
+func main() {
+	specPath := flag.String("spec", "actions.yaml", "action spec file")
+	root := flag.String("root", "../..", "repository root")
+	flag.Parse()
+	log.SetFlags(0)
+	log.SetPrefix("apigen: ")
+
+	spec, err := load(*specPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	source := filepath.Base(*specPath)
+	outputs := []struct {
+		path string
+		gen  func(*Spec) []byte
+	}{
+		{"pkg/const/api_gen.go", genConstants},
+		{"internal/server/api_gen.go", genServer},
+		{"pkg/event/bot_gen.go", genBotAPI},
+		{"pkg/event/context_gen.go", genContext},
+		{"pkg/event/testutil_gen.go", genTestServer},
+	}
+	for _, out := range outputs {
+		var buf bytes.Buffer
+		fmt.Fprintf(&buf, "// Code generated by apigen from %s. DO NOT EDIT.\n\n", source)
+		buf.Write(out.gen(spec))
+		code, err := format.Source(buf.Bytes())
+		if err != nil {
+			log.Fatalf("%s: %v\n%s", out.path, err, buf.Bytes())
+		}
+		if err := os.WriteFile(filepath.Join(*root, out.path), code, 0o644); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+// load 读取并校验 spec
+func load(path string) (*Spec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var spec Spec
+	if err := yaml.UnmarshalWithOptions(data, &spec, yaml.DisallowUnknownField()); err != nil {
+		return nil, fmt.Errorf("%s: %s", path, yaml.FormatError(err, false, true))
+	}
+
+	names := make(map[string]bool)
+	for gi := range spec.Groups {
+		for ai := range spec.Groups[gi].Actions {
+			a := &spec.Groups[gi].Actions[ai]
+			if a.Action == "" || a.Name == "" {
+				return nil, fmt.Errorf("%s: action and name are required", path)
+			}
+			if names[a.Name] {
+				return nil, fmt.Errorf("%s: duplicate name %s", path, a.Name)
+			}
+			names[a.Name] = true
+			for _, s := range a.Args {
+				fields := strings.Fields(s)
+				switch {
+				case len(fields) == 3 && a.Params != "":
+					a.args = append(a.args, arg{fields[0], fields[1], fields[2]})
+				case len(fields) == 2 && a.Params == "" && len(a.Args) == 1:
+					a.args = append(a.args, arg{name: fields[0], typ: fields[1]})
+				default:
+					return nil, fmt.Errorf("%s: %s: invalid arg %q", path, a.Name, s)
+				}
+			}
+			if a.Context != nil {
+				for name := range a.Context.Fixed {
+					if !a.hasArg(name) {
+						return nil, fmt.Errorf("%s: %s: fixed arg %s does not exist", path, a.Name, name)
+					}
+				}
+			}
+		}
+	}
+	return &spec, nil
+}
+
+func (a *Action) hasArg(name string) bool {
+	for _, arg := range a.args {
+		if arg.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ============ 类型和签名 ============
+
+// qualify 为 types 包中的类型添加包名，如 *SendMessageParams -> *types.SendMessageParams
+func qualify(typ string) string {
+	prefix := ""
+	for strings.HasPrefix(typ, "*") || strings.HasPrefix(typ, "[]") {
+		if typ[0] == '*' {
+			prefix, typ = prefix+"*", typ[1:]
+		} else {
+			prefix, typ = prefix+"[]", typ[2:]
+		}
+	}
+	if typ != "" && unicode.IsUpper([]rune(typ)[0]) {
+		typ = "types." + typ
+	}
+	return prefix + typ
+}
+
+// signature 返回参数列表，相邻的相同类型合并（如 groupID, userID int64），skip 中的参数被省略
+func (a *Action) signature(skip map[string]string) string {
+	var parts []string
+	var names []string
+	for i, arg := range a.args {
+		if _, ok := skip[arg.name]; ok {
+			continue
+		}
+		names = append(names, arg.name)
+		next := i + 1
+		for next < len(a.args) {
+			if _, ok := skip[a.args[next].name]; !ok {
+				break
+			}
+			next++
+		}
+		if next < len(a.args) && a.args[next].typ == arg.typ {
+			continue
+		}
+		parts = append(parts, strings.Join(names, ", ")+" "+qualify(arg.typ))
+		names = nil
+	}
+	return strings.Join(parts, ", ")
+}
+
+// argTypes 返回只有类型的参数列表，用于不使用参数的实现
+func (a *Action) argTypes() string {
+	types := make([]string, len(a.args))
+	for i, arg := range a.args {
+		types[i] = qualify(arg.typ)
+	}
+	return strings.Join(types, ", ")
+}
+
+// results 返回结果列表
+func (a *Action) results() string {
+	if a.Response == "" {
+		return "error"
+	}
+	return "(" + qualify(a.Response) + ", error)"
+}
+
+// returnErr 返回错误的 return 语句
+func (a *Action) returnErr(err string) string {
+	if a.Response == "" {
+		return "return " + err
+	}
+	return "return nil, " + err
+}
+
+// pointer 响应是否为指针
+func (a *Action) pointer() bool {
+	return strings.HasPrefix(a.Response, "*")
+}
+
+// literal 返回参数结构体字面量，multiline 时每个字段一行
+func (a *Action) literal(multiline bool) string {
+	if len(a.args) == 0 {
+		return qualify(a.Params) + "{}"
+	}
+	fields := make([]string, len(a.args))
+	for i, arg := range a.args {
+		fields[i] = arg.field + ": " + arg.name
+	}
+	if multiline {
+		return qualify(a.Params) + "{\n" + strings.Join(fields, ",\n") + ",\n}"
+	}
+	return qualify(a.Params) + "{" + strings.Join(fields, ", ") + "}"
+}
+
+// ============ 生成 ============
+
+func genConstants(spec *Spec) []byte {
+	var b bytes.Buffer
+	b.WriteString("package types\n\n// OneBot API Action 常量定义\n\nconst (\n")
+	for i, group := range spec.Groups {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "// %s\n", group.Name)
+		for _, a := range group.Actions {
+			fmt.Fprintf(&b, "Action%s = %q // %s\n", a.Name, a.Action, a.Doc)
+		}
+	}
+	b.WriteString(")\n")
+	return b.Bytes()
+}
+
+func genServer(spec *Spec) []byte {
+	var b bytes.Buffer
+	b.WriteString("package server\n\nimport (\n\"fmt\"\n\ntypes \"onebot-go2/pkg/const\"\n)\n")
+	for _, group := range spec.Groups {
+		header := false
+		for _, a := range group.Actions {
+			if a.Server == custom {
+				continue
+			}
+			if !header {
+				fmt.Fprintf(&b, "\n// ============ %s API ============\n", group.Name)
+				header = true
+			}
+			fmt.Fprintf(&b, "\n// %s %s\nfunc (s *WSServer) %s(%s) %s {\n", a.Name, a.Doc, a.Name, a.signature(nil), a.results())
+			params := "nil"
+			switch {
+			case a.Params != "":
+				fmt.Fprintf(&b, "params := %s\n\n", a.literal(true))
+				params = "params"
+			case len(a.args) == 1:
+				params = a.args[0].name
+			}
+			if a.Response == "" {
+				fmt.Fprintf(&b, "_, err := s.CallAPI(types.Action%s, %s)\nreturn err\n}\n", a.Name, params)
+				continue
+			}
+			fmt.Fprintf(&b, "resp, err := s.CallAPI(types.Action%s, %s)\nif err != nil {\nreturn nil, err\n}\n\n", a.Name, params)
+			fmt.Fprintf(&b, "var result %s\n", qualify(strings.TrimPrefix(a.Response, "*")))
+			b.WriteString("if err := mapToStruct(resp.Data, &result); err != nil {\nreturn nil, fmt.Errorf(\"failed to parse response: %w\", err)\n}\n\n")
+			if a.pointer() {
+				b.WriteString("return &result, nil\n}\n")
+			} else {
+				b.WriteString("return result, nil\n}\n")
+			}
+		}
+	}
+	return b.Bytes()
+}
+
+func genBotAPI(spec *Spec) []byte {
+	var b bytes.Buffer
+	b.WriteString("package event\n\nimport (\ntypes \"onebot-go2/pkg/const\"\n)\n\n")
+	b.WriteString("// BotAPI OneBot 的全部 API，处理器通过 ctx.Bot() 调用\n")
+	b.WriteString("// 与 WSServer 的方法一一对应，internal/server 中的编译期断言保证两者同步\n")
+	b.WriteString("type BotAPI interface {\n")
+	for i, group := range spec.Groups {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "// %s\n", group.Name)
+		for _, a := range group.Actions {
+			fmt.Fprintf(&b, "%s(%s) %s\n", a.Name, a.signature(nil), a.results())
+		}
+	}
+	b.WriteString("\n// CallAPI 调用任意 action，用于未封装的扩展 API\n")
+	b.WriteString("CallAPI(action string, params interface{}) (*types.APIResponse, error)\n}\n")
+
+	for _, group := range spec.Groups {
+		for _, a := range group.Actions {
+			fmt.Fprintf(&b, "\nfunc (unavailableBot) %s(%s) %s {\n%s\n}\n", a.Name, a.argTypes(), a.results(), a.returnErr("errServerUnavailable"))
+		}
+	}
+	return b.Bytes()
+}
+
+func genContext(spec *Spec) []byte {
+	var b bytes.Buffer
+	b.WriteString("package event\n\nimport (\n\"fmt\"\n\ntypes \"onebot-go2/pkg/const\"\n)\n")
+	for _, group := range spec.Groups {
+		for _, a := range group.Actions {
+			if a.Context == nil {
+				continue
+			}
+			doc := a.Context.Doc
+			if doc == "" {
+				doc = a.Doc
+			}
+			args := make([]string, len(a.args))
+			for i, arg := range a.args {
+				args[i] = arg.name
+				if value, ok := a.Context.Fixed[arg.name]; ok {
+					args[i] = value
+				}
+			}
+			fmt.Fprintf(&b, "\n// %s %s\nfunc (c *Context[T]) %s(%s) %s {\n", a.Context.Name, doc, a.Context.Name, a.signature(a.Context.Fixed), a.results())
+			fmt.Fprintf(&b, "server := c.GetServer()\nif server == nil {\n%s\n}\n", a.returnErr(`fmt.Errorf("server not available")`))
+			fmt.Fprintf(&b, "return server.%s(%s)\n}\n", a.Name, strings.Join(args, ", "))
+		}
+	}
+	return b.Bytes()
+}
+
+func genTestServer(spec *Spec) []byte {
+	var b bytes.Buffer
+	b.WriteString("package event\n\nimport (\ntypes \"onebot-go2/pkg/const\"\n)\n")
+	for _, group := range spec.Groups {
+		for _, a := range group.Actions {
+			if a.Test == custom {
+				continue
+			}
+			fmt.Fprintf(&b, "\n// %s %s\nfunc (s *TestServer) %s(%s) %s {\n", a.Name, a.Doc, a.Name, a.signature(nil), a.results())
+			params := "nil"
+			switch {
+			case a.Params != "":
+				params = "&" + a.literal(false)
+			case len(a.args) == 1 && strings.HasPrefix(a.args[0].typ, "*"):
+				fmt.Fprintf(&b, "copied := *%s\n", a.args[0].name)
+				params = "&copied"
+			case len(a.args) == 1:
+				params = a.args[0].name
+			}
+			record := fmt.Sprintf("s.record(types.Action%s, %s)", a.Name, params)
+			if a.Response == "" {
+				fmt.Fprintf(&b, "return %s\n}\n", record)
+				continue
+			}
+			fmt.Fprintf(&b, "if err := %s; err != nil {\nreturn nil, err\n}\n", record)
+			if a.pointer() {
+				fmt.Fprintf(&b, "return &%s{}, nil\n}\n", qualify(a.Response[1:]))
+			} else {
+				b.WriteString("return nil, nil\n}\n")
+			}
+		}
+	}
+	return b.Bytes()
+}
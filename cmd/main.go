@@ -1,78 +1,238 @@
 package main
 
 import (
-	"log"
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"reflect"
+	"sync/atomic"
 	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
-	types "onebot-go2/pkg/const"
-	"onebot-go2/pkg/event"
+	"onebot-go2/internal/admin"
+	"onebot-go2/internal/config"
 	"onebot-go2/internal/handler"
 	"onebot-go2/internal/server"
+	"onebot-go2/pkg/command"
+	"onebot-go2/pkg/event"
+	"onebot-go2/pkg/history"
+	"onebot-go2/pkg/infocache"
+	"onebot-go2/pkg/longmsg"
+	"onebot-go2/pkg/metrics"
+	"onebot-go2/pkg/permission"
+	"onebot-go2/pkg/plugin"
+	"onebot-go2/pkg/ratelimit"
+	"onebot-go2/pkg/record"
+	"onebot-go2/pkg/schedule"
+	"onebot-go2/pkg/storage"
+	"onebot-go2/pkg/throttle"
+	"onebot-go2/pkg/trace"
 )
 
 func main() {
-	log.SetFlags(log.Ldate | log.Ltime | log.Lshortfile)
-	log.Println("=== OneBot Go2 Bot Starting ===")
+	configPath := flag.String("config", os.Getenv("ONEBOT_CONFIG"), "配置文件路径（默认 config.yaml）")
+	replayPath := flag.String("replay", "", "重放录制文件（record.file）后退出，用于离线复现问题")
+	replaySpeed := flag.Float64("replay-speed", 1, "重放速度：1 为原始速度，0 为尽快重放")
+	flag.Parse()
+
+	// ============ 加载配置 ============
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		fatal("Failed to load config", err)
+	}
+
+	logLevel, logCloser, err := config.SetupLogging(cfg.Logging)
+	if err != nil {
+		fatal("Failed to setup logging", err)
+	}
+	defer logCloser.Close()
+
+	slog.Info("=== OneBot Go2 Bot Starting ===")
 
 	// 创建 WebSocket 服务器
-	token := os.Getenv("ONEBOT_TOKEN")
-	if token == "" {
-		token = "your-token-here"
-		log.Printf("Warning: Using default token. Set ONEBOT_TOKEN environment variable for production.")
+	if cfg.OneBot.Token == "" {
+		slog.Warn("onebot.token is empty, WebSocket connections are not authenticated. Set ONEBOT_TOKEN for production.")
 	}
 
-	wsServer := server.NewWSServer(token)
+	wsServer := server.NewWSServer(cfg.OneBot.Token)
+	wsServer.SetCallTimeout(cfg.OneBot.CallTimeout())
 	dispatcher := wsServer.GetDispatcher()
+	dispatcher.SetAsync(cfg.Dispatcher.Async)
 
-	// ============ 配置中间件 ============
-	log.Println("Configuring middlewares...")
+	// ============ 存储 ============
+	// 插件通过 ctx.Storage() / bot.Storage() 访问各自命名空间的存储
+	store, err := openStorage(cfg)
+	if err != nil {
+		fatal("Failed to open storage", err)
+	}
+	defer store.Close()
+	dispatcher.SetStorage(store)
 
-	// 1. 恢复中间件 - 防止 panic 导致程序崩溃
-	dispatcher.Use(event.RecoveryMiddleware())
+	// ============ 消息历史 ============
+	// 记录收到和发出的消息，处理器通过 ctx.History() 查询
+	wsServer.SetHistory(history.New(cfg.History.MaxRecords, cfg.History.MaxAgeDuration()))
 
-	// 2. 日志中间件 - 记录每个事件的处理时间
-	dispatcher.Use(event.LoggingMiddleware())
+	// ============ 信息缓存 ============
+	// 缓存群信息、群成员信息和好友列表，收到相关通知时自动更新
+	if cfg.Cache.Enabled {
+		wsServer.SetInfoCache(infocache.New(cfg.Cache.CacheOptions()))
+		wsServer.SetCacheWarmup(cfg.Cache.Warm, cfg.Cache.WarmMembers)
+	}
 
-	// 3. 超时中间件 - 防止处理器执行过长时间
-	// dispatcher.Use(event.TimeoutMiddleware(5 * time.Second))
+	// ============ 运行指标 ============
+	// 事件、处理器、API 调用和连接状态，通过 metrics.path 以 Prometheus 文本格式输出
+	var botMetrics *metrics.Bot
+	metricsRegistry := metrics.NewRegistry()
+	if cfg.Metrics.Enabled {
+		botMetrics = metrics.NewBot(metricsRegistry)
+		wsServer.SetMetrics(botMetrics)
+	}
 
-	// 4. 限流中间件 - 防止过载（可选）
-	// dispatcher.Use(event.RateLimitMiddleware(100, time.Minute))
+	// ============ 追踪 ============
+	// 每个事件一个 trace：事件 -> 处理器 -> API 调用，配置 trace.file 时导出为 JSON Lines
+	var exporter trace.Exporter
+	if cfg.Trace.File != "" {
+		fileExporter, err := trace.NewFileExporter(cfg.Trace.File)
+		if err != nil {
+			fatal("Failed to open trace file", err)
+		}
+		defer fileExporter.Close()
+		exporter = fileExporter
+	}
+	tracer := trace.NewTracer(exporter)
+	dispatcher.SetTracer(tracer)
+	wsServer.SetTracer(tracer)
 
-	// ============ 注册事件处理器 ============
-	log.Println("Registering event handlers...")
+	// ============ 流量录制 ============
+	// 记录收发的每一帧，可以通过 -replay 离线重放；重放时不录制
+	if cfg.Record.File != "" && *replayPath == "" {
+		recorder, err := record.NewRecorder(cfg.Record.File, cfg.Record.Redact)
+		if err != nil {
+			fatal("Failed to open record file", err)
+		}
+		defer recorder.Close()
+		wsServer.SetRecorder(recorder)
+		slog.Warn("Recording raw OneBot traffic", "file", cfg.Record.File, "redact", cfg.Record.Redact)
+	}
 
-	// 1. 消息日志处理器 - 记录所有消息
-	event.Register(dispatcher, handler.NewMessageLogHandler())
+	// ============ 配置中间件 ============
+	slog.Info("Configuring middlewares...")
+	rateLimit := useMiddlewares(dispatcher, cfg.Middleware, botMetrics)
 
-	// 2. 消息过滤器 - 过滤不当内容（可选）
-	// event.Register(dispatcher, handler.NewMessageFilterHandler())
+	// ============ 权限管理 ============
+	// 超级用户来自配置中的 admins，群主/群管理员根据群角色判断
+	permissions := permission.NewManager(cfg.Admins)
+	// 运行时授予的本群机器人管理员，重启后保持
+	if err := permissions.LoadAdmins(filepath.Join(cfg.DataDir, "bot_admins.json")); err != nil {
+		fatal("Failed to load bot admins", err)
+	}
 
-	// 3. 命令处理器 - 处理 /help, /ping, /echo 等命令
-	event.Register(dispatcher, handler.NewDefaultCommandHandler())
+	// ============ 超长消息 ============
+	// 在发送限流之前注册，拆分后的每条消息分别限流；合并转发节点使用机器人昵称
+	longMessages := longmsg.New(cfg.LongMsg.LongMessageOptions()).SetObserver(botMetrics.ObserveLongMessage).
+		SetNickname(func(selfID int64) string { return wsServer.ForBot(selfID).Self().Nickname })
+	wsServer.UseOutbound(longMessages.Interceptor())
 
-	// 4. 简单回复处理器示例
-	event.RegisterFunc(dispatcher, "SimpleReplyHandler", 100, func(ctx *event.Context[*types.MessageEvent]) error {
-		// 示例：回复包含"你好"的消息
-		rawMsg, _ := ctx.GetRawMessage()
-		if rawMsg == "你好" {
-			_, err := ctx.ReplyText("你好！我是 OneBot Go2 Bot")
-			return err
+	// ============ 发送限流 ============
+	// 所有发出的消息按账号和群限流，始终注册以便热重载时启用；回复管理员的消息优先发送
+	sendThrottle := throttle.New(cfg.Throttle.ThrottleOptions()).SetObserver(botMetrics.ObserveThrottle)
+	wsServer.UseOutbound(sendThrottle.Interceptor())
+	if cfg.Metrics.Enabled {
+		metricsRegistry.NewGaugeFunc("onebot_throttle_queue_depth", "Outgoing messages waiting in the send throttle queue.",
+			func() float64 { return float64(sendThrottle.Stats().Queued) })
+	}
+	var adminPriority atomic.Bool
+	adminPriority.Store(cfg.Throttle.AdminPriority)
+	dispatcher.Use(throttle.Middleware(func(ctx *event.Context[interface{}]) throttle.Priority {
+		if userID, ok := ctx.GetUserID(); ok && adminPriority.Load() && permissions.IsSuperuser(userID) {
+			return throttle.PriorityHigh
 		}
-		return nil
-	})
+		return throttle.PriorityNormal
+	}))
+
+	// ============ 注册插件 ============
+	slog.Info("Registering plugins...")
 
-	// 5. 通知事件处理器
-	event.Register(dispatcher, handler.NewNoticeHandler())
+	// 命令注册表由所有插件共享，插件通过 bot.Command 注册命令
+	commands := command.NewRegistry(cfg.Commands.Prefix).SetPermissions(permissions)
+
+	// 定时任务调度器由所有插件共享，插件通过 bot.Cron / bot.Schedule 添加任务
+	scheduler := schedule.New(wsServer).SetLocation(cfg.Scheduler.Location())
+
+	plugins := plugin.NewManager(dispatcher, wsServer).
+		SetCommands(commands).
+		SetScheduler(scheduler).
+		SetConfig(cfg.Plugins)
+
+	// 按群/按用户的插件和命令开关，通过 /plugin 命令修改，重启后保持
+	switches, err := plugin.NewSwitches(filepath.Join(cfg.DataDir, "plugin_switches.json"))
+	if err != nil {
+		fatal("Failed to load plugin switches", err)
+	}
+	switches.Protect(plugin.KindPlugin, "commands").Protect(plugin.KindCommand, "plugin")
+	dispatcher.SetGroupFilter(switches.GroupFilter())
+	commands.SetFilter(switches.CommandFilter(plugins))
+	if err := commands.Register(handler.NewPluginCommand(plugins, switches)); err != nil {
+		fatal("Failed to register plugin command", err)
+	}
 
-	// 6. 请求事件处理器
-	event.Register(dispatcher, handler.NewRequestHandler())
+	// 内置插件：日志、禁用词过滤、命令、关键词回复、定时任务
+	filter := handler.NewFilterPlugin(cfg.Filter.BannedWords)
+	err = plugins.Register(
+		handler.NewLoggerPlugin(),
+		filter,
+		handler.NewCommandsPlugin(commands, cfg.Commands),
+		handler.NewReplyPlugin(),
+		handler.NewSchedulePlugin(),
+	)
+	if err != nil {
+		fatal("Failed to register plugins", err)
+	}
+
+	if err := plugins.Init(); err != nil {
+		fatal("Failed to initialize plugins", err)
+	}
+	if err := plugins.Start(); err != nil {
+		fatal("Failed to start plugins", err)
+	}
+	defer plugins.Stop()
+
+	slog.Info("Plugins started", "plugins", plugins.Names())
+
+	// 调度器在插件之前停止，等待运行中的任务结束
+	scheduler.Start()
+	defer scheduler.Stop()
+
+	// ============ 配置热重载 ============
+	// 管理员、命令、禁用词、限流、长消息、日志级别和插件配置无需重启即可生效，OneBot 连接不会断开
+	watcher := config.NewWatcher(*configPath, cfg)
+	watcher.Subscribe(func(change *config.ChangeEvent) {
+		next := change.New
+		permissions.SetSuperusers(next.Admins)
+		handler.ApplyCommandsConfig(commands, next.Commands)
+		filter.SetBannedWords(next.Filter.BannedWords)
+		if !reflect.DeepEqual(change.Old.Middleware.RateLimit, next.Middleware.RateLimit) {
+			rateLimit.Set(rateLimitRule(next.Middleware.RateLimit))
+		}
+		sendThrottle.SetConfig(next.Throttle.ThrottleOptions())
+		adminPriority.Store(next.Throttle.AdminPriority)
+		longMessages.SetConfig(next.LongMsg.LongMessageOptions())
+		logLevel.Set(config.ParseLevel(next.Logging.Level))
+		if err := plugins.Reload(next.Plugins); err != nil {
+			slog.Error("Failed to reload plugin config", "component", "config", "error", err)
+		}
 
-	log.Println("All handlers registered successfully")
+		// 通知订阅了 *config.ChangeEvent 的处理器
+		dispatcher.Dispatch(context.Background(), change, wsServer)
+	})
+	watcher.Start(config.DefaultPollInterval)
+	defer watcher.Stop()
 
 	// ============ 启动 Gin HTTP 服务器 ============
 	gin.SetMode(gin.ReleaseMode)
@@ -94,29 +254,134 @@ func main() {
 		})
 	})
 
-	// 获取服务器端口
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
+	// 指标端点
+	if cfg.Metrics.Enabled {
+		r.GET(cfg.Metrics.Path, gin.WrapH(metricsRegistry))
+	}
+
+	// 管理 API
+	if cfg.AdminAPI.Enabled {
+		adminAPI, err := admin.New(wsServer, cfg.AdminAPI)
+		if err != nil {
+			fatal("Failed to create admin API", err)
+		}
+		defer adminAPI.Close()
+		adminAPI.Register(r)
 	}
 
 	// 启动服务器
-	log.Printf("Starting HTTP server on port %s", port)
-	log.Printf("WebSocket endpoint: ws://localhost:%s/ws", port)
-	log.Printf("Health check: http://localhost:%s/health", port)
-	log.Println("Waiting for OneBot client connection...")
+	addr := cfg.Server.Addr()
+	slog.Info("Starting HTTP server", "addr", addr)
+	slog.Info(fmt.Sprintf("WebSocket endpoint: ws://localhost:%d/ws", cfg.Server.Port))
+	slog.Info(fmt.Sprintf("Health check: http://localhost:%d/health", cfg.Server.Port))
+	if cfg.Metrics.Enabled {
+		slog.Info(fmt.Sprintf("Metrics: http://localhost:%d%s", cfg.Server.Port, cfg.Metrics.Path))
+	}
+	if cfg.AdminAPI.Enabled {
+		slog.Info(fmt.Sprintf("Admin API: http://localhost:%d/admin/", cfg.Server.Port))
+	}
+	slog.Info("Waiting for OneBot client connection...")
 
 	// 优雅关闭
 	go func() {
-		if err := r.Run(":" + port); err != nil {
-			log.Fatalf("Failed to start server: %v", err)
+		if err := r.Run(addr); err != nil {
+			fatal("Failed to start server", err)
 		}
 	}()
 
 	// 等待中断信号
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
-	<-sigChan
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if *replayPath != "" {
+		if err := replay(ctx, cfg, *replayPath, *replaySpeed); err != nil {
+			slog.Error("Replay failed", "error", err)
+		}
+	} else {
+		<-ctx.Done()
+	}
+
+	slog.Info("=== OneBot Go2 Bot Shutting Down ===")
+}
+
+// replay 作为 OneBot 客户端连接本机器人，重放录制的事件，API 请求由录制的响应应答
+func replay(ctx context.Context, cfg *config.Config, path string, speed float64) error {
+	frames, err := record.Load(path)
+	if err != nil {
+		return err
+	}
+	host := cfg.Server.Host
+	if host == "" || host == "0.0.0.0" {
+		host = "127.0.0.1"
+	}
+	url := fmt.Sprintf("ws://%s:%d/ws", host, cfg.Server.Port)
+	slog.Info("Replaying recording", "file", path, "frames", len(frames), "speed", speed)
+
+	result, err := record.NewReplayer(frames).SetSpeed(speed).SetToken(cfg.OneBot.Token).Run(ctx, url)
+	if err != nil {
+		return err
+	}
+	slog.Info("Replay finished", "events", result.Events, "calls", result.Calls, "unmatched", len(result.Unmatched))
+	fmt.Println(result.Summary())
+	return nil
+}
+
+// fatal 记录错误并退出
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
 
-	log.Println("=== OneBot Go2 Bot Shutting Down ===")
+// useMiddlewares 根据配置注册中间件，返回可热重载的限流规则开关
+func useMiddlewares(dispatcher *event.Dispatcher, cfg config.MiddlewareConfig, botMetrics *metrics.Bot) *ratelimit.Switch {
+	// 0. 指标中间件 - 最外层，记录所有处理器（包括 panic 被恢复的）的调用次数和耗时
+	if botMetrics != nil {
+		dispatcher.Use(botMetrics.Middleware())
+	}
+
+	// 1. 恢复中间件 - 防止 panic 导致程序崩溃
+	if cfg.Recovery.Enabled {
+		dispatcher.Use(event.RecoveryMiddleware())
+	}
+
+	// 2. 日志中间件 - 记录每个事件的处理时间
+	if cfg.Logging.Enabled {
+		dispatcher.Use(event.LoggingMiddleware())
+	}
+
+	// 3. 超时中间件 - 防止处理器执行过长时间
+	if cfg.Timeout.Enabled {
+		dispatcher.Use(event.TimeoutMiddleware(time.Duration(cfg.Timeout.Duration) * time.Second))
+	}
+
+	// 4. 限流中间件 - 防止刷屏，始终注册以便热重载时启用
+	rateLimit := ratelimit.NewSwitch(rateLimitRule(cfg.RateLimit))
+	dispatcher.Use(rateLimit.Middleware())
+
+	return rateLimit
+}
+
+// openStorage 根据配置打开存储
+func openStorage(cfg *config.Config) (storage.Store, error) {
+	if cfg.Storage.Backend == "memory" {
+		slog.Warn("Using in-memory storage, data will be lost on restart", "component", "storage")
+		return storage.NewMemory(), nil
+	}
+	path := filepath.Join(cfg.DataDir, "storage.log")
+	slog.Info("Using file storage", "component", "storage", "path", path)
+	return storage.OpenFile(path)
+}
+
+// rateLimitRule 根据配置创建限流规则，未启用时返回 nil
+func rateLimitRule(cfg config.RateLimitConfig) *ratelimit.Rule {
+	if !cfg.Enabled {
+		return nil
+	}
+	key := map[string]ratelimit.KeyFunc{
+		"user":   ratelimit.PerUser,
+		"group":  ratelimit.PerGroup,
+		"global": ratelimit.Global,
+	}[cfg.Key]
+	window := time.Duration(cfg.Window) * time.Second
+	return ratelimit.NewRule(cfg.Limit, window, key)
 }
@@ -0,0 +1,405 @@
+package admin
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"onebot-go2/internal/config"
+	"onebot-go2/internal/server"
+	types "onebot-go2/pkg/const"
+	"onebot-go2/pkg/event"
+	"onebot-go2/pkg/infocache"
+	"onebot-go2/pkg/message"
+	"onebot-go2/pkg/outbound"
+	"onebot-go2/pkg/throttle"
+)
+
+// API 管理 HTTP API，供运维工具在不写 Go 代码的情况下调用 OneBot action
+//
+//	GET  /admin/bots                        已连接的机器人及其身份信息（需要 bots 权限）
+//	POST /admin/api/:action                 转发任意 action，请求体为 params
+//	POST /admin/groups/:group_id/messages   发送群消息（send_group_msg），message 与 send_group_msg 的参数含义相同
+//	GET  /admin/groups                      群列表（get_group_list）
+//	GET  /admin/groups/:group_id/members    群成员列表（get_group_member_list）
+//	GET  /admin/cache                       信息缓存命中统计（需要 cache_stats 权限）
+//	GET  /events/stream                     收到的事件和发出的 API 调用（SSE，需要 events_stream 权限）
+//
+// 请求通过 API 密钥鉴权，每个密钥只能调用允许的 action，所有请求写入审计日志
+// 多个机器人连接时通过 self_id 查询参数或 X-Self-ID 请求头选择目标机器人
+// 群列表和群成员列表默认读取信息缓存，no_cache=true 时直接调用 API
+type API struct {
+	server       *server.WSServer
+	keys         []apiKey
+	audit        *slog.Logger
+	closer       io.Closer
+	streamBuffer int
+}
+
+type apiKey struct {
+	name    string
+	key     []byte
+	actions map[string]bool // 为空表示允许全部
+}
+
+// CacheStatsAction 查询 /admin/cache 需要的权限，与 action 一起在 admin_api.keys[].actions 中配置
+const CacheStatsAction = "cache_stats"
+
+// BotsAction 查询 /admin/bots 需要的权限
+const BotsAction = "bots"
+
+// keyContextKey gin.Context 中保存当前密钥的键
+const keyContextKey = "admin_api_key"
+
+// New 创建管理 API，配置了 audit_log 时打开审计日志文件
+func New(srv *server.WSServer, cfg config.AdminAPIConfig) (*API, error) {
+	a := &API{
+		server:       srv,
+		audit:        slog.Default().With("component", "admin"),
+		streamBuffer: cfg.StreamBuffer,
+	}
+	for _, k := range cfg.Keys {
+		key := apiKey{name: k.Name, key: []byte(k.Key)}
+		for _, action := range k.Actions {
+			if action == "*" {
+				key.actions = nil
+				break
+			}
+			if key.actions == nil {
+				key.actions = make(map[string]bool)
+			}
+			key.actions[action] = true
+		}
+		a.keys = append(a.keys, key)
+	}
+
+	if cfg.AuditLog != "" {
+		if err := os.MkdirAll(filepath.Dir(cfg.AuditLog), 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create audit log directory: %w", err)
+		}
+		file, err := os.OpenFile(cfg.AuditLog, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open audit log: %w", err)
+		}
+		a.audit = slog.New(slog.NewJSONHandler(file, nil))
+		a.closer = file
+	}
+	return a, nil
+}
+
+// Close 关闭审计日志文件
+func (a *API) Close() error {
+	if a.closer == nil {
+		return nil
+	}
+	return a.closer.Close()
+}
+
+// Register 在 r 上注册 /admin 路由
+func (a *API) Register(r gin.IRouter) {
+	g := r.Group("/admin", a.authenticate)
+	g.GET("/bots", a.listBots)
+	g.POST("/api/:action", a.callAction)
+	g.POST("/groups/:group_id/messages", a.sendGroupMsg)
+	g.GET("/groups", a.listGroups)
+	g.GET("/groups/:group_id/members", a.listGroupMembers)
+	g.GET("/cache", a.cacheStats)
+	r.GET("/events/stream", a.authenticate, a.streamEvents)
+}
+
+// Authenticate 返回只校验 API 密钥的中间件，供其他需要密钥保护的路由使用
+func (a *API) Authenticate() gin.HandlerFunc {
+	return a.authenticate
+}
+
+// ============ 鉴权 ============
+
+// authenticate 校验 Authorization: Bearer <key> 或 X-API-Key: <key>
+func (a *API) authenticate(c *gin.Context) {
+	provided := c.GetHeader("X-API-Key")
+	if auth := c.GetHeader("Authorization"); provided == "" && strings.HasPrefix(auth, "Bearer ") {
+		provided = strings.TrimPrefix(auth, "Bearer ")
+	}
+
+	for i := range a.keys {
+		if subtle.ConstantTimeCompare([]byte(provided), a.keys[i].key) == 1 {
+			c.Set(keyContextKey, &a.keys[i])
+			c.Next()
+			return
+		}
+	}
+
+	a.record(c, "", 0, http.StatusUnauthorized, errors.New("invalid API key"))
+	c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid API key"})
+}
+
+// authorize 检查当前密钥是否允许调用 action，并选择目标机器人
+// 返回 nil 时已写入错误响应
+func (a *API) authorize(c *gin.Context, action string) *server.WSServer {
+	if !a.allowed(c, action) {
+		return nil
+	}
+
+	selfID, err := a.selfID(c)
+	if err != nil {
+		a.fail(c, action, 0, http.StatusBadRequest, err)
+		return nil
+	}
+	bot := a.server.ForBot(selfID)
+	if !bot.IsConnected() {
+		err := server.ErrNotConnected
+		if selfID != 0 {
+			err = fmt.Errorf("bot %d: %w", selfID, err)
+		}
+		a.fail(c, action, selfID, http.StatusServiceUnavailable, err)
+		return nil
+	}
+	return bot
+}
+
+// allowed 检查当前密钥是否允许调用 action，不允许时写入 403 响应
+func (a *API) allowed(c *gin.Context, action string) bool {
+	key := c.MustGet(keyContextKey).(*apiKey)
+	if key.actions != nil && !key.actions[action] {
+		err := fmt.Errorf("action %s is not allowed for key %s", action, key.name)
+		a.fail(c, action, 0, http.StatusForbidden, err)
+		return false
+	}
+	return true
+}
+
+// selfID 返回请求指定的机器人，未指定时只有一个机器人连接才能确定目标
+func (a *API) selfID(c *gin.Context) (int64, error) {
+	raw := c.Query("self_id")
+	if raw == "" {
+		raw = c.GetHeader("X-Self-ID")
+	}
+	if raw != "" {
+		selfID, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid self_id %q", raw)
+		}
+		return selfID, nil
+	}
+
+	bots := a.server.Bots()
+	if len(bots) > 1 {
+		return 0, fmt.Errorf("%d bots are connected, specify one with self_id: %v", len(bots), bots)
+	}
+	if len(bots) == 1 {
+		return bots[0], nil
+	}
+	return 0, nil
+}
+
+// ============ 路由 ============
+
+// listBots 返回已连接机器人的 self_id 和身份信息
+func (a *API) listBots(c *gin.Context) {
+	if !a.allowed(c, BotsAction) {
+		return
+	}
+	bots := a.server.Bots()
+	info := make([]event.Self, len(bots))
+	for i, selfID := range bots {
+		info[i] = a.server.ForBot(selfID).Self()
+	}
+	a.record(c, BotsAction, 0, http.StatusOK, nil)
+	c.JSON(http.StatusOK, gin.H{"bots": bots, "info": info})
+}
+
+// callAction 转发任意 action，请求体（JSON 对象）作为 params 原样发送
+func (a *API) callAction(c *gin.Context) {
+	action := c.Param("action")
+	bot := a.authorize(c, action)
+	if bot == nil {
+		return
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		a.fail(c, action, bot.SelfID(), http.StatusBadRequest, err)
+		return
+	}
+	params := json.RawMessage("{}")
+	if len(strings.TrimSpace(string(body))) > 0 {
+		if !json.Valid(body) {
+			a.fail(c, action, bot.SelfID(), http.StatusBadRequest, errors.New("request body must be JSON"))
+			return
+		}
+		params = body
+	}
+
+	resp, err := bot.CallAPIContext(c.Request.Context(), action, params)
+	if err != nil && resp != nil {
+		// OneBot 返回了失败状态，原样返回响应（不支持的 action 为 501，其他为 502）
+		a.record(c, action, bot.SelfID(), statusOf(err), err, "params", string(params))
+		c.JSON(statusOf(err), resp)
+		return
+	}
+	if err != nil {
+		a.fail(c, action, bot.SelfID(), statusOf(err), err, "params", string(params))
+		return
+	}
+	a.record(c, action, bot.SelfID(), http.StatusOK, nil, "params", string(params))
+	c.JSON(http.StatusOK, resp)
+}
+
+// sendGroupMsg 请求体为 {"message": ..., "auto_escape": false}
+// message 与 POST /admin/api/send_group_msg 的含义相同：字符串按 CQ 码解析（auto_escape 为 true 时作为纯文本），
+// 也可以是消息段数组或单个消息段
+func (a *API) sendGroupMsg(c *gin.Context) {
+	bot := a.authorize(c, types.ActionSendGroupMsg)
+	if bot == nil {
+		return
+	}
+	groupID, err := strconv.ParseInt(c.Param("group_id"), 10, 64)
+	if err != nil {
+		a.fail(c, types.ActionSendGroupMsg, bot.SelfID(), http.StatusBadRequest, fmt.Errorf("invalid group_id %q", c.Param("group_id")))
+		return
+	}
+
+	var body struct {
+		Message    json.RawMessage `json:"message"`
+		AutoEscape bool            `json:"auto_escape"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		a.fail(c, types.ActionSendGroupMsg, bot.SelfID(), http.StatusBadRequest, err)
+		return
+	}
+	msg, err := parseMessage(body.Message, body.AutoEscape)
+	if err != nil {
+		a.fail(c, types.ActionSendGroupMsg, bot.SelfID(), http.StatusBadRequest, err)
+		return
+	}
+
+	resp, err := bot.WithContext(c.Request.Context()).SendGroupMsg(groupID, msg)
+	a.respond(c, types.ActionSendGroupMsg, bot.SelfID(), resp, err, "group_id", groupID, "message", string(body.Message))
+}
+
+func (a *API) listGroups(c *gin.Context) {
+	bot := a.authorize(c, types.ActionGetGroupList)
+	if bot == nil {
+		return
+	}
+	groups, err := bot.WithContext(requestContext(c)).GetGroupList()
+	a.respond(c, types.ActionGetGroupList, bot.SelfID(), groups, err)
+}
+
+func (a *API) listGroupMembers(c *gin.Context) {
+	bot := a.authorize(c, types.ActionGetGroupMemberList)
+	if bot == nil {
+		return
+	}
+	groupID, err := strconv.ParseInt(c.Param("group_id"), 10, 64)
+	if err != nil {
+		a.fail(c, types.ActionGetGroupMemberList, bot.SelfID(), http.StatusBadRequest, fmt.Errorf("invalid group_id %q", c.Param("group_id")))
+		return
+	}
+	members, err := bot.WithContext(requestContext(c)).GetGroupMemberList(groupID)
+	a.respond(c, types.ActionGetGroupMemberList, bot.SelfID(), members, err, "group_id", groupID)
+}
+
+func (a *API) cacheStats(c *gin.Context) {
+	if !a.allowed(c, CacheStatsAction) {
+		return
+	}
+	a.record(c, CacheStatsAction, 0, http.StatusOK, nil)
+	c.JSON(http.StatusOK, gin.H{
+		"enabled": a.server.InfoCache() != nil,
+		"stats":   a.server.InfoCache().Stats(),
+	})
+}
+
+// requestContext 返回请求的 context，查询参数 no_cache=true 时跳过信息缓存
+func requestContext(c *gin.Context) context.Context {
+	ctx := c.Request.Context()
+	if noCache, _ := strconv.ParseBool(c.Query("no_cache")); noCache {
+		ctx = infocache.WithNoCache(ctx)
+	}
+	return ctx
+}
+
+// ============ 响应和审计 ============
+
+// respond 写入便捷路由的响应，格式与 OneBot 响应一致
+func (a *API) respond(c *gin.Context, action string, selfID int64, data interface{}, err error, attrs ...any) {
+	if err != nil {
+		a.fail(c, action, selfID, statusOf(err), err, attrs...)
+		return
+	}
+	a.record(c, action, selfID, http.StatusOK, nil, attrs...)
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "retcode": 0, "data": data})
+}
+
+// fail 写入错误响应并记录审计日志
+func (a *API) fail(c *gin.Context, action string, selfID int64, status int, err error, attrs ...any) {
+	a.record(c, action, selfID, status, err, attrs...)
+	c.AbortWithStatusJSON(status, gin.H{"error": err.Error()})
+}
+
+// record 写入一条审计日志
+func (a *API) record(c *gin.Context, action string, selfID int64, status int, err error, attrs ...any) {
+	keyName := ""
+	if key, ok := c.Get(keyContextKey); ok {
+		keyName = key.(*apiKey).name
+	}
+	attrs = append([]any{
+		"key", keyName,
+		"remote", c.ClientIP(),
+		"method", c.Request.Method,
+		"path", c.Request.URL.Path,
+		"action", action,
+		"self_id", selfID,
+		"status", status,
+	}, attrs...)
+
+	if err != nil {
+		a.audit.Warn("Admin API request", append(attrs, "error", err.Error())...)
+		return
+	}
+	a.audit.Info("Admin API request", attrs...)
+}
+
+// statusOf 将 API 调用错误映射为 HTTP 状态码
+func statusOf(err error) int {
+	switch {
+	case errors.Is(err, server.ErrNotConnected):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, server.ErrCallTimeout):
+		return http.StatusGatewayTimeout
+	case errors.Is(err, event.ErrUnsupported):
+		return http.StatusNotImplemented
+	case errors.Is(err, server.ErrInvalidParams):
+		return http.StatusBadRequest
+	case errors.Is(err, outbound.ErrVetoed):
+		return http.StatusForbidden
+	case errors.Is(err, throttle.ErrDropped):
+		return http.StatusTooManyRequests
+	default:
+		return http.StatusBadGateway
+	}
+}
+
+// parseMessage 解析请求中的消息，格式见 message.Decode，不能为空
+func parseMessage(raw json.RawMessage, autoEscape bool) (types.MessageArray, error) {
+	msg, err := message.Decode(raw, autoEscape)
+	if err != nil {
+		return nil, err
+	}
+	if len(msg) == 0 {
+		return nil, errors.New("message is required")
+	}
+	return msg, nil
+}
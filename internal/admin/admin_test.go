@@ -0,0 +1,258 @@
+package admin_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"onebot-go2/internal/admin"
+	"onebot-go2/internal/config"
+	"onebot-go2/internal/server"
+	types "onebot-go2/pkg/const"
+	"onebot-go2/pkg/onebottest"
+)
+
+// testAPI 连接了假客户端的管理 API
+type testAPI struct {
+	router *gin.Engine
+	bot    *onebottest.Client
+}
+
+func newTestAPI(t *testing.T, cfg config.AdminAPIConfig) *testAPI {
+	t.Helper()
+	srv := server.NewWSServer("")
+	bot := onebottest.Start(t, srv)
+	api, err := admin.New(srv, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { api.Close() })
+
+	r := gin.New()
+	api.Register(r)
+	return &testAPI{router: r, bot: bot}
+}
+
+// do 发送请求，key 为空时不带密钥
+func (a *testAPI) do(method, path, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if key != "" {
+		req.Header.Set("X-API-Key", key)
+	}
+	w := httptest.NewRecorder()
+	a.router.ServeHTTP(w, req)
+	return w
+}
+
+func TestMessageRoutesAgree(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		want    []string // 发出的消息段类型
+		wantTxt string
+	}{
+		{
+			name:    "string is cq code",
+			body:    `{"message": "hi [CQ:at,qq=10001] &#91;ok&#93;"}`,
+			want:    []string{"text", "at", "text"},
+			wantTxt: "hi  [ok]",
+		},
+		{
+			name:    "auto escape sends plain text",
+			body:    `{"message": "[CQ:at,qq=10001]", "auto_escape": true}`,
+			want:    []string{"text"},
+			wantTxt: "[CQ:at,qq=10001]",
+		},
+		{
+			name:    "segment array",
+			body:    `{"message": [{"type": "face", "data": {"id": "1"}}, {"type": "text", "data": {"text": "x"}}]}`,
+			want:    []string{"face", "text"},
+			wantTxt: "x",
+		},
+		{
+			name:    "single segment",
+			body:    `{"message": {"type": "text", "data": {"text": "one"}}}`,
+			want:    []string{"text"},
+			wantTxt: "one",
+		},
+	}
+
+	routes := []struct {
+		name string
+		path string
+		body func(string) string
+	}{
+		{name: "messages route", path: "/admin/groups/100/messages", body: func(b string) string { return b }},
+		{
+			name: "api route",
+			path: "/admin/api/send_group_msg",
+			body: func(b string) string { return `{"group_id": 100, ` + strings.TrimPrefix(b, "{") },
+		},
+	}
+
+	a := newTestAPI(t, config.AdminAPIConfig{Keys: []config.APIKeyConfig{{Name: "ops", Key: "0123456789abcdef", Actions: []string{"*"}}}})
+	for _, tt := range tests {
+		for _, route := range routes {
+			t.Run(tt.name+"/"+route.name, func(t *testing.T) {
+				a.bot.Reset()
+				w := a.do(http.MethodPost, route.path, "0123456789abcdef", route.body(tt.body))
+				if w.Code != http.StatusOK {
+					t.Fatalf("status = %d, body %s", w.Code, w.Body)
+				}
+				call := a.bot.AssertCalled(types.ActionSendGroupMsg)
+				var got []string
+				for _, seg := range call.Message() {
+					got = append(got, seg.Type)
+				}
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("segments = %v, want %v", got, tt.want)
+				}
+				if call.Text() != tt.wantTxt {
+					t.Errorf("text = %q, want %q", call.Text(), tt.wantTxt)
+				}
+			})
+		}
+	}
+
+	for _, body := range []string{`{}`, `{"message": ""}`, `{"message": 42}`} {
+		if w := a.do(http.MethodPost, "/admin/groups/100/messages", "0123456789abcdef", body); w.Code != http.StatusBadRequest {
+			t.Errorf("POST %s: status = %d, want 400", body, w.Code)
+		}
+	}
+}
+
+func TestAuthorization(t *testing.T) {
+	a := newTestAPI(t, config.AdminAPIConfig{Keys: []config.APIKeyConfig{
+		{Name: "ops", Key: "ops-key-0123456789", Actions: []string{"*"}},
+		{Name: "reader", Key: "reader-key-0123456789", Actions: []string{types.ActionGetGroupList, types.ActionGetStatus}},
+	}})
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		header string // 请求头 名称: 值
+		body   string
+		status int
+	}{
+		{name: "no key", method: http.MethodGet, path: "/admin/groups", status: http.StatusUnauthorized},
+		{name: "wrong key", method: http.MethodGet, path: "/admin/groups", header: "X-API-Key: nope", status: http.StatusUnauthorized},
+		{name: "key prefix", method: http.MethodGet, path: "/admin/groups", header: "X-API-Key: ops-key", status: http.StatusUnauthorized},
+		{name: "bearer", method: http.MethodGet, path: "/admin/groups", header: "Authorization: Bearer ops-key-0123456789", status: http.StatusOK},
+		{name: "x-api-key", method: http.MethodGet, path: "/admin/groups", header: "X-API-Key: reader-key-0123456789", status: http.StatusOK},
+		{name: "allowed action", method: http.MethodPost, path: "/admin/api/get_status", header: "X-API-Key: reader-key-0123456789", status: http.StatusOK},
+		{
+			name: "action not allowed", method: http.MethodPost, path: "/admin/api/set_group_kick",
+			header: "X-API-Key: reader-key-0123456789", body: `{"group_id": 100, "user_id": 1}`, status: http.StatusForbidden,
+		},
+		{
+			name: "convenience route checks its action", method: http.MethodPost, path: "/admin/groups/100/messages",
+			header: "X-API-Key: reader-key-0123456789", body: `{"message": "hi"}`, status: http.StatusForbidden,
+		},
+		{name: "bots needs permission", method: http.MethodGet, path: "/admin/bots", header: "X-API-Key: reader-key-0123456789", status: http.StatusForbidden},
+		{name: "bots with wildcard", method: http.MethodGet, path: "/admin/bots", header: "X-API-Key: ops-key-0123456789", status: http.StatusOK},
+		{name: "cache stats needs permission", method: http.MethodGet, path: "/admin/cache", header: "X-API-Key: reader-key-0123456789", status: http.StatusForbidden},
+		{name: "stream needs permission", method: http.MethodGet, path: "/events/stream", header: "X-API-Key: reader-key-0123456789", status: http.StatusForbidden},
+		{name: "stream needs key", method: http.MethodGet, path: "/events/stream", status: http.StatusUnauthorized},
+		{name: "unknown bot", method: http.MethodGet, path: "/admin/groups?self_id=1", header: "X-API-Key: ops-key-0123456789", status: http.StatusServiceUnavailable},
+		{name: "invalid self_id", method: http.MethodGet, path: "/admin/groups?self_id=abc", header: "X-API-Key: ops-key-0123456789", status: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a.bot.Reset()
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			if name, value, ok := strings.Cut(tt.header, ": "); ok {
+				req.Header.Set(name, value)
+			}
+			w := httptest.NewRecorder()
+			a.router.ServeHTTP(w, req)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d; body %s", w.Code, tt.status, w.Body)
+			}
+			if tt.status == http.StatusForbidden || tt.status == http.StatusUnauthorized {
+				if calls := a.bot.Calls(); len(calls) != 0 {
+					t.Errorf("rejected request reached the bot: %v", calls)
+				}
+			}
+		})
+	}
+}
+
+func TestBots(t *testing.T) {
+	a := newTestAPI(t, config.AdminAPIConfig{Keys: []config.APIKeyConfig{{Name: "ops", Key: "ops-key-0123456789", Actions: []string{admin.BotsAction}}}})
+	w := a.do(http.MethodGet, "/admin/bots", "ops-key-0123456789", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body)
+	}
+	var resp struct {
+		Bots []int64 `json:"bots"`
+		Info []struct {
+			UserID   int64  `json:"user_id"`
+			Nickname string `json:"nickname"`
+		} `json:"info"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(resp.Bots, []int64{onebottest.DefaultSelfID}) || len(resp.Info) != 1 || resp.Info[0].Nickname != "onebottest" {
+		t.Errorf("GET /admin/bots = %s", w.Body)
+	}
+}
+
+func TestAuditLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "audit.log")
+	a := newTestAPI(t, config.AdminAPIConfig{
+		AuditLog: path,
+		Keys:     []config.APIKeyConfig{{Name: "reader", Key: "reader-key-0123456789", Actions: []string{types.ActionGetStatus}}},
+	})
+
+	a.do(http.MethodPost, "/admin/api/get_status", "reader-key-0123456789", `{"x": 1}`)
+	a.do(http.MethodPost, "/admin/api/set_group_kick", "reader-key-0123456789", `{}`)
+	a.do(http.MethodGet, "/admin/groups", "wrong", "")
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entries []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var entry map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("invalid audit log line %q: %v", line, err)
+		}
+		entries = append(entries, entry)
+	}
+
+	want := []struct {
+		key, action, path, err string
+		status                 float64
+	}{
+		{key: "reader", action: "get_status", path: "/admin/api/get_status", status: 200},
+		{key: "reader", action: "set_group_kick", path: "/admin/api/set_group_kick", status: 403, err: "not allowed"},
+		{key: "", action: "", path: "/admin/groups", status: 401, err: "invalid API key"},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("audit log has %d entries, want %d:\n%s", len(entries), len(want), data)
+	}
+	for i, w := range want {
+		e := entries[i]
+		if e["key"] != w.key || e["action"] != w.action || e["path"] != w.path || e["status"] != w.status {
+			t.Errorf("entry %d = %v, want %+v", i, e, w)
+		}
+		errText, _ := e["error"].(string)
+		if w.err == "" && errText != "" || !strings.Contains(errText, w.err) {
+			t.Errorf("entry %d error = %q, want %q", i, errText, w.err)
+		}
+	}
+	if entries[0]["params"] != `{"x": 1}` {
+		t.Errorf("params = %v, want the request body", entries[0]["params"])
+	}
+}
@@ -0,0 +1,117 @@
+package admin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"onebot-go2/internal/server"
+)
+
+// StreamAction 订阅 /events/stream 需要的权限，与 action 一起在 admin_api.keys[].actions 中配置
+const StreamAction = "events_stream"
+
+// streamHeartbeat SSE 心跳间隔，避免代理因空闲断开连接
+const streamHeartbeat = 15 * time.Second
+
+// streamEvents 以 Server-Sent Events 推送收到的事件（event: event）和发出的 API 调用（event: api）
+//
+// 查询参数（均可省略）：
+//
+//	kind=event,api            条目类型
+//	post_type=message,notice  事件的 post_type
+//	self_id、group_id、user_id  按机器人、群、用户过滤，API 调用按参数中的 group_id、user_id 匹配
+//
+// 客户端读取过慢导致缓冲区满时，推送 event: dropped 后断开
+func (a *API) streamEvents(c *gin.Context) {
+	if !a.allowed(c, StreamAction) {
+		return
+	}
+	filter, err := parseStreamFilter(c)
+	if err != nil {
+		a.fail(c, StreamAction, 0, http.StatusBadRequest, err)
+		return
+	}
+
+	stream := a.server.Stream()
+	sub := stream.Subscribe(filter, a.streamBuffer)
+	defer stream.Unsubscribe(sub)
+	a.record(c, StreamAction, filter.SelfID, http.StatusOK, nil, "query", c.Request.URL.RawQuery)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case item, ok := <-sub.Items():
+			if !ok {
+				if sub.Dropped() {
+					fmt.Fprint(c.Writer, "event: dropped\ndata: {\"error\":\"subscriber buffer full\"}\n\n")
+					c.Writer.Flush()
+					a.audit.Warn("Event stream subscriber dropped", "remote", c.ClientIP(), "buffer", a.streamBuffer)
+				}
+				return
+			}
+			data, err := json.Marshal(item)
+			if err != nil {
+				continue
+			}
+			if _, err := fmt.Fprintf(c.Writer, "event: %s\ndata: %s\n\n", item.Kind, data); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(c.Writer, ": ping\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		case <-c.Request.Context().Done():
+			return
+		}
+	}
+}
+
+// parseStreamFilter 解析过滤条件
+func parseStreamFilter(c *gin.Context) (server.StreamFilter, error) {
+	filter := server.StreamFilter{
+		Kinds:     splitList(c.Query("kind")),
+		PostTypes: splitList(c.Query("post_type")),
+	}
+	for name, target := range map[string]*int64{
+		"self_id":  &filter.SelfID,
+		"group_id": &filter.GroupID,
+		"user_id":  &filter.UserID,
+	} {
+		if raw := c.Query(name); raw != "" {
+			id, err := strconv.ParseInt(raw, 10, 64)
+			if err != nil {
+				return filter, fmt.Errorf("invalid %s %q", name, raw)
+			}
+			*target = id
+		}
+	}
+	return filter, nil
+}
+
+// splitList 解析逗号分隔的列表
+func splitList(raw string) []string {
+	var values []string
+	for _, v := range strings.Split(raw, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
@@ -0,0 +1,530 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-yaml"
+
+	"onebot-go2/pkg/infocache"
+	"onebot-go2/pkg/longmsg"
+	"onebot-go2/pkg/record"
+	"onebot-go2/pkg/throttle"
+)
+
+// DefaultPath 默认配置文件路径
+const DefaultPath = "config.yaml"
+
+// Config 应用配置，与 config.yaml 结构一一对应
+type Config struct {
+	Server     ServerConfig           `yaml:"server"`
+	OneBot     OneBotConfig           `yaml:"onebot"`
+	Dispatcher DispatcherConfig       `yaml:"dispatcher"`
+	Middleware MiddlewareConfig       `yaml:"middleware"`
+	Commands   CommandsConfig         `yaml:"commands"`
+	Filter     FilterConfig           `yaml:"filter"`
+	Logging    LoggingConfig          `yaml:"logging"`
+	Admins     []int64                `yaml:"admins"`
+	DataDir    string                 `yaml:"data_dir"` // 持久化数据目录
+	Storage    StorageConfig          `yaml:"storage"`
+	Scheduler  SchedulerConfig        `yaml:"scheduler"`
+	History    HistoryConfig          `yaml:"history"`
+	Cache      CacheConfig            `yaml:"cache"`
+	Throttle   ThrottleConfig         `yaml:"throttle"`
+	LongMsg    LongMessageConfig      `yaml:"long_message"`
+	Metrics    MetricsConfig          `yaml:"metrics"`
+	Trace      TraceConfig            `yaml:"trace"`
+	AdminAPI   AdminAPIConfig         `yaml:"admin_api"`
+	Record     RecordConfig           `yaml:"record"`
+	Plugins    map[string]interface{} `yaml:"plugins"` // 各插件的配置段，由插件自行解析
+}
+
+// ServerConfig HTTP 服务器配置
+type ServerConfig struct {
+	Host string `yaml:"host"`
+	Port int    `yaml:"port"`
+}
+
+// Addr 返回监听地址
+func (c ServerConfig) Addr() string {
+	return fmt.Sprintf("%s:%d", c.Host, c.Port)
+}
+
+// OneBotConfig OneBot 连接配置
+type OneBotConfig struct {
+	Token      string `yaml:"token"`
+	APITimeout int    `yaml:"api_timeout"` // 秒
+}
+
+// CallTimeout 返回 API 调用超时时间
+func (c OneBotConfig) CallTimeout() time.Duration {
+	return time.Duration(c.APITimeout) * time.Second
+}
+
+// DispatcherConfig 事件分发器配置
+type DispatcherConfig struct {
+	Async bool `yaml:"async"`
+}
+
+// MiddlewareConfig 中间件配置
+type MiddlewareConfig struct {
+	Logging   ToggleConfig    `yaml:"logging"`
+	Recovery  ToggleConfig    `yaml:"recovery"`
+	Timeout   TimeoutConfig   `yaml:"timeout"`
+	RateLimit RateLimitConfig `yaml:"rate_limit"`
+}
+
+// ToggleConfig 只有开关的中间件配置
+type ToggleConfig struct {
+	Enabled bool `yaml:"enabled"`
+}
+
+// TimeoutConfig 超时中间件配置
+type TimeoutConfig struct {
+	Enabled  bool `yaml:"enabled"`
+	Duration int  `yaml:"duration"` // 秒
+}
+
+// RateLimitConfig 限流中间件配置
+type RateLimitConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Limit   int    `yaml:"limit"`  // 时间窗口内允许的次数
+	Window  int    `yaml:"window"` // 时间窗口（秒）
+	Key     string `yaml:"key"`    // 限流维度: user, group, global
+}
+
+// CommandsConfig 命令处理器配置
+type CommandsConfig struct {
+	Prefix          string   `yaml:"prefix"`
+	EnabledCommands []string `yaml:"enabled_commands"` // 为空表示启用全部命令
+	DenyMessage     *string  `yaml:"deny_message"`     // 权限不足时的回复，%s 为所需权限；空字符串表示不回复
+}
+
+// FilterConfig 消息过滤配置
+type FilterConfig struct {
+	BannedWords []string `yaml:"banned_words"` // 包含这些词的消息不会被后续处理器处理，为空表示不过滤
+}
+
+// StorageConfig 插件存储配置
+type StorageConfig struct {
+	Backend string `yaml:"backend"` // file（data_dir/storage.log）, memory（不持久化）
+}
+
+// SchedulerConfig 定时任务配置
+type SchedulerConfig struct {
+	Timezone string `yaml:"timezone"` // cron 表达式使用的时区，如 Asia/Shanghai；为空表示系统时区
+}
+
+// Location 返回时区，名称无效时返回系统时区（Validate 会提前报错）
+func (c SchedulerConfig) Location() *time.Location {
+	if c.Timezone == "" {
+		return time.Local
+	}
+	loc, err := time.LoadLocation(c.Timezone)
+	if err != nil {
+		return time.Local
+	}
+	return loc
+}
+
+// HistoryConfig 消息历史配置
+type HistoryConfig struct {
+	MaxRecords int `yaml:"max_records"` // 最多保留的消息条数
+	MaxAge     int `yaml:"max_age"`     // 最长保留时间（秒），0 表示只按条数限制
+}
+
+// MaxAgeDuration 返回最长保留时间
+func (c HistoryConfig) MaxAgeDuration() time.Duration {
+	return time.Duration(c.MaxAge) * time.Second
+}
+
+// CacheConfig 群信息、群成员信息和好友列表缓存配置
+type CacheConfig struct {
+	Enabled     bool `yaml:"enabled"`
+	GroupTTL    int  `yaml:"group_ttl"`    // 群信息和群列表的有效期（秒）
+	MemberTTL   int  `yaml:"member_ttl"`   // 群成员信息和群成员列表的有效期（秒）
+	FriendTTL   int  `yaml:"friend_ttl"`   // 好友列表的有效期（秒）
+	Warm        bool `yaml:"warm"`         // 连接建立后预先获取群列表和好友列表
+	WarmMembers bool `yaml:"warm_members"` // 预热时同时获取每个群的成员列表，群较多时会产生大量 API 调用
+}
+
+// CacheOptions 返回 infocache 使用的配置
+func (c CacheConfig) CacheOptions() infocache.Config {
+	return infocache.Config{
+		GroupTTL:  time.Duration(c.GroupTTL) * time.Second,
+		MemberTTL: time.Duration(c.MemberTTL) * time.Second,
+		FriendTTL: time.Duration(c.FriendTTL) * time.Second,
+	}
+}
+
+// ThrottleConfig 发送限流配置，限制数为 0 表示不限制
+type ThrottleConfig struct {
+	Enabled       bool `yaml:"enabled"`
+	AccountLimit  int  `yaml:"account_limit"`   // 每个账号在 account_window 内最多发送的消息数
+	AccountWindow int  `yaml:"account_window"`  // 账号限制的窗口（秒）
+	GroupLimit    int  `yaml:"group_limit"`     // 每个群在 group_window 内最多发送的消息数
+	GroupWindow   int  `yaml:"group_window"`    // 群限制的窗口（秒）
+	MinIntervalMS int  `yaml:"min_interval_ms"` // 同一账号两条消息的最小间隔（毫秒）
+	JitterMS      int  `yaml:"jitter_ms"`       // 在最小间隔上随机增加的时间上限（毫秒）
+	MaxWait       int  `yaml:"max_wait"`        // 最长排队时间（秒），超过时丢弃，0 表示不限制
+	QueueSize     int  `yaml:"queue_size"`      // 每个账号最多排队的消息数，0 表示不限制
+	AdminPriority bool `yaml:"admin_priority"`  // 回复管理员（admins）的消息优先发送
+}
+
+// ThrottleOptions 返回 throttle 使用的配置
+func (c ThrottleConfig) ThrottleOptions() throttle.Config {
+	return throttle.Config{
+		Enabled:       c.Enabled,
+		AccountLimit:  c.AccountLimit,
+		AccountWindow: time.Duration(c.AccountWindow) * time.Second,
+		GroupLimit:    c.GroupLimit,
+		GroupWindow:   time.Duration(c.GroupWindow) * time.Second,
+		MinInterval:   time.Duration(c.MinIntervalMS) * time.Millisecond,
+		Jitter:        time.Duration(c.JitterMS) * time.Millisecond,
+		MaxWait:       time.Duration(c.MaxWait) * time.Second,
+		QueueSize:     c.QueueSize,
+	}
+}
+
+// LongMessageConfig 超长消息处理配置
+type LongMessageConfig struct {
+	Mode            string `yaml:"mode"`             // 超长时的处理方式：split（拆分）、forward（合并转发）或 off
+	MaxLength       int    `yaml:"max_length"`       // 每条消息文本的最大字符数，0 表示不限制
+	MaxParts        int    `yaml:"max_parts"`        // 拆分的最大条数，超过时改为合并转发，0 表示不限制
+	ForwardNickname string `yaml:"forward_nickname"` // 合并转发节点的昵称，为空时使用机器人昵称
+}
+
+// LongMessageOptions 返回 longmsg 使用的配置
+func (c LongMessageConfig) LongMessageOptions() longmsg.Config {
+	return longmsg.Config{
+		Mode:            longmsg.Mode(c.Mode),
+		MaxLength:       c.MaxLength,
+		MaxParts:        c.MaxParts,
+		ForwardNickname: c.ForwardNickname,
+	}
+}
+
+// MetricsConfig 运行指标配置
+type MetricsConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Path    string `yaml:"path"` // Prometheus 文本格式的指标地址
+}
+
+// TraceConfig 追踪配置
+type TraceConfig struct {
+	File string `yaml:"file"` // span 导出文件（JSON Lines），为空表示不导出
+}
+
+// RecordConfig 原始流量录制配置
+type RecordConfig struct {
+	File   string   `yaml:"file"`   // 录制文件（JSON Lines），为空表示不录制
+	Redact []string `yaml:"redact"` // 写入前脱敏的字段名（任意层级），字符串替换为 [REDACTED]，数字替换为 0
+}
+
+// AdminAPIConfig 管理 HTTP API 配置
+type AdminAPIConfig struct {
+	Enabled      bool           `yaml:"enabled"`
+	Keys         []APIKeyConfig `yaml:"keys"`
+	AuditLog     string         `yaml:"audit_log"`     // 审计日志文件（JSON Lines），为空表示写入普通日志
+	StreamBuffer int            `yaml:"stream_buffer"` // /events/stream 每个订阅者的缓冲条目数，读取过慢的订阅者被断开
+}
+
+// APIKeyConfig 管理 API 密钥
+type APIKeyConfig struct {
+	Name    string   `yaml:"name"`    // 密钥名称，记录在审计日志中
+	Key     string   `yaml:"key"`     // 请求头 Authorization: Bearer <key> 或 X-API-Key: <key>
+	Actions []string `yaml:"actions"` // 允许调用的 action，"*" 表示全部
+}
+
+// LoggingConfig 日志配置
+type LoggingConfig struct {
+	Level  string `yaml:"level"`  // debug, info, warn, error
+	Format string `yaml:"format"` // text, json
+	File   string `yaml:"file"`   // 为空表示输出到控制台
+}
+
+// Default 返回默认配置
+func Default() *Config {
+	return &Config{
+		Server: ServerConfig{
+			Host: "0.0.0.0",
+			Port: 8080,
+		},
+		OneBot: OneBotConfig{
+			APITimeout: 10,
+		},
+		Middleware: MiddlewareConfig{
+			Logging:  ToggleConfig{Enabled: true},
+			Recovery: ToggleConfig{Enabled: true},
+			Timeout:  TimeoutConfig{Duration: 5},
+			RateLimit: RateLimitConfig{
+				Limit:  100,
+				Window: 60,
+				Key:    "user",
+			},
+		},
+		Commands: CommandsConfig{
+			Prefix: "/",
+		},
+		Logging: LoggingConfig{
+			Level:  "info",
+			Format: "text",
+		},
+		DataDir: "data",
+		Storage: StorageConfig{
+			Backend: "file",
+		},
+		History: HistoryConfig{
+			MaxRecords: 10000,
+			MaxAge:     86400,
+		},
+		Cache: CacheConfig{
+			Enabled:   true,
+			GroupTTL:  300,
+			MemberTTL: 300,
+			FriendTTL: 600,
+			Warm:      true,
+		},
+		Throttle: ThrottleConfig{
+			AccountLimit:  20,
+			AccountWindow: 60,
+			GroupLimit:    5,
+			GroupWindow:   10,
+			MinIntervalMS: 500,
+			JitterMS:      500,
+			MaxWait:       120,
+			QueueSize:     200,
+			AdminPriority: true,
+		},
+		LongMsg: LongMessageConfig{
+			Mode:      string(longmsg.ModeSplit),
+			MaxLength: 1500,
+			MaxParts:  5,
+		},
+		Metrics: MetricsConfig{
+			Enabled: true,
+			Path:    "/metrics",
+		},
+		AdminAPI: AdminAPIConfig{
+			StreamBuffer: 256,
+		},
+		Record: RecordConfig{
+			Redact: append([]string(nil), record.DefaultRedact...),
+		},
+	}
+}
+
+// Load 加载配置：默认值 -> 配置文件 -> 环境变量，最后进行校验
+// path 为空时使用 DefaultPath，且文件不存在时只使用默认值和环境变量
+func Load(path string) (*Config, error) {
+	cfg := Default()
+
+	optional := path == ""
+	if optional {
+		path = DefaultPath
+	}
+
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := Parse(data, cfg); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	case optional && errors.Is(err, os.ErrNotExist):
+		logger().Info("Config file not found, using defaults", "path", path)
+	default:
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	if err := cfg.ApplyEnv(); err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Parse 将 YAML 解析到 cfg 中，未出现的字段保持原值
+func Parse(data []byte, cfg *Config) error {
+	if err := yaml.UnmarshalWithOptions(data, cfg, yaml.DisallowUnknownField()); err != nil {
+		return errors.New(yaml.FormatError(err, false, true))
+	}
+	return nil
+}
+
+// ============ 环境变量覆盖 ============
+
+// ApplyEnv 使用环境变量覆盖配置
+//
+//	ONEBOT_TOKEN           onebot.token
+//	ONEBOT_API_TIMEOUT     onebot.api_timeout
+//	ONEBOT_HOST            server.host
+//	PORT / ONEBOT_PORT     server.port
+//	ONEBOT_ASYNC           dispatcher.async
+//	ONEBOT_COMMAND_PREFIX  commands.prefix
+//	ONEBOT_LOG_LEVEL       logging.level
+//	ONEBOT_LOG_FORMAT      logging.format
+//	ONEBOT_LOG_FILE        logging.file
+//	ONEBOT_ADMINS          admins（逗号分隔）
+//	ONEBOT_DATA_DIR        data_dir
+func (c *Config) ApplyEnv() error {
+	var errs []error
+
+	setString := func(name string, target *string) {
+		if val, ok := os.LookupEnv(name); ok {
+			*target = val
+		}
+	}
+	setInt := func(name string, target *int) {
+		if val, ok := os.LookupEnv(name); ok {
+			n, err := strconv.Atoi(val)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %q is not an integer", name, val))
+				return
+			}
+			*target = n
+		}
+	}
+
+	setString("ONEBOT_TOKEN", &c.OneBot.Token)
+	setInt("ONEBOT_API_TIMEOUT", &c.OneBot.APITimeout)
+	setString("ONEBOT_HOST", &c.Server.Host)
+	setInt("PORT", &c.Server.Port)
+	setInt("ONEBOT_PORT", &c.Server.Port)
+	setString("ONEBOT_COMMAND_PREFIX", &c.Commands.Prefix)
+	setString("ONEBOT_LOG_LEVEL", &c.Logging.Level)
+	setString("ONEBOT_LOG_FORMAT", &c.Logging.Format)
+	setString("ONEBOT_LOG_FILE", &c.Logging.File)
+	setString("ONEBOT_DATA_DIR", &c.DataDir)
+
+	if val, ok := os.LookupEnv("ONEBOT_ASYNC"); ok {
+		async, err := strconv.ParseBool(val)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("ONEBOT_ASYNC: %q is not a boolean", val))
+		} else {
+			c.Dispatcher.Async = async
+		}
+	}
+
+	if val, ok := os.LookupEnv("ONEBOT_ADMINS"); ok {
+		c.Admins = nil
+		for _, field := range strings.Split(val, ",") {
+			field = strings.TrimSpace(field)
+			if field == "" {
+				continue
+			}
+			userID, err := strconv.ParseInt(field, 10, 64)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("ONEBOT_ADMINS: %q is not a QQ number", field))
+				continue
+			}
+			c.Admins = append(c.Admins, userID)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// ============ 校验 ============
+
+// Validate 校验配置，返回所有错误
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, field, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: %s", field, fmt.Sprintf(format, args...)))
+		}
+	}
+
+	check(c.Server.Port > 0 && c.Server.Port <= 65535, "server.port", "must be between 1 and 65535, got %d", c.Server.Port)
+	check(c.OneBot.APITimeout > 0, "onebot.api_timeout", "must be a positive number of seconds, got %d", c.OneBot.APITimeout)
+
+	if c.Middleware.Timeout.Enabled {
+		check(c.Middleware.Timeout.Duration > 0, "middleware.timeout.duration", "must be a positive number of seconds, got %d", c.Middleware.Timeout.Duration)
+	}
+	if c.Middleware.RateLimit.Enabled {
+		check(c.Middleware.RateLimit.Limit > 0, "middleware.rate_limit.limit", "must be positive, got %d", c.Middleware.RateLimit.Limit)
+		check(c.Middleware.RateLimit.Window > 0, "middleware.rate_limit.window", "must be a positive number of seconds, got %d", c.Middleware.RateLimit.Window)
+		check(oneOf(c.Middleware.RateLimit.Key, "user", "group", "global"), "middleware.rate_limit.key", "must be one of user, group, global, got %q", c.Middleware.RateLimit.Key)
+	}
+
+	check(c.Commands.Prefix != "" && strings.TrimSpace(c.Commands.Prefix) == c.Commands.Prefix,
+		"commands.prefix", "must be non-empty and contain no surrounding spaces, got %q", c.Commands.Prefix)
+	for i, name := range c.Commands.EnabledCommands {
+		check(name != "" && !strings.ContainsAny(name, " \t"), fmt.Sprintf("commands.enabled_commands[%d]", i), "invalid command name %q", name)
+	}
+	if msg := c.Commands.DenyMessage; msg != nil {
+		verbs := strings.Count(*msg, "%")
+		check(verbs <= 1 && verbs == strings.Count(*msg, "%s"), "commands.deny_message", "may contain at most one %%s and no other %% verbs, got %q", *msg)
+	}
+
+	for i, word := range c.Filter.BannedWords {
+		check(strings.TrimSpace(word) != "", fmt.Sprintf("filter.banned_words[%d]", i), "must not be empty")
+	}
+
+	check(oneOf(c.Logging.Level, "debug", "info", "warn", "error"), "logging.level", "must be one of debug, info, warn, error, got %q", c.Logging.Level)
+	check(oneOf(c.Logging.Format, "text", "json"), "logging.format", "must be text or json, got %q", c.Logging.Format)
+
+	for i, userID := range c.Admins {
+		check(userID > 0, fmt.Sprintf("admins[%d]", i), "must be a QQ number, got %d", userID)
+	}
+	check(c.DataDir != "", "data_dir", "must not be empty")
+	check(oneOf(c.Storage.Backend, "file", "memory"), "storage.backend", "must be file or memory, got %q", c.Storage.Backend)
+	check(c.History.MaxRecords > 0, "history.max_records", "must be positive, got %d", c.History.MaxRecords)
+	check(c.History.MaxAge >= 0, "history.max_age", "must not be negative, got %d", c.History.MaxAge)
+	if c.Cache.Enabled {
+		check(c.Cache.GroupTTL > 0, "cache.group_ttl", "must be a positive number of seconds, got %d", c.Cache.GroupTTL)
+		check(c.Cache.MemberTTL > 0, "cache.member_ttl", "must be a positive number of seconds, got %d", c.Cache.MemberTTL)
+		check(c.Cache.FriendTTL > 0, "cache.friend_ttl", "must be a positive number of seconds, got %d", c.Cache.FriendTTL)
+	}
+	check(c.Throttle.AccountLimit >= 0, "throttle.account_limit", "must not be negative, got %d", c.Throttle.AccountLimit)
+	check(c.Throttle.AccountLimit == 0 || c.Throttle.AccountWindow > 0, "throttle.account_window", "must be a positive number of seconds when account_limit is set, got %d", c.Throttle.AccountWindow)
+	check(c.Throttle.GroupLimit >= 0, "throttle.group_limit", "must not be negative, got %d", c.Throttle.GroupLimit)
+	check(c.Throttle.GroupLimit == 0 || c.Throttle.GroupWindow > 0, "throttle.group_window", "must be a positive number of seconds when group_limit is set, got %d", c.Throttle.GroupWindow)
+	check(c.Throttle.MinIntervalMS >= 0, "throttle.min_interval_ms", "must not be negative, got %d", c.Throttle.MinIntervalMS)
+	check(c.Throttle.JitterMS >= 0, "throttle.jitter_ms", "must not be negative, got %d", c.Throttle.JitterMS)
+	check(c.Throttle.MaxWait >= 0, "throttle.max_wait", "must not be negative, got %d", c.Throttle.MaxWait)
+	check(c.Throttle.QueueSize >= 0, "throttle.queue_size", "must not be negative, got %d", c.Throttle.QueueSize)
+	check(longmsg.Mode(c.LongMsg.Mode).Valid(), "long_message.mode", "must be split, forward or off, got %q", c.LongMsg.Mode)
+	check(c.LongMsg.MaxLength >= 0, "long_message.max_length", "must not be negative, got %d", c.LongMsg.MaxLength)
+	check(c.LongMsg.MaxParts >= 0, "long_message.max_parts", "must not be negative, got %d", c.LongMsg.MaxParts)
+	if c.Metrics.Enabled {
+		check(strings.HasPrefix(c.Metrics.Path, "/") && c.Metrics.Path != "/ws" && c.Metrics.Path != "/health" && !strings.HasPrefix(c.Metrics.Path, "/admin/"),
+			"metrics.path", "must start with / and not conflict with /ws, /health or /admin/, got %q", c.Metrics.Path)
+	}
+	if c.AdminAPI.Enabled {
+		check(len(c.AdminAPI.Keys) > 0, "admin_api.keys", "must not be empty when admin_api is enabled")
+		check(c.AdminAPI.StreamBuffer > 0, "admin_api.stream_buffer", "must be positive, got %d", c.AdminAPI.StreamBuffer)
+	}
+	names := make(map[string]bool)
+	for i, key := range c.AdminAPI.Keys {
+		field := fmt.Sprintf("admin_api.keys[%d]", i)
+		check(key.Name != "" && !names[key.Name], field+".name", "must be unique and not empty, got %q", key.Name)
+		check(len(key.Key) >= 16, field+".key", "must be at least 16 characters")
+		check(len(key.Actions) > 0, field+".actions", "must not be empty, use \"*\" to allow all actions")
+		names[key.Name] = true
+	}
+	if c.Scheduler.Timezone != "" {
+		_, err := time.LoadLocation(c.Scheduler.Timezone)
+		check(err == nil, "scheduler.timezone", "unknown time zone %q", c.Scheduler.Timezone)
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid config:\n%w", errors.Join(errs...))
+	}
+	return nil
+}
+
+func oneOf(value string, options ...string) bool {
+	for _, option := range options {
+		if value == option {
+			return true
+		}
+	}
+	return false
+}
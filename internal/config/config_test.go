@@ -0,0 +1,304 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// envNames ApplyEnv 读取的环境变量
+var envNames = []string{
+	"ONEBOT_TOKEN", "ONEBOT_API_TIMEOUT", "ONEBOT_HOST", "PORT", "ONEBOT_PORT", "ONEBOT_ASYNC",
+	"ONEBOT_COMMAND_PREFIX", "ONEBOT_LOG_LEVEL", "ONEBOT_LOG_FORMAT", "ONEBOT_LOG_FILE",
+	"ONEBOT_ADMINS", "ONEBOT_DATA_DIR",
+}
+
+// clearEnv 清除测试进程中的配置环境变量，测试结束后恢复
+func clearEnv(t *testing.T) {
+	t.Helper()
+	for _, name := range envNames {
+		t.Setenv(name, "")
+		os.Unsetenv(name)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(c *Config)
+		fields []string // 期望错误中出现的字段，为空表示校验通过
+	}{
+		{name: "default", modify: func(c *Config) {}},
+		{
+			name:   "port out of range",
+			modify: func(c *Config) { c.Server.Port = 70000 },
+			fields: []string{"server.port"},
+		},
+		{
+			name: "rate limit checked only when enabled",
+			modify: func(c *Config) {
+				c.Middleware.RateLimit.Key = "nobody"
+				c.Middleware.RateLimit.Window = 0
+			},
+		},
+		{
+			name: "rate limit enabled",
+			modify: func(c *Config) {
+				c.Middleware.RateLimit.Enabled = true
+				c.Middleware.RateLimit.Key = "nobody"
+				c.Middleware.RateLimit.Window = 0
+			},
+			fields: []string{"middleware.rate_limit.key", "middleware.rate_limit.window"},
+		},
+		{
+			name:   "prefix with spaces",
+			modify: func(c *Config) { c.Commands.Prefix = " /" },
+			fields: []string{"commands.prefix"},
+		},
+		{
+			name: "deny message with extra verbs",
+			modify: func(c *Config) {
+				msg := "需要 %s，%d"
+				c.Commands.DenyMessage = &msg
+			},
+			fields: []string{"commands.deny_message"},
+		},
+		{
+			name: "deny message without verbs",
+			modify: func(c *Config) {
+				msg := "没有权限"
+				c.Commands.DenyMessage = &msg
+			},
+		},
+		{
+			name: "all errors are reported",
+			modify: func(c *Config) {
+				c.Logging.Level = "verbose"
+				c.Logging.Format = "xml"
+				c.Admins = []int64{0}
+				c.Storage.Backend = "redis"
+			},
+			fields: []string{"logging.level", "logging.format", "admins[0]", "storage.backend"},
+		},
+		{
+			name: "throttle window required with limit",
+			modify: func(c *Config) {
+				c.Throttle.GroupWindow = 0
+			},
+			fields: []string{"throttle.group_window"},
+		},
+		{
+			name: "throttle disabled without window",
+			modify: func(c *Config) {
+				c.Throttle.GroupLimit = 0
+				c.Throttle.GroupWindow = 0
+			},
+		},
+		{
+			name:   "long message mode",
+			modify: func(c *Config) { c.LongMsg.Mode = "truncate" },
+			fields: []string{"long_message.mode"},
+		},
+		{
+			name:   "metrics path conflicts",
+			modify: func(c *Config) { c.Metrics.Path = "/admin/metrics" },
+			fields: []string{"metrics.path"},
+		},
+		{
+			name: "admin api keys",
+			modify: func(c *Config) {
+				c.AdminAPI.Enabled = true
+				c.AdminAPI.Keys = []APIKeyConfig{
+					{Name: "ops", Key: "0123456789abcdef", Actions: []string{"*"}},
+					{Name: "ops", Key: "short"},
+				}
+			},
+			fields: []string{"admin_api.keys[1].name", "admin_api.keys[1].key", "admin_api.keys[1].actions"},
+		},
+		{
+			name:   "admin api enabled without keys",
+			modify: func(c *Config) { c.AdminAPI.Enabled = true },
+			fields: []string{"admin_api.keys"},
+		},
+		{
+			name:   "unknown time zone",
+			modify: func(c *Config) { c.Scheduler.Timezone = "Mars/Olympus" },
+			fields: []string{"scheduler.timezone"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Default()
+			tt.modify(cfg)
+			err := cfg.Validate()
+			if len(tt.fields) == 0 {
+				if err != nil {
+					t.Fatalf("Validate() error = %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("Validate() = nil, want errors for %v", tt.fields)
+			}
+			for _, field := range tt.fields {
+				if !strings.Contains(err.Error(), field+":") {
+					t.Errorf("Validate() error does not mention %s:\n%v", field, err)
+				}
+			}
+		})
+	}
+}
+
+func TestApplyEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		check   func(c *Config) bool
+		wantErr string
+	}{
+		{
+			name: "strings",
+			env:  map[string]string{"ONEBOT_TOKEN": "secret", "ONEBOT_COMMAND_PREFIX": "!", "ONEBOT_DATA_DIR": "/tmp/bot"},
+			check: func(c *Config) bool {
+				return c.OneBot.Token == "secret" && c.Commands.Prefix == "!" && c.DataDir == "/tmp/bot"
+			},
+		},
+		{
+			name:  "ONEBOT_PORT overrides PORT",
+			env:   map[string]string{"PORT": "9000", "ONEBOT_PORT": "9001"},
+			check: func(c *Config) bool { return c.Server.Port == 9001 },
+		},
+		{
+			name:  "PORT",
+			env:   map[string]string{"PORT": "9000"},
+			check: func(c *Config) bool { return c.Server.Port == 9000 },
+		},
+		{
+			name:    "invalid integer",
+			env:     map[string]string{"ONEBOT_API_TIMEOUT": "ten"},
+			wantErr: "ONEBOT_API_TIMEOUT",
+		},
+		{
+			name:  "async",
+			env:   map[string]string{"ONEBOT_ASYNC": "true"},
+			check: func(c *Config) bool { return c.Dispatcher.Async },
+		},
+		{
+			name:    "invalid async",
+			env:     map[string]string{"ONEBOT_ASYNC": "maybe"},
+			wantErr: "ONEBOT_ASYNC",
+		},
+		{
+			name:  "admins replace config",
+			env:   map[string]string{"ONEBOT_ADMINS": " 10001, ,10002 "},
+			check: func(c *Config) bool { return reflect.DeepEqual(c.Admins, []int64{10001, 10002}) },
+		},
+		{
+			name:  "empty admins clears config",
+			env:   map[string]string{"ONEBOT_ADMINS": ""},
+			check: func(c *Config) bool { return len(c.Admins) == 0 },
+		},
+		{
+			name:    "invalid admin",
+			env:     map[string]string{"ONEBOT_ADMINS": "10001,abc"},
+			wantErr: `"abc"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t)
+			for name, val := range tt.env {
+				t.Setenv(name, val)
+			}
+			cfg := Default()
+			cfg.Admins = []int64{1}
+
+			err := cfg.ApplyEnv()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ApplyEnv() error = %v, want it to mention %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ApplyEnv() error = %v", err)
+			}
+			if !tt.check(cfg) {
+				t.Errorf("ApplyEnv() produced unexpected config: %+v", cfg)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		env     map[string]string
+		port    int
+		wantErr string
+	}{
+		{
+			name: "file overrides defaults",
+			path: write("file.yaml", "server:\n  port: 9000\n"),
+			port: 9000,
+		},
+		{
+			name: "env overrides file",
+			path: write("env.yaml", "server:\n  port: 9000\n"),
+			env:  map[string]string{"ONEBOT_PORT": "9001"},
+			port: 9001,
+		},
+		{
+			name:    "unknown field",
+			path:    write("unknown.yaml", "server:\n  prot: 9000\n"),
+			wantErr: "prot",
+		},
+		{
+			name:    "invalid after env",
+			path:    write("invalid.yaml", "server:\n  port: 9000\n"),
+			env:     map[string]string{"ONEBOT_LOG_LEVEL": "loud"},
+			wantErr: "logging.level",
+		},
+		{
+			name:    "explicit path must exist",
+			path:    filepath.Join(dir, "missing.yaml"),
+			wantErr: "failed to read config",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t)
+			for name, val := range tt.env {
+				t.Setenv(name, val)
+			}
+			cfg, err := Load(tt.path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Load() error = %v, want it to mention %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if cfg.Server.Port != tt.port {
+				t.Errorf("Server.Port = %d, want %d", cfg.Server.Port, tt.port)
+			}
+		})
+	}
+}
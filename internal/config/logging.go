@@ -0,0 +1,58 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+)
+
+// logger 返回配置模块使用的日志记录器
+func logger() *slog.Logger {
+	return slog.Default().With("component", "config")
+}
+
+// ParseLevel 将配置中的日志级别转换为 slog.Level
+func ParseLevel(level string) slog.Level {
+	switch level {
+	case "debug":
+		return slog.LevelDebug
+	case "warn":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// SetupLogging 根据日志配置设置默认 logger
+// 标准库 log 包的输出也会经过该 logger（按 info 级别处理），
+// 返回的 LevelVar 可用于运行时调整级别，Closer 用于关闭日志文件
+func SetupLogging(c LoggingConfig) (*slog.LevelVar, io.Closer, error) {
+	var out io.Writer = os.Stderr
+	var closer io.Closer = io.NopCloser(nil)
+
+	if c.File != "" {
+		file, err := os.OpenFile(c.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to open log file: %w", err)
+		}
+		out = file
+		closer = file
+	}
+
+	level := new(slog.LevelVar)
+	level.Set(ParseLevel(c.Level))
+
+	opts := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler
+	if c.Format == "json" {
+		handler = slog.NewJSONHandler(out, opts)
+	} else {
+		handler = slog.NewTextHandler(out, opts)
+	}
+
+	slog.SetDefault(slog.New(handler))
+	return level, closer, nil
+}
@@ -0,0 +1,194 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"os/signal"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// DefaultPollInterval 默认的配置文件轮询间隔
+const DefaultPollInterval = 5 * time.Second
+
+// ChangeEvent 配置变更事件
+// 热重载成功后通知订阅者，也会作为事件分发给 Dispatcher 中注册的 *config.ChangeEvent 处理器
+type ChangeEvent struct {
+	Old *Config
+	New *Config
+}
+
+// RestartRequired 返回发生变化但需要重启才能生效的配置项
+func (e *ChangeEvent) RestartRequired() []string {
+	var fields []string
+	check := func(field string, old, new interface{}) {
+		if !reflect.DeepEqual(old, new) {
+			fields = append(fields, field)
+		}
+	}
+
+	check("server", e.Old.Server, e.New.Server)
+	check("onebot", e.Old.OneBot, e.New.OneBot)
+	check("dispatcher", e.Old.Dispatcher, e.New.Dispatcher)
+	check("middleware.logging", e.Old.Middleware.Logging, e.New.Middleware.Logging)
+	check("middleware.recovery", e.Old.Middleware.Recovery, e.New.Middleware.Recovery)
+	check("middleware.timeout", e.Old.Middleware.Timeout, e.New.Middleware.Timeout)
+	check("logging.format", e.Old.Logging.Format, e.New.Logging.Format)
+	check("logging.file", e.Old.Logging.File, e.New.Logging.File)
+	check("data_dir", e.Old.DataDir, e.New.DataDir)
+	check("storage", e.Old.Storage, e.New.Storage)
+	check("scheduler", e.Old.Scheduler, e.New.Scheduler)
+	check("history", e.Old.History, e.New.History)
+	check("cache", e.Old.Cache, e.New.Cache)
+	check("metrics", e.Old.Metrics, e.New.Metrics)
+	check("trace", e.Old.Trace, e.New.Trace)
+	check("admin_api", e.Old.AdminAPI, e.New.AdminAPI)
+	check("record", e.Old.Record, e.New.Record)
+	return fields
+}
+
+// Watcher 配置文件监视器
+// 收到 SIGHUP 或轮询发现文件内容变化时重新加载配置，校验失败时保留旧配置
+type Watcher struct {
+	path     string
+	current  atomic.Pointer[Config]
+	lastData []byte
+
+	mu          sync.Mutex // 保证同一时间只有一次重载
+	subscribers []func(*ChangeEvent)
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewWatcher 创建配置监视器，initial 为启动时加载的配置
+func NewWatcher(path string, initial *Config) *Watcher {
+	if path == "" {
+		path = DefaultPath
+	}
+	w := &Watcher{path: path}
+	w.current.Store(initial)
+	w.lastData, _ = os.ReadFile(path)
+	return w
+}
+
+// Current 返回当前生效的配置
+func (w *Watcher) Current() *Config {
+	return w.current.Load()
+}
+
+// Subscribe 订阅配置变更，回调按订阅顺序同步执行（回调中不能再调用 Subscribe 或 Reload）
+func (w *Watcher) Subscribe(fn func(*ChangeEvent)) {
+	w.mu.Lock()
+	w.subscribers = append(w.subscribers, fn)
+	w.mu.Unlock()
+}
+
+// Reload 重新加载配置文件
+// 新配置校验失败时返回错误并保留旧配置；内容未变化时不通知订阅者
+func (w *Watcher) Reload() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	data, err := os.ReadFile(w.path)
+	if err != nil {
+		return err
+	}
+	w.lastData = data
+
+	cfg := Default()
+	if err := Parse(data, cfg); err != nil {
+		return err
+	}
+	if err := cfg.ApplyEnv(); err != nil {
+		return err
+	}
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	old := w.current.Load()
+	if reflect.DeepEqual(old, cfg) {
+		return nil
+	}
+	w.current.Store(cfg)
+
+	change := &ChangeEvent{Old: old, New: cfg}
+	if fields := change.RestartRequired(); len(fields) > 0 {
+		logger().Warn("Some changes require a restart to take effect", "fields", fields)
+	}
+	logger().Info("Reloaded config", "path", w.path)
+
+	for _, fn := range w.subscribers {
+		fn(change)
+	}
+	return nil
+}
+
+// Start 开始监听 SIGHUP 并按 interval 轮询配置文件
+func (w *Watcher) Start(interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+	w.stop = make(chan struct{})
+	w.done = make(chan struct{})
+
+	go w.run(interval)
+	logger().Info("Watching config (SIGHUP or file change)", "path", w.path, "interval", interval)
+}
+
+// Stop 停止监听
+func (w *Watcher) Stop() {
+	if w.stop == nil {
+		return
+	}
+	close(w.stop)
+	<-w.done
+}
+
+func (w *Watcher) run(interval time.Duration) {
+	defer close(w.done)
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-hup:
+			logger().Info("SIGHUP received, reloading config", "path", w.path)
+			w.reloadAndLog()
+		case <-ticker.C:
+			if w.fileChanged() {
+				logger().Info("Config file changed, reloading", "path", w.path)
+				w.reloadAndLog()
+			}
+		}
+	}
+}
+
+// fileChanged 判断配置文件内容是否与上次读取时不同
+func (w *Watcher) fileChanged() bool {
+	data, err := os.ReadFile(w.path)
+	if err != nil {
+		return false
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return !bytes.Equal(data, w.lastData)
+}
+
+func (w *Watcher) reloadAndLog() {
+	if err := w.Reload(); err != nil {
+		logger().Error("Reload rejected, keeping previous config", "path", w.path, "error", err)
+	}
+}
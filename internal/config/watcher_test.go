@@ -0,0 +1,76 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestWatcherReload(t *testing.T) {
+	clearEnv(t)
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("commands:\n  prefix: \"/\"\n")
+	initial, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := NewWatcher(path, initial)
+
+	var changes []*ChangeEvent
+	w.Subscribe(func(change *ChangeEvent) { changes = append(changes, change) })
+
+	steps := []struct {
+		name    string
+		content string
+		wantErr bool
+		prefix  string   // 重载后生效的命令前缀
+		notify  int      // 累计通知次数
+		restart []string // 最近一次通知中需要重启的配置项
+	}{
+		{name: "unchanged", content: "commands:\n  prefix: \"/\"\n", prefix: "/"},
+		{name: "hot change", content: "commands:\n  prefix: \"!\"\n", prefix: "!", notify: 1},
+		{name: "invalid keeps old", content: "commands:\n  prefix: \"\"\n", wantErr: true, prefix: "!", notify: 1},
+		{name: "parse error keeps old", content: "commands: [\n", wantErr: true, prefix: "!", notify: 1},
+		{
+			name:    "restart required",
+			content: "commands:\n  prefix: \"!\"\nserver:\n  port: 9000\n",
+			prefix:  "!",
+			notify:  2,
+			restart: []string{"server"},
+		},
+	}
+
+	for _, step := range steps {
+		write(step.content)
+		err := w.Reload()
+		if (err != nil) != step.wantErr {
+			t.Fatalf("%s: Reload() error = %v, wantErr %v", step.name, err, step.wantErr)
+		}
+		if got := w.Current().Commands.Prefix; got != step.prefix {
+			t.Errorf("%s: prefix = %q, want %q", step.name, got, step.prefix)
+		}
+		if len(changes) != step.notify {
+			t.Fatalf("%s: notified %d times, want %d", step.name, len(changes), step.notify)
+		}
+		if step.restart != nil {
+			if got := changes[len(changes)-1].RestartRequired(); !reflect.DeepEqual(got, step.restart) {
+				t.Errorf("%s: RestartRequired() = %v, want %v", step.name, got, step.restart)
+			}
+		}
+	}
+
+	if changes[0].Old != initial || changes[0].New.Commands.Prefix != "!" {
+		t.Errorf("first change: Old = %p (want initial %p), New.Commands.Prefix = %q", changes[0].Old, initial, changes[0].New.Commands.Prefix)
+	}
+	if got := changes[0].RestartRequired(); len(got) != 0 {
+		t.Errorf("prefix change should not require restart, got %v", got)
+	}
+}
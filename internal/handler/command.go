@@ -1,117 +1,56 @@
 package handler
 
 import (
+	"errors"
 	"fmt"
-	"log"
 	"strings"
+	"time"
 
+	"onebot-go2/internal/config"
+	"onebot-go2/pkg/command"
 	types "onebot-go2/pkg/const"
 	"onebot-go2/pkg/event"
 	"onebot-go2/pkg/message"
+	"onebot-go2/pkg/permission"
+	"onebot-go2/pkg/plugin"
+	"onebot-go2/pkg/ratelimit"
 )
 
-// CommandHandler 命令处理器
-// 支持命令路由和参数解析
-type CommandHandler struct {
-	prefix   string                                                 // 命令前缀，如 "/"
-	commands map[string]func(*event.Context[*types.MessageEvent]) error // 命令处理函数映射
-}
-
-// NewCommandHandler 创建命令处理器
-func NewCommandHandler(prefix string) *CommandHandler {
-	return &CommandHandler{
-		prefix:   prefix,
-		commands: make(map[string]func(*event.Context[*types.MessageEvent]) error),
-	}
-}
-
-// Register 注册命令
-func (h *CommandHandler) Register(command string, handler func(*event.Context[*types.MessageEvent]) error) {
-	h.commands[command] = handler
-	log.Printf("[CommandHandler] Registered command: %s%s", h.prefix, command)
-}
-
-// Handle 处理消息事件
-func (h *CommandHandler) Handle(ctx *event.Context[*types.MessageEvent]) error {
-	rawMsg := ctx.Event.RawMessage
-
-	// 检查是否以命令前缀开头
-	if !strings.HasPrefix(rawMsg, h.prefix) {
-		return nil
-	}
-
-	// 去除前缀
-	cmdLine := strings.TrimPrefix(rawMsg, h.prefix)
-
-	// 解析命令和参数
-	parts := strings.Fields(cmdLine)
-	if len(parts) == 0 {
-		return nil
-	}
-
-	command := parts[0]
-	args := parts[1:]
-
-	// 存储命令和参数到上下文，供后续使用
-	ctx.Set("command", command)
-	ctx.Set("args", args)
-
-	// 查找并执行命令处理函数
-	if handler, exists := h.commands[command]; exists {
-		log.Printf("[CommandHandler] Executing command: %s%s (args: %v)", h.prefix, command, args)
-		return handler(ctx)
-	}
-
-	return nil
-}
-
-func (h *CommandHandler) Priority() int {
-	return 30 // 较高优先级，在过滤器之后
-}
-
-func (h *CommandHandler) Name() string {
-	return "CommandHandler"
-}
-
 // ============ 示例命令实现 ============
 
 // HelpCommand /help 命令
-func HelpCommand(ctx *event.Context[*types.MessageEvent]) error {
-	helpText := `可用命令：
-/help - 显示帮助信息
-/ping - 测试响应
-/echo <文本> - 回复相同文本
-/info - 显示群/用户信息
-/ban <@用户> <时长(秒)> - 禁言用户
-/unban <@用户> - 解除禁言`
-
-	_, err := ctx.ReplyText(helpText)
+// 不带参数时列出所有命令，带命令名时显示该命令的详细用法
+func HelpCommand(ctx *event.Context[*types.MessageEvent], args *command.Args) error {
+	registry := args.Registry()
+
+	if name := args.String("command"); name != "" {
+		cmd, ok := registry.Lookup(name)
+		if !ok {
+			_, err := ctx.ReplyText(fmt.Sprintf("未知命令：%s", name))
+			return err
+		}
+		_, err := ctx.ReplyText(cmd.Usage(registry.Prefix()))
+		return err
+	}
+
+	_, err := ctx.ReplyText(registry.HelpText())
 	return err
 }
 
 // PingCommand /ping 命令
-func PingCommand(ctx *event.Context[*types.MessageEvent]) error {
+func PingCommand(ctx *event.Context[*types.MessageEvent], args *command.Args) error {
 	_, err := ctx.ReplyText("Pong!")
 	return err
 }
 
 // EchoCommand /echo 命令
-func EchoCommand(ctx *event.Context[*types.MessageEvent]) error {
-	args, _ := ctx.Get("args")
-	argList := args.([]string)
-
-	if len(argList) == 0 {
-		_, err := ctx.ReplyText("请提供要回复的内容")
-		return err
-	}
-
-	text := strings.Join(argList, " ")
-	_, err := ctx.ReplyText(text)
+func EchoCommand(ctx *event.Context[*types.MessageEvent], args *command.Args) error {
+	_, err := ctx.ReplyText(args.String("text"))
 	return err
 }
 
 // InfoCommand /info 命令 - 显示群或用户信息
-func InfoCommand(ctx *event.Context[*types.MessageEvent]) error {
+func InfoCommand(ctx *event.Context[*types.MessageEvent], args *command.Args) error {
 	if ctx.IsGroupMessage() {
 		groupID, _ := ctx.GetGroupID()
 		userID, _ := ctx.GetUserID()
@@ -157,74 +96,41 @@ func InfoCommand(ctx *event.Context[*types.MessageEvent]) error {
 }
 
 // BanCommand /ban 命令 - 禁言用户（仅管理员）
-func BanCommand(ctx *event.Context[*types.MessageEvent]) error {
+func BanCommand(ctx *event.Context[*types.MessageEvent], args *command.Args) error {
 	if !ctx.IsGroupMessage() {
 		_, err := ctx.ReplyText("该命令仅在群聊中可用")
 		return err
 	}
 
 	groupID, _ := ctx.GetGroupID()
-	args, _ := ctx.Get("args")
-	argList := args.([]string)
-
-	if len(argList) < 2 {
-		_, err := ctx.ReplyText("用法: /ban <@用户> <时长(秒)>")
-		return err
-	}
-
-	// 解析@的用户ID（简化版本，实际应该从消息段中解析）
-	// 这里假设第一个参数是用户ID
-	var targetUserID int64
-	fmt.Sscanf(argList[0], "%d", &targetUserID)
-
-	if targetUserID == 0 {
-		_, err := ctx.ReplyText("请@要禁言的用户")
-		return err
-	}
-
-	// 解析禁言时长
-	var duration int64
-	fmt.Sscanf(argList[1], "%d", &duration)
+	targetUserID := args.User("user")
+	duration := args.Duration("duration")
 
-	if duration <= 0 {
+	if duration < time.Second {
 		_, err := ctx.ReplyText("禁言时长必须大于0")
 		return err
 	}
 
 	// 执行禁言
-	if err := ctx.BanGroupMember(groupID, targetUserID, duration); err != nil {
+	seconds := int64(duration / time.Second)
+	if err := ctx.BanGroupMember(groupID, targetUserID, seconds); err != nil {
 		_, _ = ctx.ReplyText(fmt.Sprintf("禁言失败: %v", err))
 		return err
 	}
 
-	_, err := ctx.ReplyText(fmt.Sprintf("已禁言用户 %d，时长 %d 秒", targetUserID, duration))
+	_, err := ctx.ReplyText(fmt.Sprintf("已禁言用户 %d，时长 %d 秒", targetUserID, seconds))
 	return err
 }
 
 // UnbanCommand /unban 命令 - 解除禁言（仅管理员）
-func UnbanCommand(ctx *event.Context[*types.MessageEvent]) error {
+func UnbanCommand(ctx *event.Context[*types.MessageEvent], args *command.Args) error {
 	if !ctx.IsGroupMessage() {
 		_, err := ctx.ReplyText("该命令仅在群聊中可用")
 		return err
 	}
 
 	groupID, _ := ctx.GetGroupID()
-	args, _ := ctx.Get("args")
-	argList := args.([]string)
-
-	if len(argList) < 1 {
-		_, err := ctx.ReplyText("用法: /unban <@用户>")
-		return err
-	}
-
-	// 解析@的用户ID
-	var targetUserID int64
-	fmt.Sscanf(argList[0], "%d", &targetUserID)
-
-	if targetUserID == 0 {
-		_, err := ctx.ReplyText("请@要解除禁言的用户")
-		return err
-	}
+	targetUserID := args.User("user")
 
 	// 解除禁言
 	if err := ctx.UnbanGroupMember(groupID, targetUserID); err != nil {
@@ -237,54 +143,295 @@ func UnbanCommand(ctx *event.Context[*types.MessageEvent]) error {
 }
 
 // QuoteCommand /quote 命令 - 引用回复
-func QuoteCommand(ctx *event.Context[*types.MessageEvent]) error {
-	args, _ := ctx.Get("args")
-	argList := args.([]string)
-
-	if len(argList) == 0 {
-		_, err := ctx.ReplyText("请提供要回复的内容")
-		return err
-	}
-
-	text := strings.Join(argList, " ")
-	msg := message.NewBuilder().Text(text).Build()
+func QuoteCommand(ctx *event.Context[*types.MessageEvent], args *command.Args) error {
+	msg := message.NewBuilder().Text(args.String("text")).Build()
 
 	_, err := ctx.ReplyWithQuote(msg)
 	return err
 }
 
 // ImageCommand /image 命令 - 发送图片（示例）
-func ImageCommand(ctx *event.Context[*types.MessageEvent]) error {
-	args, _ := ctx.Get("args")
-	argList := args.([]string)
-
-	if len(argList) == 0 {
-		_, err := ctx.ReplyText("用法: /image <图片URL>")
-		return err
-	}
-
-	imageURL := argList[0]
-	msg := message.NewBuilder().Image(imageURL).Text("这是你要的图片").Build()
+func ImageCommand(ctx *event.Context[*types.MessageEvent], args *command.Args) error {
+	msg := message.NewBuilder().Image(args.String("url")).Text("这是你要的图片").Build()
 
 	_, err := ctx.Reply(msg)
 	return err
 }
 
+// NewAdminCommand 创建 /admin 命令 - 管理本群的机器人管理员（仅群主）
+func NewAdminCommand(permissions *permission.Manager) *command.Command {
+	userParam := []command.Param{
+		{Name: "user", Type: command.ParamUser, Description: "@用户或 QQ 号"},
+	}
+
+	return &command.Command{
+		Name:        "admin",
+		Description: "管理机器人管理员",
+		Permission:  permission.LevelGroupOwner,
+		Subcommands: []*command.Command{
+			{
+				Name:        "add",
+				Description: "添加本群机器人管理员",
+				Params:      userParam,
+				Handler: func(ctx *event.Context[*types.MessageEvent], args *command.Args) error {
+					groupID, ok := ctx.GetGroupID()
+					if !ok {
+						_, err := ctx.ReplyText("该命令仅在群聊中可用")
+						return err
+					}
+					userID := args.User("user")
+					if err := permissions.GrantAdmin(groupID, userID); err != nil {
+						_, _ = ctx.ReplyText(fmt.Sprintf("添加失败: %v", err))
+						return err
+					}
+					_, err := ctx.ReplyText(fmt.Sprintf("已将用户 %d 设为本群机器人管理员", userID))
+					return err
+				},
+			},
+			{
+				Name:        "remove",
+				Aliases:     []string{"rm"},
+				Description: "移除本群机器人管理员",
+				Params:      userParam,
+				Handler: func(ctx *event.Context[*types.MessageEvent], args *command.Args) error {
+					groupID, ok := ctx.GetGroupID()
+					if !ok {
+						_, err := ctx.ReplyText("该命令仅在群聊中可用")
+						return err
+					}
+					userID := args.User("user")
+					revoked, err := permissions.RevokeAdmin(groupID, userID)
+					if err != nil {
+						_, _ = ctx.ReplyText(fmt.Sprintf("移除失败: %v", err))
+						return err
+					}
+					if !revoked {
+						_, err := ctx.ReplyText(fmt.Sprintf("用户 %d 不是本群机器人管理员", userID))
+						return err
+					}
+					_, err = ctx.ReplyText(fmt.Sprintf("已移除用户 %d 的机器人管理员权限", userID))
+					return err
+				},
+			},
+			{
+				Name:        "list",
+				Aliases:     []string{"ls"},
+				Description: "列出本群机器人管理员",
+				Handler: func(ctx *event.Context[*types.MessageEvent], args *command.Args) error {
+					groupID, ok := ctx.GetGroupID()
+					if !ok {
+						_, err := ctx.ReplyText("该命令仅在群聊中可用")
+						return err
+					}
+					admins := permissions.Admins(groupID)
+					if len(admins) == 0 {
+						_, err := ctx.ReplyText("本群暂无机器人管理员")
+						return err
+					}
+					text := "本群机器人管理员："
+					for _, userID := range admins {
+						text += fmt.Sprintf("\n%d", userID)
+					}
+					_, err := ctx.ReplyText(text)
+					return err
+				},
+			},
+		},
+	}
+}
+
 // ============ 创建默认命令处理器的辅助函数 ============
 
-// NewDefaultCommandHandler 创建带有默认命令的命令处理器
-func NewDefaultCommandHandler() *CommandHandler {
-	handler := NewCommandHandler("/")
-
-	// 注册默认命令
-	handler.Register("help", HelpCommand)
-	handler.Register("ping", PingCommand)
-	handler.Register("echo", EchoCommand)
-	handler.Register("info", InfoCommand)
-	handler.Register("ban", BanCommand)
-	handler.Register("unban", UnbanCommand)
-	handler.Register("quote", QuoteCommand)
-	handler.Register("image", ImageCommand)
-
-	return handler
+// DefaultCommands 返回默认命令声明
+func DefaultCommands() []*command.Command {
+	return []*command.Command{
+		{
+			Name:        "help",
+			Aliases:     []string{"帮助"},
+			Description: "显示帮助信息",
+			Params: []command.Param{
+				{Name: "command", Type: command.ParamString, Optional: true, Description: "查看指定命令的用法"},
+			},
+			Handler: HelpCommand,
+		},
+		{
+			Name:        "ping",
+			Description: "测试响应",
+			Handler:     PingCommand,
+		},
+		{
+			Name:        "echo",
+			Description: "回复相同文本",
+			Params: []command.Param{
+				{Name: "text", Type: command.ParamRest, Description: "要回复的内容"},
+			},
+			Cooldown: ratelimit.NewRule(3, 10*time.Second, ratelimit.PerUser),
+			Handler:  EchoCommand,
+		},
+		{
+			Name:        "info",
+			Description: "显示群/用户信息",
+			Handler:     InfoCommand,
+		},
+		{
+			Name:        "ban",
+			Aliases:     []string{"禁言"},
+			Description: "禁言用户",
+			Params: []command.Param{
+				{Name: "user", Type: command.ParamUser, Description: "@用户或 QQ 号"},
+				{Name: "duration", Type: command.ParamDuration, Description: "禁言时长，如 60、10m、1h"},
+			},
+			Permission: permission.LevelGroupAdmin,
+			Handler:    BanCommand,
+		},
+		{
+			Name:        "unban",
+			Aliases:     []string{"解禁"},
+			Description: "解除禁言",
+			Params: []command.Param{
+				{Name: "user", Type: command.ParamUser, Description: "@用户或 QQ 号"},
+			},
+			Permission: permission.LevelGroupAdmin,
+			Handler:    UnbanCommand,
+		},
+		{
+			Name:        "quote",
+			Description: "引用回复",
+			Params: []command.Param{
+				{Name: "text", Type: command.ParamRest, Description: "要回复的内容"},
+			},
+			Handler: QuoteCommand,
+		},
+		{
+			Name:        "image",
+			Description: "发送图片",
+			Params: []command.Param{
+				{Name: "url", Type: command.ParamString, Description: "图片 URL"},
+			},
+			Cooldown: ratelimit.NewRule(1, 10*time.Second, ratelimit.PerUser),
+			Handler:  ImageCommand,
+		},
+	}
+}
+
+// ApplyCommandsConfig 将命令配置应用到注册表，配置热重载时也会调用
+func ApplyCommandsConfig(registry *command.Registry, cfg config.CommandsConfig) {
+	registry.SetPrefix(cfg.Prefix)
+	if unknown := registry.SetEnabled(cfg.EnabledCommands); len(unknown) > 0 {
+		registry.Logger().Warn("Unknown commands in enabled_commands", "commands", unknown)
+	}
+
+	denyMessage := permission.DefaultDenyMessage
+	if cfg.DenyMessage != nil {
+		denyMessage = *cfg.DenyMessage
+	}
+	registry.Permissions().SetDenyMessage(denyMessage)
+}
+
+// NewPluginCommand 创建 /plugin 命令 - 按群/按用户开关插件和命令（群管理员；按用户开关仅超级用户）
+func NewPluginCommand(plugins *plugin.Manager, switches *plugin.Switches) *command.Command {
+	toggle := func(enabled bool) command.Handler {
+		return func(ctx *event.Context[*types.MessageEvent], args *command.Args) error {
+			name := args.String("name")
+			kind := plugin.KindPlugin
+			if _, ok := plugins.Lookup(name); !ok {
+				cmd, ok := args.Registry().Lookup(name)
+				if !ok {
+					_, err := ctx.ReplyText(fmt.Sprintf("未知的插件或命令：%s", name))
+					return err
+				}
+				kind, name = plugin.KindCommand, cmd.Name
+			}
+
+			scope, id, target := plugin.ScopeGroup, int64(0), "本群"
+			if args.Has("user") {
+				if !args.Registry().Permissions().IsSuperuser(ctx.Event.UserID) {
+					return permission.Deny(args.Registry().Permissions(), ctx, permission.LevelSuperuser)
+				}
+				scope, id = plugin.ScopeUser, args.User("user")
+				target = fmt.Sprintf("用户 %d", id)
+			} else if groupID, ok := ctx.GetGroupID(); ok {
+				id = groupID
+			} else {
+				_, err := ctx.ReplyText("请在群聊中使用，或指定用户")
+				return err
+			}
+
+			if err := switches.Set(scope, id, kind, name, enabled); err != nil {
+				if errors.Is(err, plugin.ErrProtected) {
+					_, err := ctx.ReplyText(fmt.Sprintf("%s 不能被关闭", name))
+					return err
+				}
+				_, _ = ctx.ReplyText(fmt.Sprintf("保存失败: %v", err))
+				return err
+			}
+
+			state := "关闭"
+			if enabled {
+				state = "开启"
+			}
+			label := "插件"
+			if kind == plugin.KindCommand {
+				label = "命令"
+			}
+			_, err := ctx.ReplyText(fmt.Sprintf("已%s%s %s（%s）", state, label, name, target))
+			return err
+		}
+	}
+
+	toggleParams := []command.Param{
+		{Name: "name", Type: command.ParamString, Description: "插件名或命令名"},
+		{Name: "user", Type: command.ParamUser, Optional: true, Description: "只对该用户生效（仅超级用户）"},
+	}
+
+	return &command.Command{
+		Name:        "plugin",
+		Aliases:     []string{"插件"},
+		Description: "按群开关插件和命令",
+		Permission:  permission.LevelGroupAdmin,
+		Subcommands: []*command.Command{
+			{
+				Name:        "on",
+				Aliases:     []string{"enable"},
+				Description: "开启插件或命令",
+				Params:      toggleParams,
+				Handler:     toggle(true),
+			},
+			{
+				Name:        "off",
+				Aliases:     []string{"disable"},
+				Description: "关闭插件或命令",
+				Params:      toggleParams,
+				Handler:     toggle(false),
+			},
+			{
+				Name:        "list",
+				Aliases:     []string{"ls"},
+				Description: "列出插件及其在本群的状态",
+				Handler: func(ctx *event.Context[*types.MessageEvent], args *command.Args) error {
+					groupID, _ := ctx.GetGroupID()
+
+					var sb strings.Builder
+					sb.WriteString("插件列表：")
+					for _, name := range plugins.Names() {
+						state := "开启"
+						if !switches.Enabled(groupID, 0, plugin.KindPlugin, name) {
+							state = "关闭"
+						} else if s, _ := plugins.State(name); s != plugin.StateRunning {
+							state = s.String()
+						}
+						sb.WriteString(fmt.Sprintf("\n%s [%s]", name, state))
+					}
+
+					if groupID != 0 {
+						if disabled := switches.Disabled(plugin.ScopeGroup, groupID, plugin.KindCommand); len(disabled) > 0 {
+							sb.WriteString("\n本群关闭的命令：" + strings.Join(disabled, ", "))
+						}
+					}
+					_, err := ctx.ReplyText(sb.String())
+					return err
+				},
+			},
+		},
+	}
 }
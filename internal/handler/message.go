@@ -1,10 +1,10 @@
 package handler
 
 import (
-	"log"
 	"onebot-go2/pkg/event"
 	types "onebot-go2/pkg/const"
 	"strings"
+	"sync/atomic"
 )
 
 // MessageLogHandler 消息日志处理器
@@ -16,7 +16,7 @@ func NewMessageLogHandler() *MessageLogHandler {
 
 func (h *MessageLogHandler) Handle(ctx *event.Context[*types.MessageEvent]) error {
 	msg := ctx.Event
-	log.Printf("[MessageLogHandler] Received message from user %d: %s", msg.UserID, msg.RawMessage)
+	ctx.Logger().Info("Received message", "message_id", msg.MessageID, "message", msg.RawMessage)
 	
 	// 在上下文中设置一些元数据供其他处理器使用
 	ctx.Set("logged", true)
@@ -46,7 +46,7 @@ func (h *MessageEchoHandler) Handle(ctx *event.Context[*types.MessageEvent]) err
 	// 检查是否是回显命令
 	if strings.HasPrefix(msg.RawMessage, "/echo ") {
 		content := strings.TrimPrefix(msg.RawMessage, "/echo ")
-		log.Printf("[MessageEchoHandler] Echo command detected: %s", content)
+		ctx.Logger().Debug("Echo command detected", "content", content)
 		
 		ctx.Set("should_reply", true)
 		ctx.Set("reply_content", content)
@@ -65,22 +65,28 @@ func (h *MessageEchoHandler) Name() string {
 
 // MessageFilterHandler 消息过滤处理器
 type MessageFilterHandler struct {
-	bannedWords []string
+	bannedWords atomic.Pointer[[]string]
 }
 
 func NewMessageFilterHandler(bannedWords []string) *MessageFilterHandler {
-	return &MessageFilterHandler{
-		bannedWords: bannedWords,
-	}
+	h := &MessageFilterHandler{}
+	h.SetBannedWords(bannedWords)
+	return h
+}
+
+// SetBannedWords 替换禁用词列表，可在运行时调用
+func (h *MessageFilterHandler) SetBannedWords(bannedWords []string) {
+	words := append([]string{}, bannedWords...)
+	h.bannedWords.Store(&words)
 }
 
 func (h *MessageFilterHandler) Handle(ctx *event.Context[*types.MessageEvent]) error {
 	msg := ctx.Event
 	
 	// 检查是否包含禁用词
-	for _, word := range h.bannedWords {
+	for _, word := range *h.bannedWords.Load() {
 		if strings.Contains(msg.RawMessage, word) {
-			log.Printf("[MessageFilterHandler] Message contains banned word: %s", word)
+			ctx.Logger().Info("Message contains banned word", "word", word)
 			ctx.Set("filtered", true)
 			ctx.Abort() // 中止后续处理器
 			return nil
@@ -107,7 +113,7 @@ func NewNoticeHandler() *NoticeHandler {
 
 func (h *NoticeHandler) Handle(ctx *event.Context[*types.NoticeEvent]) error {
 	notice := ctx.Event
-	log.Printf("[NoticeHandler] Received notice type: %s from user %d", notice.NoticeType, notice.UserID)
+	ctx.Logger().Info("Received notice", "notice_type", notice.NoticeType, "sub_type", notice.SubType)
 	return nil
 }
 
@@ -128,7 +134,7 @@ func NewRequestHandler() *RequestHandler {
 
 func (h *RequestHandler) Handle(ctx *event.Context[*types.RequestEvent]) error {
 	req := ctx.Event
-	log.Printf("[RequestHandler] Received request type: %s from user %d", req.RequestType, req.UserID)
+	ctx.Logger().Info("Received request", "request_type", req.RequestType, "sub_type", req.SubType)
 	return nil
 }
 
@@ -0,0 +1,222 @@
+package handler
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"onebot-go2/internal/config"
+	"onebot-go2/pkg/command"
+	types "onebot-go2/pkg/const"
+	"onebot-go2/pkg/event"
+	"onebot-go2/pkg/message"
+	"onebot-go2/pkg/plugin"
+	"onebot-go2/pkg/schedule"
+)
+
+// ============ 内置插件 ============
+
+// LoggerPlugin 日志插件 - 记录消息、通知和请求事件
+type LoggerPlugin struct {
+	plugin.Base
+}
+
+func NewLoggerPlugin() *LoggerPlugin {
+	return &LoggerPlugin{}
+}
+
+func (p *LoggerPlugin) Name() string {
+	return "logger"
+}
+
+func (p *LoggerPlugin) Init(bot *plugin.Bot, cfg plugin.Config) error {
+	event.Register(bot.Handlers(), NewMessageLogHandler())
+	event.Register(bot.Handlers(), NewNoticeHandler())
+	event.Register(bot.Handlers(), NewRequestHandler())
+	return nil
+}
+
+// FilterPlugin 过滤插件 - 过滤包含禁用词的消息
+type FilterPlugin struct {
+	plugin.Base
+	filter *MessageFilterHandler
+}
+
+func NewFilterPlugin(bannedWords []string) *FilterPlugin {
+	return &FilterPlugin{filter: NewMessageFilterHandler(bannedWords)}
+}
+
+// SetBannedWords 替换禁用词列表，配置热重载时调用
+func (p *FilterPlugin) SetBannedWords(bannedWords []string) {
+	p.filter.SetBannedWords(bannedWords)
+}
+
+func (p *FilterPlugin) Name() string {
+	return "filter"
+}
+
+func (p *FilterPlugin) Init(bot *plugin.Bot, cfg plugin.Config) error {
+	return event.Register[*types.MessageEvent](bot.Handlers(), p.filter)
+}
+
+// CommandsPlugin 命令插件 - 注册内置命令并处理所有插件的命令
+type CommandsPlugin struct {
+	plugin.Base
+	registry *command.Registry
+	cfg      config.CommandsConfig
+}
+
+// NewCommandsPlugin 创建命令插件，registry 需要同时通过 plugin.Manager.SetCommands 共享给其他插件
+func NewCommandsPlugin(registry *command.Registry, cfg config.CommandsConfig) *CommandsPlugin {
+	return &CommandsPlugin{registry: registry, cfg: cfg}
+}
+
+func (p *CommandsPlugin) Name() string {
+	return "commands"
+}
+
+func (p *CommandsPlugin) Init(bot *plugin.Bot, cfg plugin.Config) error {
+	for _, cmd := range append(DefaultCommands(), NewAdminCommand(p.registry.Permissions())) {
+		if err := bot.Command(cmd); err != nil {
+			bot.Logger().Warn("Failed to register command", "command", cmd.Name, "error", err)
+		}
+	}
+	return event.Register[*types.MessageEvent](bot.Handlers(), p.registry)
+}
+
+// Start 应用命令配置；所有插件都已在 Init 中注册命令，enabled_commands 可以引用插件命令
+func (p *CommandsPlugin) Start() error {
+	ApplyCommandsConfig(p.registry, p.cfg)
+	return nil
+}
+
+// ReplyPlugin 关键词回复插件
+//
+//	plugins:
+//	  reply:
+//	    replies:
+//	      你好: 你好！我是 OneBot Go2 Bot
+type ReplyPlugin struct {
+	plugin.Base
+	replies atomic.Pointer[map[string]string]
+}
+
+// ReplyConfig 关键词回复插件配置
+type ReplyConfig struct {
+	Replies map[string]string `yaml:"replies"` // 消息完全匹配关键词时回复对应内容
+}
+
+func NewReplyPlugin() *ReplyPlugin {
+	return &ReplyPlugin{}
+}
+
+func (p *ReplyPlugin) Name() string {
+	return "reply"
+}
+
+func (p *ReplyPlugin) Init(bot *plugin.Bot, cfg plugin.Config) error {
+	if err := p.Reload(cfg); err != nil {
+		return err
+	}
+	return event.RegisterFunc(bot.Handlers(), "SimpleReplyHandler", 100, p.handle)
+}
+
+// Reload 更新关键词，配置热重载时调用
+func (p *ReplyPlugin) Reload(cfg plugin.Config) error {
+	var c ReplyConfig
+	if err := cfg.Decode(&c); err != nil {
+		return err
+	}
+	if c.Replies == nil {
+		c.Replies = map[string]string{"你好": "你好！我是 OneBot Go2 Bot"}
+	}
+	p.replies.Store(&c.Replies)
+	return nil
+}
+
+func (p *ReplyPlugin) handle(ctx *event.Context[*types.MessageEvent]) error {
+	rawMsg, _ := ctx.GetRawMessage()
+	if reply, ok := (*p.replies.Load())[strings.TrimSpace(rawMsg)]; ok && reply != "" {
+		_, err := ctx.ReplyText(reply)
+		return err
+	}
+	return nil
+}
+
+// SchedulePlugin 定时任务插件 - 按配置定时发送群消息或开关全员禁言
+//
+//	plugins:
+//	  schedule:
+//	    jobs:
+//	      - name: morning
+//	        cron: "0 9 * * *"
+//	        group_id: 123456
+//	        message: 早上好
+//	      - name: night-ban
+//	        cron: "0 23 * * *"
+//	        group_id: 123456
+//	        whole_ban: true
+type SchedulePlugin struct {
+	plugin.Base
+}
+
+// ScheduleConfig 定时任务插件配置
+type ScheduleConfig struct {
+	Jobs []ScheduledJob `yaml:"jobs"`
+}
+
+// ScheduledJob 一个定时任务，message 和 whole_ban 至少设置一个
+type ScheduledJob struct {
+	Name     string `yaml:"name"`
+	Cron     string `yaml:"cron"`      // cron 表达式，如 "0 9 * * *"
+	GroupID  int64  `yaml:"group_id"`  // 目标群号
+	Message  string `yaml:"message"`   // 发送的消息
+	WholeBan *bool  `yaml:"whole_ban"` // true 开启全员禁言，false 解除
+	CatchUp  bool   `yaml:"catch_up"`  // 断线期间错过时，重新连接后补执行
+}
+
+func NewSchedulePlugin() *SchedulePlugin {
+	return &SchedulePlugin{}
+}
+
+func (p *SchedulePlugin) Name() string {
+	return "schedule"
+}
+
+func (p *SchedulePlugin) Init(bot *plugin.Bot, cfg plugin.Config) error {
+	var c ScheduleConfig
+	if err := cfg.Decode(&c); err != nil {
+		return err
+	}
+
+	for i, job := range c.Jobs {
+		if job.Name == "" || job.GroupID == 0 || (job.Message == "" && job.WholeBan == nil) {
+			return fmt.Errorf("jobs[%d]: name, group_id and message or whole_ban are required", i)
+		}
+		var opts []schedule.JobOption
+		if job.CatchUp {
+			opts = append(opts, schedule.OnMiss(schedule.CatchUp))
+		}
+		if err := bot.Cron(job.Name, job.Cron, job.run, opts...); err != nil {
+			return fmt.Errorf("jobs[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func (job ScheduledJob) run(ctx *event.Context[*schedule.Tick]) error {
+	if job.WholeBan != nil {
+		if *job.WholeBan {
+			if err := ctx.BanAllGroupMembers(job.GroupID); err != nil {
+				return err
+			}
+		} else if err := ctx.UnbanAllGroupMembers(job.GroupID); err != nil {
+			return err
+		}
+	}
+	if job.Message != "" {
+		_, err := ctx.SendGroupMsg(job.GroupID, message.Text(job.Message))
+		return err
+	}
+	return nil
+}
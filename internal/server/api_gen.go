@@ -0,0 +1,441 @@
+// Code generated by apigen from actions.yaml. DO NOT EDIT.
+
+package server
+
+import (
+	"fmt"
+
+	types "onebot-go2/pkg/const"
+)
+
+// ============ 消息相关 API ============
+
+// GetMsg 获取消息
+func (s *WSServer) GetMsg(messageID int32) (*types.GetMsgResponse, error) {
+	params := types.GetMsgParams{
+		MessageID: messageID,
+	}
+
+	resp, err := s.CallAPI(types.ActionGetMsg, params)
+	if err != nil {
+		return nil, err
+	}
+
+	var result types.GetMsgResponse
+	if err := mapToStruct(resp.Data, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return &result, nil
+}
+
+// GetForwardMsg 获取合并转发消息
+func (s *WSServer) GetForwardMsg(id string) (*types.GetForwardMsgResponse, error) {
+	params := types.GetForwardMsgParams{
+		ID: id,
+	}
+
+	resp, err := s.CallAPI(types.ActionGetForwardMsg, params)
+	if err != nil {
+		return nil, err
+	}
+
+	var result types.GetForwardMsgResponse
+	if err := mapToStruct(resp.Data, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return &result, nil
+}
+
+// SendLike 发送好友赞
+func (s *WSServer) SendLike(userID int64, times int) error {
+	params := types.SendLikeParams{
+		UserID: userID,
+		Times:  times,
+	}
+
+	_, err := s.CallAPI(types.ActionSendLike, params)
+	return err
+}
+
+// ============ 群管理相关 API ============
+
+// SetGroupKick 群组踢人
+func (s *WSServer) SetGroupKick(groupID, userID int64, rejectAddRequest bool) error {
+	params := types.SetGroupKickParams{
+		GroupID:          groupID,
+		UserID:           userID,
+		RejectAddRequest: rejectAddRequest,
+	}
+
+	_, err := s.CallAPI(types.ActionSetGroupKick, params)
+	return err
+}
+
+// SetGroupBan 群组单人禁言
+func (s *WSServer) SetGroupBan(groupID, userID, duration int64) error {
+	params := types.SetGroupBanParams{
+		GroupID:  groupID,
+		UserID:   userID,
+		Duration: duration,
+	}
+
+	_, err := s.CallAPI(types.ActionSetGroupBan, params)
+	return err
+}
+
+// SetGroupAnonymousBan 群组匿名用户禁言
+func (s *WSServer) SetGroupAnonymousBan(groupID int64, flag string, duration int64) error {
+	params := types.SetGroupAnonymousBanParams{
+		GroupID:  groupID,
+		Flag:     flag,
+		Duration: duration,
+	}
+
+	_, err := s.CallAPI(types.ActionSetGroupAnonymousBan, params)
+	return err
+}
+
+// SetGroupWholeBan 群组全员禁言
+func (s *WSServer) SetGroupWholeBan(groupID int64, enable bool) error {
+	params := types.SetGroupWholeBanParams{
+		GroupID: groupID,
+		Enable:  enable,
+	}
+
+	_, err := s.CallAPI(types.ActionSetGroupWholeBan, params)
+	return err
+}
+
+// SetGroupAdmin 设置群管理员
+func (s *WSServer) SetGroupAdmin(groupID, userID int64, enable bool) error {
+	params := types.SetGroupAdminParams{
+		GroupID: groupID,
+		UserID:  userID,
+		Enable:  enable,
+	}
+
+	_, err := s.CallAPI(types.ActionSetGroupAdmin, params)
+	return err
+}
+
+// SetGroupAnonymous 设置群匿名
+func (s *WSServer) SetGroupAnonymous(groupID int64, enable bool) error {
+	params := types.SetGroupAnonymousParams{
+		GroupID: groupID,
+		Enable:  enable,
+	}
+
+	_, err := s.CallAPI(types.ActionSetGroupAnonymous, params)
+	return err
+}
+
+// SetGroupCard 设置群名片（群备注）
+func (s *WSServer) SetGroupCard(groupID, userID int64, card string) error {
+	params := types.SetGroupCardParams{
+		GroupID: groupID,
+		UserID:  userID,
+		Card:    card,
+	}
+
+	_, err := s.CallAPI(types.ActionSetGroupCard, params)
+	return err
+}
+
+// SetGroupName 设置群名
+func (s *WSServer) SetGroupName(groupID int64, groupName string) error {
+	params := types.SetGroupNameParams{
+		GroupID:   groupID,
+		GroupName: groupName,
+	}
+
+	_, err := s.CallAPI(types.ActionSetGroupName, params)
+	return err
+}
+
+// SetGroupLeave 退出群组
+func (s *WSServer) SetGroupLeave(groupID int64, isDismiss bool) error {
+	params := types.SetGroupLeaveParams{
+		GroupID:   groupID,
+		IsDismiss: isDismiss,
+	}
+
+	_, err := s.CallAPI(types.ActionSetGroupLeave, params)
+	return err
+}
+
+// SetGroupSpecialTitle 设置群组专属头衔
+func (s *WSServer) SetGroupSpecialTitle(groupID, userID int64, specialTitle string, duration int64) error {
+	params := types.SetGroupSpecialTitleParams{
+		GroupID:      groupID,
+		UserID:       userID,
+		SpecialTitle: specialTitle,
+		Duration:     duration,
+	}
+
+	_, err := s.CallAPI(types.ActionSetGroupSpecialTitle, params)
+	return err
+}
+
+// ============ 请求处理相关 API ============
+
+// SetFriendAddRequest 处理加好友请求
+func (s *WSServer) SetFriendAddRequest(flag string, approve bool, remark string) error {
+	params := types.SetFriendAddRequestParams{
+		Flag:    flag,
+		Approve: approve,
+		Remark:  remark,
+	}
+
+	_, err := s.CallAPI(types.ActionSetFriendAddRequest, params)
+	return err
+}
+
+// SetGroupAddRequest 处理加群请求/邀请
+func (s *WSServer) SetGroupAddRequest(flag, subType string, approve bool, reason string) error {
+	params := types.SetGroupAddRequestParams{
+		Flag:    flag,
+		SubType: subType,
+		Approve: approve,
+		Reason:  reason,
+	}
+
+	_, err := s.CallAPI(types.ActionSetGroupAddRequest, params)
+	return err
+}
+
+// ============ 信息获取相关 API ============
+
+// GetLoginInfo 获取登录号信息
+func (s *WSServer) GetLoginInfo() (*types.GetLoginInfoResponse, error) {
+	resp, err := s.CallAPI(types.ActionGetLoginInfo, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result types.GetLoginInfoResponse
+	if err := mapToStruct(resp.Data, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return &result, nil
+}
+
+// GetStrangerInfo 获取陌生人信息
+func (s *WSServer) GetStrangerInfo(userID int64, noCache bool) (*types.GetStrangerInfoResponse, error) {
+	params := types.GetStrangerInfoParams{
+		UserID:  userID,
+		NoCache: noCache,
+	}
+
+	resp, err := s.CallAPI(types.ActionGetStrangerInfo, params)
+	if err != nil {
+		return nil, err
+	}
+
+	var result types.GetStrangerInfoResponse
+	if err := mapToStruct(resp.Data, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return &result, nil
+}
+
+// GetGroupHonorInfo 获取群荣誉信息
+func (s *WSServer) GetGroupHonorInfo(groupID int64, honorType string) (*types.GetGroupHonorInfoResponse, error) {
+	params := types.GetGroupHonorInfoParams{
+		GroupID: groupID,
+		Type:    honorType,
+	}
+
+	resp, err := s.CallAPI(types.ActionGetGroupHonorInfo, params)
+	if err != nil {
+		return nil, err
+	}
+
+	var result types.GetGroupHonorInfoResponse
+	if err := mapToStruct(resp.Data, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return &result, nil
+}
+
+// GetCookies 获取Cookies
+func (s *WSServer) GetCookies(domain string) (*types.GetCookiesResponse, error) {
+	params := types.GetCookiesParams{
+		Domain: domain,
+	}
+
+	resp, err := s.CallAPI(types.ActionGetCookies, params)
+	if err != nil {
+		return nil, err
+	}
+
+	var result types.GetCookiesResponse
+	if err := mapToStruct(resp.Data, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return &result, nil
+}
+
+// GetCsrfToken 获取CSRF Token
+func (s *WSServer) GetCsrfToken() (*types.GetCsrfTokenResponse, error) {
+	resp, err := s.CallAPI(types.ActionGetCsrfToken, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result types.GetCsrfTokenResponse
+	if err := mapToStruct(resp.Data, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return &result, nil
+}
+
+// GetCredentials 获取QQ相关接口凭证
+func (s *WSServer) GetCredentials(domain string) (*types.GetCredentialsResponse, error) {
+	params := types.GetCredentialsParams{
+		Domain: domain,
+	}
+
+	resp, err := s.CallAPI(types.ActionGetCredentials, params)
+	if err != nil {
+		return nil, err
+	}
+
+	var result types.GetCredentialsResponse
+	if err := mapToStruct(resp.Data, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return &result, nil
+}
+
+// GetRecord 获取语音
+func (s *WSServer) GetRecord(file, outFormat string) (*types.GetRecordResponse, error) {
+	params := types.GetRecordParams{
+		File:      file,
+		OutFormat: outFormat,
+	}
+
+	resp, err := s.CallAPI(types.ActionGetRecord, params)
+	if err != nil {
+		return nil, err
+	}
+
+	var result types.GetRecordResponse
+	if err := mapToStruct(resp.Data, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return &result, nil
+}
+
+// GetImage 获取图片
+func (s *WSServer) GetImage(file string) (*types.GetImageResponse, error) {
+	params := types.GetImageParams{
+		File: file,
+	}
+
+	resp, err := s.CallAPI(types.ActionGetImage, params)
+	if err != nil {
+		return nil, err
+	}
+
+	var result types.GetImageResponse
+	if err := mapToStruct(resp.Data, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return &result, nil
+}
+
+// CanSendImage 检查是否可以发送图片
+func (s *WSServer) CanSendImage() (*types.CanSendImageResponse, error) {
+	resp, err := s.CallAPI(types.ActionCanSendImage, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result types.CanSendImageResponse
+	if err := mapToStruct(resp.Data, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return &result, nil
+}
+
+// CanSendRecord 检查是否可以发送语音
+func (s *WSServer) CanSendRecord() (*types.CanSendRecordResponse, error) {
+	resp, err := s.CallAPI(types.ActionCanSendRecord, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result types.CanSendRecordResponse
+	if err := mapToStruct(resp.Data, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return &result, nil
+}
+
+// GetStatus 获取运行状态
+func (s *WSServer) GetStatus() (*types.GetStatusResponse, error) {
+	resp, err := s.CallAPI(types.ActionGetStatus, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result types.GetStatusResponse
+	if err := mapToStruct(resp.Data, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return &result, nil
+}
+
+// GetVersionInfo 获取版本信息
+func (s *WSServer) GetVersionInfo() (*types.GetVersionInfoResponse, error) {
+	resp, err := s.CallAPI(types.ActionGetVersionInfo, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result types.GetVersionInfoResponse
+	if err := mapToStruct(resp.Data, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return &result, nil
+}
+
+// ============ 其他 API ============
+
+// SetRestart 重启OneBot实现
+func (s *WSServer) SetRestart(delay int) error {
+	params := types.SetRestartParams{
+		Delay: delay,
+	}
+
+	_, err := s.CallAPI(types.ActionSetRestart, params)
+	return err
+}
+
+// CleanCache 清理缓存
+func (s *WSServer) CleanCache() error {
+	params := types.CleanCacheParams{}
+
+	_, err := s.CallAPI(types.ActionCleanCache, params)
+	return err
+}
+
+// SetQQProfile 设置登录号资料（需要扩展API）
+func (s *WSServer) SetQQProfile(params *types.SetQQProfileParams) error {
+	_, err := s.CallAPI(types.ActionSetQQProfile, params)
+	return err
+}
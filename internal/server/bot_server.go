@@ -5,10 +5,18 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
+	"sort"
+	"strconv"
 	types "onebot-go2/pkg/const"
 	"onebot-go2/pkg/event"
+	"onebot-go2/pkg/history"
+	"onebot-go2/pkg/infocache"
+	"onebot-go2/pkg/metrics"
+	"onebot-go2/pkg/outbound"
+	"onebot-go2/pkg/record"
+	"onebot-go2/pkg/trace"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -18,9 +26,26 @@ import (
 	"github.com/gorilla/websocket"
 )
 
+// ErrNotConnected 没有可用的 OneBot 连接（或指定的机器人未连接）
+var ErrNotConnected = errors.New("not connected to OneBot client")
+
+// ErrCallTimeout 在超时时间内没有收到 API 响应
+var ErrCallTimeout = errors.New("API call timeout")
+
+// eventQueueSize 每个连接等待分发的事件数上限，超过时丢弃新事件
+const eventQueueSize = 1024
+
 type WSServer struct {
+	*wsState
+	ctx    context.Context // API 调用使用的 context，携带 trace 信息
+	selfID int64           // API 调用使用的机器人，0 表示最近建立的连接
+}
+
+// wsState 连接状态，WithContext、ForBot 返回的实例与原实例共享
+type wsState struct {
 	token        string
-	activeClient *websocket.Conn  // 改为单客户端连接
+	clients      map[int64]*botConn // self_id -> 连接，客户端未提供 X-Self-ID 时为 0
+	latest       *botConn           // 最近建立的连接，未指定机器人时使用
 	clientMu     sync.RWMutex     // 保护客户端连接的读写锁
 	upgrader     websocket.Upgrader
 	pendingCalls sync.Map         // 存储待响应的 API 调用
@@ -28,19 +53,52 @@ type WSServer struct {
 	echoCounter  uint64           // Echo ID 计数器
 	callTimeout  time.Duration    // API 调用超时时间
 	connected    atomic.Bool      // 连接状态
-}
+	history      *history.Store   // 消息历史
+	logger       *slog.Logger
+	metrics      *metrics.Bot     // 运行指标，为空表示不记录
+	writeMu      sync.Mutex       // 保证同一时间只有一个 goroutine 写连接
+	connections  atomic.Int64     // 已建立的连接数，用于统计重连
+	tracer       *trace.Tracer    // 追踪器，为空表示只分配 trace ID 不导出
+	stream       *Stream          // 事件和 API 调用的实时推送
+	recorder     *record.Recorder // 原始帧录制，为空表示不录制
+	outbound     *outbound.Chain  // 发出消息的拦截器
+	cache        *infocache.Cache // 信息缓存，为空表示不缓存
+	warmup       bool             // 连接建立后预热信息缓存
+	warmMembers  bool             // 预热时获取每个群的成员列表
+}
+
+// botConn 一个机器人的 WebSocket 连接
+type botConn struct {
+	conn        *websocket.Conn
+	selfID      int64
+	since       time.Time
+	self        atomic.Pointer[event.Self] // 连接建立后获取的身份信息
+	unsupported sync.Map                   // 返回过 1404 的 action
+}
+
+// WSServer 实现 OneBot 的全部 API，新增 API 时 event.BotAPI 需要同步添加
+var (
+	_ event.BotAPI          = (*WSServer)(nil)
+	_ event.ServerInterface = (*WSServer)(nil)
+	_ event.SelfProvider    = (*WSServer)(nil)
+)
 
 func NewWSServer(token string) *WSServer {
-	server := &WSServer{
+	server := &WSServer{ctx: context.Background(), wsState: &wsState{
 		token:       token,
+		clients:     make(map[int64]*botConn),
 		dispatcher:  event.NewDispatcher(),
 		callTimeout: 10 * time.Second, // 默认10秒超时
+		history:     history.New(history.DefaultMaxRecords, 0),
+		stream:      NewStream(),
+		outbound:    outbound.NewChain(),
+		logger:      slog.Default().With("component", "server"),
 		upgrader: websocket.Upgrader{
 			CheckOrigin: func(r *http.Request) bool {
 				return true
 			},
 		},
-	}
+	}}
 	server.connected.Store(false)
 	return server
 }
@@ -55,8 +113,100 @@ func (s *WSServer) SetCallTimeout(timeout time.Duration) {
 	s.callTimeout = timeout
 }
 
-// IsConnected 检查是否已连接
+// SetTracer 设置追踪器，用于导出 API 调用的 span
+func (s *WSServer) SetTracer(tracer *trace.Tracer) {
+	s.tracer = tracer
+}
+
+// WithContext 返回绑定 ctx 的实例，与原实例共享连接
+// 通过它发起的 API 调用作为 ctx 中 span 的子 span，日志中带有相同的 trace_id
+func (s *WSServer) WithContext(ctx context.Context) event.ServerInterface {
+	return &WSServer{wsState: s.wsState, ctx: ctx, selfID: s.selfID}
+}
+
+// ForBot 返回绑定到指定机器人的实例，与原实例共享连接
+// 多个机器人同时连接时用于选择 API 调用的目标，selfID 为 0 表示最近建立的连接
+func (s *WSServer) ForBot(selfID int64) *WSServer {
+	return &WSServer{wsState: s.wsState, ctx: s.ctx, selfID: selfID}
+}
+
+// SelfID 返回绑定的机器人 self_id，0 表示未绑定
+func (s *WSServer) SelfID() int64 {
+	return s.selfID
+}
+
+// Bots 返回已连接机器人的 self_id，按连接时间排序
+func (s *WSServer) Bots() []int64 {
+	s.clientMu.RLock()
+	conns := make([]*botConn, 0, len(s.clients))
+	for _, c := range s.clients {
+		conns = append(conns, c)
+	}
+	s.clientMu.RUnlock()
+
+	sort.Slice(conns, func(i, j int) bool { return conns[i].since.Before(conns[j].since) })
+	ids := make([]int64, len(conns))
+	for i, c := range conns {
+		ids[i] = c.selfID
+	}
+	return ids
+}
+
+// client 返回 API 调用使用的连接
+func (s *WSServer) client() (*botConn, error) {
+	s.clientMu.RLock()
+	defer s.clientMu.RUnlock()
+	if s.selfID == 0 {
+		if s.latest == nil {
+			return nil, ErrNotConnected
+		}
+		return s.latest, nil
+	}
+	c, ok := s.clients[s.selfID]
+	if !ok {
+		return nil, fmt.Errorf("bot %d: %w", s.selfID, ErrNotConnected)
+	}
+	return c, nil
+}
+
+// SetLogger 设置日志记录器，默认使用创建时的 slog.Default()
+func (s *WSServer) SetLogger(logger *slog.Logger) {
+	s.logger = logger.With("component", "server")
+}
+
+// SetMetrics 设置运行指标
+func (s *WSServer) SetMetrics(m *metrics.Bot) {
+	s.metrics = m
+}
+
+// SetHistory 设置消息历史
+func (s *WSServer) SetHistory(store *history.Store) {
+	s.history = store
+}
+
+// History 返回消息历史
+func (s *WSServer) History() *history.Store {
+	return s.history
+}
+
+// SetRecorder 设置原始帧录制，录制文件可以通过 record.Replayer 重放
+func (s *WSServer) SetRecorder(recorder *record.Recorder) {
+	s.recorder = recorder
+}
+
+// Stream 返回收到的事件和发出的 API 调用的实时推送
+func (s *WSServer) Stream() *Stream {
+	return s.stream
+}
+
+// IsConnected 检查是否已连接，绑定机器人时检查该机器人是否已连接
 func (s *WSServer) IsConnected() bool {
+	if s.selfID != 0 {
+		s.clientMu.RLock()
+		defer s.clientMu.RUnlock()
+		_, ok := s.clients[s.selfID]
+		return ok
+	}
 	return s.connected.Load()
 }
 
@@ -66,42 +216,100 @@ func (s *WSServer) generateEcho() string {
 	return uuid.New().String()
 }
 
+// authorize 校验 OneBot 鉴权 token
+// 支持 Authorization: Bearer <token> 请求头和 access_token 查询参数，未配置 token 时不校验
+func (s *WSServer) authorize(r *http.Request) bool {
+	if s.token == "" {
+		return true
+	}
+	if auth := r.Header.Get("Authorization"); auth != "" {
+		return auth == "Bearer "+s.token || auth == "Token "+s.token
+	}
+	return r.URL.Query().Get("access_token") == s.token
+}
+
 func (s *WSServer) HandlerWebsocket(c *gin.Context) {
+	if !s.authorize(c.Request) {
+		s.logger.Warn("WebSocket connection rejected: invalid access token", "remote", c.ClientIP())
+		c.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+
 	conn, err := s.upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
-		log.Printf("WebSocket upgrader error: %v", err)
+		s.logger.Error("WebSocket upgrade failed", "remote", c.ClientIP(), "error", err)
 		return
 	}
 
-	// 设置活动客户端连接
+	// OneBot 反向 WebSocket 通过 X-Self-ID 请求头提供机器人 QQ 号
+	// 同一机器人的新连接替换旧连接，不同机器人的连接同时保持
+	selfID, _ := strconv.ParseInt(c.GetHeader("X-Self-ID"), 10, 64)
+	current := &botConn{conn: conn, selfID: selfID, since: time.Now()}
+
 	s.clientMu.Lock()
-	if s.activeClient != nil {
+	if old, ok := s.clients[selfID]; ok {
 		// 关闭旧连接
-		s.activeClient.Close()
-		log.Printf("Closed old connection")
+		old.conn.Close()
+		s.logger.Info("Closed old connection", "remote", old.conn.RemoteAddr().String(), "self_id", selfID)
 	}
-	s.activeClient = conn
+	s.clients[selfID] = current
+	s.latest = current
 	s.connected.Store(true)
+	s.metrics.SetConnected(true, s.connections.Add(1) > 1)
 	s.clientMu.Unlock()
 
 	defer func() {
 		s.clientMu.Lock()
-		if s.activeClient == conn {
-			s.activeClient = nil
-			s.connected.Store(false)
+		if s.clients[selfID] == current {
+			delete(s.clients, selfID)
+			if s.latest == current {
+				s.latest = nil
+				for _, c := range s.clients {
+					if s.latest == nil || c.since.After(s.latest.since) {
+						s.latest = c
+					}
+				}
+			}
+			s.connected.Store(len(s.clients) > 0)
+			s.metrics.SetConnected(len(s.clients) > 0, false)
 		}
 		s.clientMu.Unlock()
 		conn.Close()
 	}()
 
-	log.Printf("WebSocket connection established from %s", conn.RemoteAddr())
+	remote := conn.RemoteAddr().String()
+	s.logger.Info("WebSocket connection established", "remote", remote, "self_id", selfID)
+
+	// 该连接上的事件使用绑定到该机器人的实例分发，处理器的回复发往同一连接
+	bot := s.ForBot(selfID)
+
+	// 事件在单独的 goroutine 中按收到的顺序分发，读取循环不运行处理器
+	// 同步分发时处理器等待 API 响应（包括发送限流排队）也不会阻塞响应的读取
+	events := make(chan interface{}, eventQueueSize)
+	defer close(events)
+	go func() {
+		for evt := range events {
+			if err := s.dispatcher.Dispatch(context.Background(), evt, bot); err != nil {
+				s.logger.Error("Failed to dispatch event", append(event.EventAttrs(evt), "error", err)...)
+			}
+		}
+	}()
+
+	go s.identify(current)
+
+	// 重新连接期间错过的通知可能使缓存过期，清空后重新预热
+	s.cache.Reset(selfID)
+	if s.cache != nil && s.warmup {
+		go s.warmCache(selfID)
+	}
 
 	for {
 		_, message, err := conn.ReadMessage()
 		if err != nil {
-			log.Printf("Error reading message from %s: %v", conn.RemoteAddr(), err)
+			s.logger.Warn("WebSocket connection closed", "remote", remote, "self_id", selfID, "error", err)
 			break
 		}
+		s.recorder.Record(record.DirIn, selfID, message)
 
 		// 尝试解析为 API 响应
 		var response struct {
@@ -115,7 +323,7 @@ func (s *WSServer) HandlerWebsocket(c *gin.Context) {
 					select {
 					case respChan <- &response.APIResponse:
 					case <-time.After(1 * time.Second):
-						log.Printf("Response channel timeout for echo: %s", response.Echo)
+						s.logger.Warn("Response channel timeout", "echo", response.Echo)
 					}
 					close(respChan)
 				}
@@ -126,13 +334,35 @@ func (s *WSServer) HandlerWebsocket(c *gin.Context) {
 		// 解析为事件
 		evt, err := ParseEvent(message)
 		if err != nil {
-			log.Printf("Parse event error: %v", err)
+			s.logger.Warn("Failed to parse event", "error", err)
 			continue
 		}
 
-		// 分发事件到注册的处理器
-		if err := s.dispatcher.Dispatch(context.Background(), evt, s); err != nil {
-			log.Printf("Error dispatching event: %v", err)
+		s.logger.Debug("Received event", event.EventAttrs(evt)...)
+		s.metrics.ObserveEvent(evt)
+		s.recordEvent(evt)
+		s.cache.Observe(selfID, evt)
+		if s.stream.Active() {
+			s.stream.Publish(eventItem(evt))
+		}
+
+		// 交给分发 goroutine，队列已满时丢弃，避免阻塞 API 响应的读取
+		select {
+		case events <- evt:
+		default:
+			s.logger.Warn("Event queue is full, dropping event", append(event.EventAttrs(evt), "queue_size", eventQueueSize)...)
+		}
+	}
+}
+
+// recordEvent 将收到的消息写入历史，撤回通知标记对应消息为已撤回
+func (s *WSServer) recordEvent(evt interface{}) {
+	switch e := evt.(type) {
+	case *types.MessageEvent:
+		s.history.Add(history.FromEvent(e))
+	case *types.NoticeEvent:
+		if e.NoticeType == "group_recall" || e.NoticeType == "friend_recall" {
+			s.history.MarkRecalled(e.MessageID)
 		}
 	}
 }
@@ -177,18 +407,56 @@ func ParseEvent(data []byte) (interface{}, error) {
 	}
 }
 
-// CallAPI 通用 API 调用方法
+// CallAPI 通用 API 调用方法，使用实例绑定的 context
 func (s *WSServer) CallAPI(action string, params interface{}) (*types.APIResponse, error) {
-	if !s.IsConnected() {
-		return nil, errors.New("not connected to OneBot client")
+	return s.CallAPIContext(s.ctx, action, params)
+}
+
+// CallAPIContext 调用 OneBot API
+// 发送消息和撤回消息的 action 与 SendMsg、DeleteMsg 一样经过发出消息的拦截器，其他 action 直接调用
+// API 调用作为 ctx 中 span 的子 span 记录，ctx 取消时不再等待响应
+func (s *WSServer) CallAPIContext(ctx context.Context, action string, params interface{}) (*types.APIResponse, error) {
+	if isOutboundAction(action) {
+		return s.callOutbound(ctx, action, params)
+	}
+	return s.call(ctx, action, params)
+}
+
+// call 直接调用 OneBot API，不经过发出消息的拦截器
+func (s *WSServer) call(ctx context.Context, action string, params interface{}) (resp *types.APIResponse, err error) {
+	bot, err := s.client()
+	if err != nil {
+		return nil, err
+	}
+	if err := bot.unsupportedError(action); err != nil {
+		return nil, err
 	}
 
 	// 生成唯一的 echo ID
 	echo := s.generateEcho()
 
+	begin := time.Now()
+	ctx, span := s.tracer.Start(ctx, "api", "action", action, "echo", echo)
+	defer func() {
+		if resp != nil {
+			span.SetAttrs("retcode", resp.RetCode)
+		}
+		span.SetError(err)
+		span.End()
+		if s.stream.Active() {
+			s.stream.Publish(apiItem(bot.selfID, apiRecord(action, params, echo, resp, err, time.Since(begin))))
+		}
+	}()
+	logger := s.logger.With("action", action, "echo", echo, "trace_id", span.TraceID())
+	if s.selfID != 0 {
+		logger = logger.With("self_id", s.selfID)
+	}
+
 	// 创建响应通道
 	respChan := make(chan *types.APIResponse, 1)
 	s.pendingCalls.Store(echo, respChan)
+	s.metrics.AddPendingCalls(1)
+	defer s.metrics.AddPendingCalls(-1)
 
 	// 构造 API 请求
 	request := types.APIRequest{
@@ -205,523 +473,185 @@ func (s *WSServer) CallAPI(action string, params interface{}) (*types.APIRespons
 	}
 
 	// 发送请求
-	s.clientMu.RLock()
-	conn := s.activeClient
-	s.clientMu.RUnlock()
-
-	if conn == nil {
-		s.pendingCalls.Delete(echo)
-		return nil, errors.New("no active connection")
-	}
-
-	if err := conn.WriteMessage(websocket.TextMessage, data); err != nil {
+	start := time.Now()
+	if err := s.writeMessage(bot, data); err != nil {
 		s.pendingCalls.Delete(echo)
+		s.metrics.ObserveAPICall(action, nil, false, time.Since(start))
+		logger.Error("Failed to send API request", "error", err)
 		return nil, fmt.Errorf("failed to send request: %w", err)
 	}
 
 	// 等待响应（带超时）
 	select {
 	case resp := <-respChan:
+		duration := time.Since(start)
+		s.metrics.ObserveAPICall(action, resp, false, duration)
 		if resp.Status != "ok" && resp.Status != "async" {
+			logger.Warn("API call failed", "duration", duration, "retcode", resp.RetCode, "message", resp.Message)
+			if bot.markUnsupported(action, resp) {
+				return resp, fmt.Errorf("API call failed: %s (retcode: %d): %w", resp.Message, resp.RetCode, event.ErrUnsupported)
+			}
 			return resp, fmt.Errorf("API call failed: %s (retcode: %d)", resp.Message, resp.RetCode)
 		}
+		logger.Debug("API call succeeded", "duration", duration)
 		return resp, nil
 	case <-time.After(s.callTimeout):
 		s.pendingCalls.Delete(echo)
-		return nil, errors.New("API call timeout")
+		s.metrics.ObserveAPICall(action, nil, true, time.Since(start))
+		logger.Warn("API call timeout", "timeout", s.callTimeout)
+		return nil, ErrCallTimeout
+	case <-ctx.Done():
+		s.pendingCalls.Delete(echo)
+		s.metrics.ObserveAPICall(action, nil, false, time.Since(start))
+		logger.Warn("API call canceled", "duration", time.Since(start), "error", ctx.Err())
+		return nil, fmt.Errorf("API call canceled: %w", ctx.Err())
 	}
 }
 
+// writeMessage 向连接写入一条消息
+// gorilla/websocket 不支持并发写，等待写锁的消息数即出站队列深度
+func (s *WSServer) writeMessage(bot *botConn, data []byte) error {
+	s.metrics.AddOutbound(1)
+	s.writeMu.Lock()
+	s.metrics.AddOutbound(-1)
+	defer s.writeMu.Unlock()
+	s.recorder.Record(record.DirOut, bot.selfID, data)
+	return bot.conn.WriteMessage(websocket.TextMessage, data)
+}
+
 // ============ 消息相关 API ============
+// 其余 API 由 pkg/const/actions.yaml 生成（api_gen.go），经过信息缓存的 API 在 cache.go，
+// 这里是经过拦截器链（UseOutbound）并记录消息历史的 API
 
 // SendPrivateMsg 发送私聊消息
 func (s *WSServer) SendPrivateMsg(userID int64, message types.MessageArray) (*types.SendMessageResponse, error) {
-	params := types.SendMessageParams{
-		MessageType: types.MessageTypePrivate,
-		UserID:      userID,
-		Message:     message,
-	}
-
-	resp, err := s.CallAPI(types.ActionSendPrivateMsg, params)
-	if err != nil {
-		return nil, err
-	}
-
-	var result types.SendMessageResponse
-	if err := mapToStruct(resp.Data, &result); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
-	}
-
-	return &result, nil
+	return s.send(&outbound.Message{
+		Action: types.ActionSendPrivateMsg,
+		Params: types.SendMessageParams{
+			MessageType: types.MessageTypePrivate,
+			UserID:      userID,
+			Message:     message,
+		},
+	})
 }
 
 // SendGroupMsg 发送群消息
 func (s *WSServer) SendGroupMsg(groupID int64, message types.MessageArray) (*types.SendMessageResponse, error) {
-	params := types.SendMessageParams{
-		MessageType: types.MessageTypeGroup,
-		GroupID:     groupID,
-		Message:     message,
-	}
-
-	resp, err := s.CallAPI(types.ActionSendGroupMsg, params)
-	if err != nil {
-		return nil, err
-	}
-
-	var result types.SendMessageResponse
-	if err := mapToStruct(resp.Data, &result); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
-	}
-
-	return &result, nil
+	return s.send(&outbound.Message{
+		Action: types.ActionSendGroupMsg,
+		Params: types.SendMessageParams{
+			MessageType: types.MessageTypeGroup,
+			GroupID:     groupID,
+			Message:     message,
+		},
+	})
 }
 
 // SendMsg 发送消息（自动识别类型）
 func (s *WSServer) SendMsg(params *types.SendMessageParams) (*types.SendMessageResponse, error) {
-	resp, err := s.CallAPI(types.ActionSendMsg, params)
-	if err != nil {
-		return nil, err
-	}
-
-	var result types.SendMessageResponse
-	if err := mapToStruct(resp.Data, &result); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
-	}
+	msg := &outbound.Message{Action: types.ActionSendMsg, Params: *params}
+	inferMessageType(&msg.Params)
+	return s.send(msg)
+}
+
+// SendGroupForwardMsg 发送群合并转发消息，messages 为 node 消息段
+func (s *WSServer) SendGroupForwardMsg(groupID int64, messages types.MessageArray) (*types.SendMessageResponse, error) {
+	return s.send(&outbound.Message{
+		Action: types.ActionSendGroupForwardMsg,
+		Params: types.SendMessageParams{
+			MessageType: types.MessageTypeGroup,
+			GroupID:     groupID,
+			Message:     messages,
+		},
+	})
+}
 
-	return &result, nil
+// SendPrivateForwardMsg 发送私聊合并转发消息，messages 为 node 消息段
+func (s *WSServer) SendPrivateForwardMsg(userID int64, messages types.MessageArray) (*types.SendMessageResponse, error) {
+	return s.send(&outbound.Message{
+		Action: types.ActionSendPrivateForwardMsg,
+		Params: types.SendMessageParams{
+			MessageType: types.MessageTypePrivate,
+			UserID:      userID,
+			Message:     messages,
+		},
+	})
 }
 
 // DeleteMsg 撤回消息
 func (s *WSServer) DeleteMsg(messageID int32) error {
-	params := types.DeleteMsgParams{
-		MessageID: messageID,
-	}
-
-	_, err := s.CallAPI(types.ActionDeleteMsg, params)
-	return err
-}
-
-// GetMsg 获取消息
-func (s *WSServer) GetMsg(messageID int32) (*types.GetMsgResponse, error) {
-	params := types.GetMsgParams{
-		MessageID: messageID,
-	}
-
-	resp, err := s.CallAPI(types.ActionGetMsg, params)
-	if err != nil {
-		return nil, err
-	}
-
-	var result types.GetMsgResponse
-	if err := mapToStruct(resp.Data, &result); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
-	}
-
-	return &result, nil
-}
-
-// GetForwardMsg 获取合并转发消息
-func (s *WSServer) GetForwardMsg(id string) (*types.GetForwardMsgResponse, error) {
-	params := types.GetForwardMsgParams{
-		ID: id,
-	}
-
-	resp, err := s.CallAPI(types.ActionGetForwardMsg, params)
-	if err != nil {
-		return nil, err
-	}
-
-	var result types.GetForwardMsgResponse
-	if err := mapToStruct(resp.Data, &result); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
-	}
-
-	return &result, nil
-}
-
-// SendLike 发送好友赞
-func (s *WSServer) SendLike(userID int64, times int) error {
-	params := types.SendLikeParams{
-		UserID: userID,
-		Times:  times,
-	}
-
-	_, err := s.CallAPI(types.ActionSendLike, params)
-	return err
-}
-
-// ============ 群管理相关 API ============
-
-// SetGroupKick 群组踢人
-func (s *WSServer) SetGroupKick(groupID, userID int64, rejectAddRequest bool) error {
-	params := types.SetGroupKickParams{
-		GroupID:          groupID,
-		UserID:           userID,
-		RejectAddRequest: rejectAddRequest,
-	}
-
-	_, err := s.CallAPI(types.ActionSetGroupKick, params)
-	return err
-}
-
-// SetGroupBan 群组单人禁言
-func (s *WSServer) SetGroupBan(groupID, userID int64, duration int64) error {
-	params := types.SetGroupBanParams{
-		GroupID:  groupID,
-		UserID:   userID,
-		Duration: duration,
-	}
-
-	_, err := s.CallAPI(types.ActionSetGroupBan, params)
-	return err
-}
-
-// SetGroupAnonymousBan 群组匿名用户禁言
-func (s *WSServer) SetGroupAnonymousBan(groupID int64, flag string, duration int64) error {
-	params := types.SetGroupAnonymousBanParams{
-		GroupID:  groupID,
-		Flag:     flag,
-		Duration: duration,
-	}
-
-	_, err := s.CallAPI(types.ActionSetGroupAnonymousBan, params)
-	return err
-}
-
-// SetGroupWholeBan 群组全员禁言
-func (s *WSServer) SetGroupWholeBan(groupID int64, enable bool) error {
-	params := types.SetGroupWholeBanParams{
-		GroupID: groupID,
-		Enable:  enable,
-	}
-
-	_, err := s.CallAPI(types.ActionSetGroupWholeBan, params)
-	return err
-}
-
-// SetGroupAdmin 设置群管理员
-func (s *WSServer) SetGroupAdmin(groupID, userID int64, enable bool) error {
-	params := types.SetGroupAdminParams{
-		GroupID: groupID,
-		UserID:  userID,
-		Enable:  enable,
-	}
-
-	_, err := s.CallAPI(types.ActionSetGroupAdmin, params)
-	return err
-}
-
-// SetGroupAnonymous 设置群匿名
-func (s *WSServer) SetGroupAnonymous(groupID int64, enable bool) error {
-	params := types.SetGroupAnonymousParams{
-		GroupID: groupID,
-		Enable:  enable,
-	}
-
-	_, err := s.CallAPI(types.ActionSetGroupAnonymous, params)
-	return err
-}
-
-// SetGroupCard 设置群名片
-func (s *WSServer) SetGroupCard(groupID, userID int64, card string) error {
-	params := types.SetGroupCardParams{
-		GroupID: groupID,
-		UserID:  userID,
-		Card:    card,
-	}
-
-	_, err := s.CallAPI(types.ActionSetGroupCard, params)
-	return err
-}
-
-// SetGroupName 设置群名
-func (s *WSServer) SetGroupName(groupID int64, groupName string) error {
-	params := types.SetGroupNameParams{
-		GroupID:   groupID,
-		GroupName: groupName,
-	}
-
-	_, err := s.CallAPI(types.ActionSetGroupName, params)
-	return err
-}
-
-// SetGroupLeave 退出群组
-func (s *WSServer) SetGroupLeave(groupID int64, isDismiss bool) error {
-	params := types.SetGroupLeaveParams{
-		GroupID:   groupID,
-		IsDismiss: isDismiss,
-	}
-
-	_, err := s.CallAPI(types.ActionSetGroupLeave, params)
-	return err
-}
-
-// SetGroupSpecialTitle 设置群组专属头衔
-func (s *WSServer) SetGroupSpecialTitle(groupID, userID int64, specialTitle string, duration int64) error {
-	params := types.SetGroupSpecialTitleParams{
-		GroupID:      groupID,
-		UserID:       userID,
-		SpecialTitle: specialTitle,
-		Duration:     duration,
-	}
-
-	_, err := s.CallAPI(types.ActionSetGroupSpecialTitle, params)
-	return err
-}
-
-// ============ 请求处理相关 API ============
-
-// SetFriendAddRequest 处理加好友请求
-func (s *WSServer) SetFriendAddRequest(flag string, approve bool, remark string) error {
-	params := types.SetFriendAddRequestParams{
-		Flag:    flag,
-		Approve: approve,
-		Remark:  remark,
-	}
-
-	_, err := s.CallAPI(types.ActionSetFriendAddRequest, params)
-	return err
-}
-
-// SetGroupAddRequest 处理加群请求/邀请
-func (s *WSServer) SetGroupAddRequest(flag, subType string, approve bool, reason string) error {
-	params := types.SetGroupAddRequestParams{
-		Flag:    flag,
-		SubType: subType,
-		Approve: approve,
-		Reason:  reason,
+	msg := &outbound.Message{Action: types.ActionDeleteMsg, MessageID: messageID}
+	// 消息历史中有记录时填充撤回消息所在的群或私聊
+	if rec, ok := s.history.Get(messageID); ok {
+		msg.Params = types.SendMessageParams{
+			MessageType: rec.MessageType,
+			GroupID:     rec.GroupID,
+			UserID:      rec.UserID,
+			Message:     rec.Message,
+		}
 	}
-
-	_, err := s.CallAPI(types.ActionSetGroupAddRequest, params)
+	_, err := s.send(msg)
 	return err
 }
 
-// ============ 信息获取相关 API ============
-
-// GetLoginInfo 获取登录号信息
-func (s *WSServer) GetLoginInfo() (*types.GetLoginInfoResponse, error) {
-	resp, err := s.CallAPI(types.ActionGetLoginInfo, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	var result types.GetLoginInfoResponse
-	if err := mapToStruct(resp.Data, &result); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
-	}
-
-	return &result, nil
-}
-
-// GetStrangerInfo 获取陌生人信息
-func (s *WSServer) GetStrangerInfo(userID int64, noCache bool) (*types.GetStrangerInfoResponse, error) {
-	params := types.GetStrangerInfoParams{
-		UserID:  userID,
-		NoCache: noCache,
-	}
-
-	resp, err := s.CallAPI(types.ActionGetStrangerInfo, params)
-	if err != nil {
-		return nil, err
-	}
-
-	var result types.GetStrangerInfoResponse
-	if err := mapToStruct(resp.Data, &result); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
-	}
-
-	return &result, nil
-}
-
-// GetFriendList 获取好友列表
-func (s *WSServer) GetFriendList() (types.GetFriendListResponse, error) {
-	resp, err := s.CallAPI(types.ActionGetFriendList, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	var result types.GetFriendListResponse
-	if err := mapToStruct(resp.Data, &result); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
-	}
-
-	return result, nil
-}
-
-// GetGroupInfo 获取群信息
-func (s *WSServer) GetGroupInfo(groupID int64, noCache bool) (*types.GetGroupInfoResponse, error) {
-	params := types.GetGroupInfoParams{
-		GroupID: groupID,
-		NoCache: noCache,
-	}
-
-	resp, err := s.CallAPI(types.ActionGetGroupInfo, params)
-	if err != nil {
-		return nil, err
-	}
-
-	var result types.GetGroupInfoResponse
-	if err := mapToStruct(resp.Data, &result); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
-	}
-
-	return &result, nil
-}
-
-// GetGroupList 获取群列表
-func (s *WSServer) GetGroupList() (types.GetGroupListResponse, error) {
-	resp, err := s.CallAPI(types.ActionGetGroupList, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	var result types.GetGroupListResponse
-	if err := mapToStruct(resp.Data, &result); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
-	}
-
-	return result, nil
-}
-
-// GetGroupMemberInfo 获取群成员信息
-func (s *WSServer) GetGroupMemberInfo(groupID, userID int64, noCache bool) (*types.GetGroupMemberInfoResponse, error) {
-	params := types.GetGroupMemberInfoParams{
-		GroupID: groupID,
-		UserID:  userID,
-		NoCache: noCache,
-	}
-
-	resp, err := s.CallAPI(types.ActionGetGroupMemberInfo, params)
-	if err != nil {
-		return nil, err
-	}
-
-	var result types.GetGroupMemberInfoResponse
-	if err := mapToStruct(resp.Data, &result); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
-	}
-
-	return &result, nil
-}
-
-// GetGroupMemberList 获取群成员列表
-func (s *WSServer) GetGroupMemberList(groupID int64) (types.GetGroupMemberListResponse, error) {
-	params := types.GetGroupMemberListParams{
-		GroupID: groupID,
-	}
-
-	resp, err := s.CallAPI(types.ActionGetGroupMemberList, params)
-	if err != nil {
-		return nil, err
-	}
-
-	var result types.GetGroupMemberListResponse
-	if err := mapToStruct(resp.Data, &result); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
-	}
-
-	return result, nil
-}
-
-// GetGroupHonorInfo 获取群荣誉信息
-func (s *WSServer) GetGroupHonorInfo(groupID int64, honorType string) (*types.GetGroupHonorInfoResponse, error) {
-	params := types.GetGroupHonorInfoParams{
-		GroupID: groupID,
-		Type:    honorType,
-	}
-
-	resp, err := s.CallAPI(types.ActionGetGroupHonorInfo, params)
-	if err != nil {
-		return nil, err
-	}
-
-	var result types.GetGroupHonorInfoResponse
-	if err := mapToStruct(resp.Data, &result); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
-	}
-
-	return &result, nil
-}
-
-// GetCookies 获取Cookies
-func (s *WSServer) GetCookies(domain string) (*types.GetCookiesResponse, error) {
-	params := types.GetCookiesParams{
-		Domain: domain,
-	}
-
-	resp, err := s.CallAPI(types.ActionGetCookies, params)
-	if err != nil {
-		return nil, err
-	}
-
-	var result types.GetCookiesResponse
-	if err := mapToStruct(resp.Data, &result); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
-	}
-
-	return &result, nil
+// UseOutbound 注册发出消息的拦截器，作用于发送消息和撤回消息的 API
+func (s *WSServer) UseOutbound(interceptor outbound.Interceptor) *WSServer {
+	s.outbound.Use(interceptor)
+	return s
 }
 
-// GetCsrfToken 获取CSRF Token
-func (s *WSServer) GetCsrfToken() (*types.GetCsrfTokenResponse, error) {
-	resp, err := s.CallAPI(types.ActionGetCsrfToken, nil)
+// send 经过拦截器链发送或撤回消息
+func (s *WSServer) send(msg *outbound.Message) (*types.SendMessageResponse, error) {
+	bot, err := s.client()
 	if err != nil {
 		return nil, err
 	}
-
-	var result types.GetCsrfTokenResponse
-	if err := mapToStruct(resp.Data, &result); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
-	}
-
-	return &result, nil
+	msg.Context = s.ctx
+	msg.SelfID = bot.selfID
+	return s.outbound.Then(s.deliver)(msg)
 }
 
-// GetCredentials 获取QQ相关接口凭证
-func (s *WSServer) GetCredentials(domain string) (*types.GetCredentialsResponse, error) {
-	params := types.GetCredentialsParams{
-		Domain: domain,
-	}
-
-	resp, err := s.CallAPI(types.ActionGetCredentials, params)
-	if err != nil {
-		return nil, err
-	}
-
-	var result types.GetCredentialsResponse
-	if err := mapToStruct(resp.Data, &result); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
-	}
-
-	return &result, nil
-}
+// deliver 拦截器链的末端，调用 OneBot API 并记录消息历史
+// 拦截器修改了目标类型时，send_private_msg / send_group_msg 和两个合并转发 action 改为对应的 action
+func (s *WSServer) deliver(msg *outbound.Message) (*types.SendMessageResponse, error) {
+	bot := &WSServer{wsState: s.wsState, ctx: msg.Context, selfID: msg.SelfID}
 
-// GetStatus 获取运行状态
-func (s *WSServer) GetStatus() (*types.GetStatusResponse, error) {
-	resp, err := s.CallAPI(types.ActionGetStatus, nil)
-	if err != nil {
+	if msg.Action == types.ActionDeleteMsg {
+		_, err := bot.call(msg.Context, types.ActionDeleteMsg, types.DeleteMsgParams{MessageID: msg.MessageID})
+		if err == nil {
+			s.history.MarkRecalled(msg.MessageID)
+		}
 		return nil, err
 	}
 
-	var result types.GetStatusResponse
-	if err := mapToStruct(resp.Data, &result); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+	var params interface{} = msg.Params
+	action := msg.Action
+	switch {
+	case msg.IsForward():
+		action = types.ActionSendPrivateForwardMsg
+		forward := types.SendForwardMsgParams{UserID: msg.Params.UserID, Messages: msg.Params.Message}
+		if msg.IsGroup() {
+			action = types.ActionSendGroupForwardMsg
+			forward = types.SendForwardMsgParams{GroupID: msg.Params.GroupID, Messages: msg.Params.Message}
+		}
+		params = forward
+	case action != types.ActionSendMsg:
+		action = types.ActionSendPrivateMsg
+		if msg.IsGroup() {
+			action = types.ActionSendGroupMsg
+		}
 	}
 
-	return &result, nil
-}
-
-// GetVersionInfo 获取版本信息
-func (s *WSServer) GetVersionInfo() (*types.GetVersionInfoResponse, error) {
-	resp, err := s.CallAPI(types.ActionGetVersionInfo, nil)
+	resp, err := bot.call(msg.Context, action, params)
 	if err != nil {
 		return nil, err
 	}
 
-	var result types.GetVersionInfoResponse
+	var result types.SendMessageResponse
 	if err := mapToStruct(resp.Data, &result); err != nil {
 		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
+	s.history.Add(history.FromSent(msg.Params, result.MessageID))
 
 	return &result, nil
 }
@@ -0,0 +1,38 @@
+package server_test
+
+import (
+	"testing"
+	"time"
+
+	"onebot-go2/internal/server"
+	types "onebot-go2/pkg/const"
+	"onebot-go2/pkg/event"
+	"onebot-go2/pkg/onebottest"
+)
+
+func TestSyncDispatchDoesNotBlockResponses(t *testing.T) {
+	srv := server.NewWSServer("")
+	replied := make(chan error, 1)
+	err := event.RegisterFunc(srv.GetDispatcher(), "echo", 0, func(ctx *event.Context[*types.MessageEvent]) error {
+		_, err := ctx.ReplyText("pong")
+		replied <- err
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	bot := onebottest.Start(t, srv)
+	// 同步分发时处理器等待 API 响应，响应必须能在事件处理期间被读取
+	srv.GetDispatcher().SetAsync(false)
+
+	bot.Inject(event.GroupMessage(100, 10001, "ping"))
+	select {
+	case err := <-replied:
+		if err != nil {
+			t.Fatalf("ReplyText() error = %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("reply blocked while the event was being handled")
+	}
+	bot.AssertGroupMessage(100, "pong")
+}
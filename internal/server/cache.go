@@ -0,0 +1,231 @@
+package server
+
+import (
+	"fmt"
+	"time"
+
+	types "onebot-go2/pkg/const"
+	"onebot-go2/pkg/infocache"
+)
+
+// ============ 信息获取 API（经过信息缓存） ============
+// 未设置缓存、传入 noCache 或绑定的 context 带有 infocache.WithNoCache 时直接调用 API，
+// 调用成功后结果写入缓存
+
+// SetInfoCache 设置群信息、群成员信息和好友列表的缓存，为空表示不缓存
+func (s *WSServer) SetInfoCache(cache *infocache.Cache) {
+	s.cache = cache
+}
+
+// InfoCache 返回信息缓存
+func (s *WSServer) InfoCache() *infocache.Cache {
+	return s.cache
+}
+
+// SetCacheWarmup 设置连接建立后是否预先获取群列表和好友列表，members 表示同时获取每个群的成员列表
+func (s *WSServer) SetCacheWarmup(enabled, members bool) {
+	s.warmup = enabled
+	s.warmMembers = members
+}
+
+// useCache 判断本次查询是否读取缓存
+func (s *WSServer) useCache(noCache bool) bool {
+	return s.cache != nil && !noCache && !infocache.NoCache(s.ctx)
+}
+
+// cacheKey 返回 API 调用目标机器人的 self_id，作为缓存的键
+func (s *WSServer) cacheKey() (int64, error) {
+	bot, err := s.client()
+	if err != nil {
+		return 0, err
+	}
+	return bot.selfID, nil
+}
+
+// GetGroupInfo 获取群信息
+func (s *WSServer) GetGroupInfo(groupID int64, noCache bool) (*types.GetGroupInfoResponse, error) {
+	selfID, err := s.cacheKey()
+	if err != nil {
+		return nil, err
+	}
+	if s.useCache(noCache) {
+		info, ok := s.cache.Group(selfID, groupID)
+		s.metrics.ObserveCache(infocache.KindGroup, ok)
+		if ok {
+			result := types.GetGroupInfoResponse(info)
+			return &result, nil
+		}
+	}
+
+	params := types.GetGroupInfoParams{
+		GroupID: groupID,
+		NoCache: noCache,
+	}
+
+	resp, err := s.CallAPI(types.ActionGetGroupInfo, params)
+	if err != nil {
+		return nil, err
+	}
+
+	var result types.GetGroupInfoResponse
+	if err := mapToStruct(resp.Data, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	s.cache.SetGroup(selfID, types.GroupInfo(result))
+
+	return &result, nil
+}
+
+// GetGroupList 获取群列表
+func (s *WSServer) GetGroupList() (types.GetGroupListResponse, error) {
+	selfID, err := s.cacheKey()
+	if err != nil {
+		return nil, err
+	}
+	if s.useCache(false) {
+		list, ok := s.cache.GroupList(selfID)
+		s.metrics.ObserveCache(infocache.KindGroupList, ok)
+		if ok {
+			return list, nil
+		}
+	}
+
+	resp, err := s.CallAPI(types.ActionGetGroupList, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result types.GetGroupListResponse
+	if err := mapToStruct(resp.Data, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	s.cache.SetGroupList(selfID, result)
+
+	return result, nil
+}
+
+// GetGroupMemberInfo 获取群成员信息
+func (s *WSServer) GetGroupMemberInfo(groupID, userID int64, noCache bool) (*types.GetGroupMemberInfoResponse, error) {
+	selfID, err := s.cacheKey()
+	if err != nil {
+		return nil, err
+	}
+	if s.useCache(noCache) {
+		info, ok := s.cache.Member(selfID, groupID, userID)
+		s.metrics.ObserveCache(infocache.KindMember, ok)
+		if ok {
+			result := types.GetGroupMemberInfoResponse(info)
+			return &result, nil
+		}
+	}
+
+	params := types.GetGroupMemberInfoParams{
+		GroupID: groupID,
+		UserID:  userID,
+		NoCache: noCache,
+	}
+
+	resp, err := s.CallAPI(types.ActionGetGroupMemberInfo, params)
+	if err != nil {
+		return nil, err
+	}
+
+	var result types.GetGroupMemberInfoResponse
+	if err := mapToStruct(resp.Data, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	s.cache.SetMember(selfID, types.GroupMemberInfo(result))
+
+	return &result, nil
+}
+
+// GetGroupMemberList 获取群成员列表
+func (s *WSServer) GetGroupMemberList(groupID int64) (types.GetGroupMemberListResponse, error) {
+	selfID, err := s.cacheKey()
+	if err != nil {
+		return nil, err
+	}
+	if s.useCache(false) {
+		list, ok := s.cache.MemberList(selfID, groupID)
+		s.metrics.ObserveCache(infocache.KindMemberList, ok)
+		if ok {
+			return list, nil
+		}
+	}
+
+	params := types.GetGroupMemberListParams{
+		GroupID: groupID,
+	}
+
+	resp, err := s.CallAPI(types.ActionGetGroupMemberList, params)
+	if err != nil {
+		return nil, err
+	}
+
+	var result types.GetGroupMemberListResponse
+	if err := mapToStruct(resp.Data, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	s.cache.SetMemberList(selfID, groupID, result)
+
+	return result, nil
+}
+
+// GetFriendList 获取好友列表
+func (s *WSServer) GetFriendList() (types.GetFriendListResponse, error) {
+	selfID, err := s.cacheKey()
+	if err != nil {
+		return nil, err
+	}
+	if s.useCache(false) {
+		list, ok := s.cache.FriendList(selfID)
+		s.metrics.ObserveCache(infocache.KindFriendList, ok)
+		if ok {
+			return list, nil
+		}
+	}
+
+	resp, err := s.CallAPI(types.ActionGetFriendList, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result types.GetFriendListResponse
+	if err := mapToStruct(resp.Data, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	s.cache.SetFriendList(selfID, result)
+
+	return result, nil
+}
+
+// warmCache 预先获取群列表和好友列表写入缓存，在连接建立后由单独的 goroutine 调用
+// 预热的查询不读取缓存，不计入命中统计
+func (s *WSServer) warmCache(selfID int64) {
+	start := time.Now()
+	logger := s.logger.With("self_id", selfID)
+	bot := &WSServer{wsState: s.wsState, ctx: infocache.WithNoCache(s.ctx), selfID: selfID}
+
+	friends, err := bot.GetFriendList()
+	if err != nil {
+		logger.Warn("Failed to warm friend list cache", "error", err)
+	}
+	groups, err := bot.GetGroupList()
+	if err != nil {
+		logger.Warn("Failed to warm group list cache", "error", err)
+	}
+
+	members := 0
+	if s.warmMembers {
+		for _, group := range groups {
+			list, err := bot.GetGroupMemberList(group.GroupID)
+			if err != nil {
+				logger.Warn("Failed to warm group member list cache", "group_id", group.GroupID, "error", err)
+				continue
+			}
+			members += len(list)
+		}
+	}
+
+	logger.Info("Info cache warmed", "groups", len(groups), "friends", len(friends), "members", members, "duration", time.Since(start))
+}
@@ -0,0 +1,111 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	types "onebot-go2/pkg/const"
+	"onebot-go2/pkg/message"
+	"onebot-go2/pkg/outbound"
+)
+
+// ErrInvalidParams 通过 CallAPI 发送或撤回消息时 params 无法解析
+var ErrInvalidParams = errors.New("invalid params")
+
+// isOutboundAction 是否为经过发出消息拦截器的 action
+func isOutboundAction(action string) bool {
+	switch action {
+	case types.ActionSendPrivateMsg, types.ActionSendGroupMsg, types.ActionSendMsg, types.ActionDeleteMsg,
+		types.ActionSendGroupForwardMsg, types.ActionSendPrivateForwardMsg:
+		return true
+	}
+	return false
+}
+
+// inferMessageType 未指定 message_type 时按 group_id 判断，拦截器总能看到目标类型
+func inferMessageType(params *types.SendMessageParams) {
+	if params.MessageType == "" {
+		params.MessageType = types.MessageTypePrivate
+		if params.GroupID != 0 {
+			params.MessageType = types.MessageTypeGroup
+		}
+	}
+}
+
+// callOutbound 将 CallAPI 发起的发送或撤回转换为 outbound.Message，经过拦截器后发送
+// 响应只包含 message_id，params 中 OneBot 11 之外的字段不会被转发
+func (s *WSServer) callOutbound(ctx context.Context, action string, params interface{}) (*types.APIResponse, error) {
+	view := &WSServer{wsState: s.wsState, ctx: ctx, selfID: s.selfID}
+	data, err := json.Marshal(params)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w: %v", action, ErrInvalidParams, err)
+	}
+
+	if action == types.ActionDeleteMsg {
+		var p types.DeleteMsgParams
+		if err := json.Unmarshal(data, &p); err != nil {
+			return nil, fmt.Errorf("%s: %w: %v", action, ErrInvalidParams, err)
+		}
+		if err := view.DeleteMsg(p.MessageID); err != nil {
+			return nil, err
+		}
+		return &types.APIResponse{Status: "ok"}, nil
+	}
+
+	msg, err := decodeSend(action, data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w: %v", action, ErrInvalidParams, err)
+	}
+	result, err := view.send(msg)
+	if err != nil {
+		return nil, err
+	}
+	return &types.APIResponse{Status: "ok", Data: result}, nil
+}
+
+// decodeSend 解析发送消息的 params，message 的格式见 message.Decode
+// 合并转发 action 的消息在 messages 中
+func decodeSend(action string, data []byte) (*outbound.Message, error) {
+	var raw struct {
+		MessageType types.MessageType `json:"message_type"`
+		UserID      int64             `json:"user_id"`
+		GroupID     int64             `json:"group_id"`
+		Message     json.RawMessage   `json:"message"`
+		Messages    json.RawMessage   `json:"messages"`
+		AutoEscape  bool              `json:"auto_escape"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	msg := &outbound.Message{Action: action, Params: types.SendMessageParams{
+		MessageType: raw.MessageType,
+		UserID:      raw.UserID,
+		GroupID:     raw.GroupID,
+	}}
+	switch action {
+	case types.ActionSendPrivateMsg:
+		msg.Params.MessageType = types.MessageTypePrivate
+	case types.ActionSendGroupMsg, types.ActionSendGroupForwardMsg:
+		msg.Params.MessageType = types.MessageTypeGroup
+	case types.ActionSendPrivateForwardMsg:
+		msg.Params.MessageType = types.MessageTypePrivate
+	default:
+		inferMessageType(&msg.Params)
+	}
+	if msg.IsForward() {
+		if len(raw.Messages) == 0 {
+			return nil, errors.New("messages is required")
+		}
+		raw.Message = raw.Messages
+	}
+
+	messages, err := message.Decode(raw.Message, raw.AutoEscape)
+	if err != nil {
+		return nil, err
+	}
+	msg.Params.Message = messages
+	return msg, nil
+}
@@ -0,0 +1,216 @@
+package server_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"onebot-go2/internal/server"
+	types "onebot-go2/pkg/const"
+	"onebot-go2/pkg/longmsg"
+	"onebot-go2/pkg/message"
+	"onebot-go2/pkg/onebottest"
+	"onebot-go2/pkg/outbound"
+)
+
+func TestCallAPIOutbound(t *testing.T) {
+	tests := []struct {
+		name     string
+		action   string
+		params   interface{}
+		veto     bool
+		wantType types.MessageType // 拦截器看到的消息类型
+		wantText string            // 客户端收到的消息文本
+		wantAt   bool              // 客户端收到的消息包含 at 消息段
+		wantErr  error
+	}{
+		{
+			name:     "cq string with inferred group",
+			action:   types.ActionSendMsg,
+			params:   map[string]interface{}{"group_id": 100, "message": "hi [CQ:at,qq=10001] &#91;ok&#93;"},
+			wantType: types.MessageTypeGroup,
+			wantText: "hi  [ok]",
+			wantAt:   true,
+		},
+		{
+			name:     "auto escape keeps cq text",
+			action:   types.ActionSendPrivateMsg,
+			params:   map[string]interface{}{"user_id": 10001, "message": "[CQ:at,qq=1]", "auto_escape": true},
+			wantType: types.MessageTypePrivate,
+			wantText: "[CQ:at,qq=1]",
+		},
+		{
+			name:     "segment array",
+			action:   types.ActionSendGroupMsg,
+			params:   types.SendMessageParams{GroupID: 100, Message: message.AtText(10001, "hello")},
+			wantType: types.MessageTypeGroup,
+			wantText: " hello",
+			wantAt:   true,
+		},
+		{
+			name:     "single segment",
+			action:   types.ActionSendMsg,
+			params:   map[string]interface{}{"user_id": 10001, "message": map[string]interface{}{"type": "text", "data": map[string]interface{}{"text": "one"}}},
+			wantType: types.MessageTypePrivate,
+			wantText: "one",
+		},
+		{
+			name:     "vetoed",
+			action:   types.ActionSendGroupMsg,
+			params:   map[string]interface{}{"group_id": 100, "message": "blocked"},
+			veto:     true,
+			wantType: types.MessageTypeGroup,
+			wantErr:  outbound.ErrVetoed,
+		},
+		{
+			name:    "missing message",
+			action:  types.ActionSendGroupMsg,
+			params:  map[string]interface{}{"group_id": 100},
+			wantErr: server.ErrInvalidParams,
+		},
+		{
+			name:    "invalid message",
+			action:  types.ActionSendGroupMsg,
+			params:  map[string]interface{}{"group_id": 100, "message": 42},
+			wantErr: server.ErrInvalidParams,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := server.NewWSServer("")
+			var seen []*outbound.Message
+			srv.UseOutbound(func(next outbound.SendFunc) outbound.SendFunc {
+				return func(msg *outbound.Message) (*types.SendMessageResponse, error) {
+					seen = append(seen, msg)
+					if tt.veto {
+						return nil, outbound.Veto("test")
+					}
+					return next(msg)
+				}
+			})
+			bot := onebottest.Start(t, srv)
+
+			resp, err := srv.CallAPI(tt.action, tt.params)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("CallAPI() error = %v, want %v", err, tt.wantErr)
+				}
+				bot.AssertNoCalls(tt.action, 50*time.Millisecond)
+			} else {
+				if err != nil {
+					t.Fatalf("CallAPI() error = %v", err)
+				}
+				if resp.Status != "ok" {
+					t.Errorf("Status = %q, want ok", resp.Status)
+				}
+				call := bot.AssertCalled(tt.action)
+				if got := call.Text(); got != tt.wantText {
+					t.Errorf("sent text = %q, want %q", got, tt.wantText)
+				}
+				hasAt := false
+				for _, seg := range call.Message() {
+					hasAt = hasAt || seg.Type == "at"
+				}
+				if hasAt != tt.wantAt {
+					t.Errorf("sent message %v, want at segment: %v", call.Message(), tt.wantAt)
+				}
+			}
+
+			if tt.wantType == "" {
+				if len(seen) != 0 {
+					t.Errorf("interceptor saw %d messages for invalid params", len(seen))
+				}
+				return
+			}
+			if len(seen) != 1 {
+				t.Fatalf("interceptor saw %d messages, want 1", len(seen))
+			}
+			if seen[0].Params.MessageType != tt.wantType {
+				t.Errorf("MessageType = %q, want %q", seen[0].Params.MessageType, tt.wantType)
+			}
+		})
+	}
+}
+
+func TestCallAPIOtherActionsBypassInterceptors(t *testing.T) {
+	srv := server.NewWSServer("")
+	srv.UseOutbound(func(next outbound.SendFunc) outbound.SendFunc {
+		return func(msg *outbound.Message) (*types.SendMessageResponse, error) {
+			return nil, outbound.Veto("test")
+		}
+	})
+	bot := onebottest.Start(t, srv)
+
+	if _, err := srv.CallAPI(types.ActionGetStatus, nil); err != nil {
+		t.Fatalf("CallAPI(get_status) error = %v", err)
+	}
+	bot.AssertCalled(types.ActionGetStatus)
+}
+
+func TestLongMessageForward(t *testing.T) {
+	tests := []struct {
+		name        string
+		unsupported bool
+		wantSends   int // 回退后拆分发送的条数
+	}{
+		{name: "forward action"},
+		{name: "unsupported forward falls back to split", unsupported: true, wantSends: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := server.NewWSServer("")
+			srv.UseOutbound(longmsg.New(longmsg.Config{Mode: longmsg.ModeForward, MaxLength: 5}).Interceptor())
+			bot := onebottest.Start(t, srv)
+			if tt.unsupported {
+				bot.Fail(types.ActionSendGroupForwardMsg, 1404, "unsupported action")
+			}
+
+			if _, err := srv.SendGroupMsg(100, message.Text("aaaaabbbbbcc")); err != nil {
+				t.Fatalf("SendGroupMsg() error = %v", err)
+			}
+			call := bot.AssertCalled(types.ActionSendGroupForwardMsg)
+			if g, _ := call.Target(); g != 100 {
+				t.Errorf("forward sent to group %d, want 100", g)
+			}
+			if nodes := call.Message(); len(nodes) != 3 || nodes[0].Type != "node" {
+				t.Errorf("forward messages = %v, want 3 nodes", nodes)
+			}
+			if got := len(bot.CallsTo(types.ActionSendGroupMsg)); got != tt.wantSends {
+				t.Errorf("send_group_msg called %d times, want %d", got, tt.wantSends)
+			}
+			if tt.unsupported {
+				bot.AssertGroupMessage(100, "cc")
+			}
+		})
+	}
+}
+
+func TestCallAPIForward(t *testing.T) {
+	srv := server.NewWSServer("")
+	var seen *outbound.Message
+	srv.UseOutbound(func(next outbound.SendFunc) outbound.SendFunc {
+		return func(msg *outbound.Message) (*types.SendMessageResponse, error) {
+			seen = msg
+			return next(msg)
+		}
+	})
+	bot := onebottest.Start(t, srv)
+
+	nodes := message.NewBuilder().Node(10001, "bot", message.Text("hi")).Build()
+	if _, err := srv.CallAPI(types.ActionSendPrivateForwardMsg, map[string]interface{}{"user_id": 10001, "messages": nodes}); err != nil {
+		t.Fatalf("CallAPI() error = %v", err)
+	}
+	if seen == nil || !seen.IsForward() || seen.IsGroup() || len(seen.Params.Message) != 1 {
+		t.Fatalf("interceptor saw %+v, want a private forward message", seen)
+	}
+	call := bot.AssertCalled(types.ActionSendPrivateForwardMsg)
+	if _, u := call.Target(); u != 10001 || len(call.Message()) != 1 {
+		t.Errorf("sent %s", call)
+	}
+
+	if _, err := srv.CallAPI(types.ActionSendGroupForwardMsg, map[string]interface{}{"group_id": 100}); !errors.Is(err, server.ErrInvalidParams) {
+		t.Errorf("CallAPI() without messages error = %v, want ErrInvalidParams", err)
+	}
+}
@@ -0,0 +1,92 @@
+package server
+
+import (
+	"fmt"
+	"sort"
+
+	types "onebot-go2/pkg/const"
+	"onebot-go2/pkg/event"
+)
+
+// retCodeUnsupported OneBot 11 中 action 不存在的 retcode
+const retCodeUnsupported = 1404
+
+// Self 返回 API 调用目标机器人的身份信息，未连接时返回零值
+func (s *WSServer) Self() event.Self {
+	bot, err := s.client()
+	if err != nil {
+		return event.Self{SelfID: s.selfID}
+	}
+	return bot.identity()
+}
+
+// identity 返回连接的身份信息，附带已知不支持的 action
+func (b *botConn) identity() event.Self {
+	self := event.Self{SelfID: b.selfID, ConnectedAt: b.since}
+	if known := b.self.Load(); known != nil {
+		self = *known
+	}
+	self.Unsupported = nil
+	b.unsupported.Range(func(action, _ interface{}) bool {
+		self.Unsupported = append(self.Unsupported, action.(string))
+		return true
+	})
+	sort.Strings(self.Unsupported)
+	return self
+}
+
+// unsupportedError 返回 action 不受支持的错误，action 未返回过 1404 时返回 nil
+func (b *botConn) unsupportedError(action string) error {
+	if _, ok := b.unsupported.Load(action); ok {
+		return fmt.Errorf("%s: %w", action, event.ErrUnsupported)
+	}
+	return nil
+}
+
+// identify 获取新连接的机器人身份信息，在连接建立后由单独的 goroutine 调用
+// get_version_info、get_status 失败时对应字段保持为空，不影响其他信息
+func (s *WSServer) identify(current *botConn) {
+	bot := s.ForBot(current.selfID)
+	logger := s.logger.With("self_id", current.selfID)
+	self := event.Self{SelfID: current.selfID, ConnectedAt: current.since}
+
+	login, err := bot.GetLoginInfo()
+	if err != nil {
+		logger.Warn("Failed to get login info", "error", err)
+		return
+	}
+	if current.selfID != 0 && login.UserID != current.selfID {
+		logger.Warn("Login info does not match X-Self-ID", "user_id", login.UserID)
+	}
+	self.SelfID = login.UserID
+	self.Nickname = login.Nickname
+
+	if version, err := bot.GetVersionInfo(); err != nil {
+		logger.Warn("Failed to get version info", "error", err)
+	} else {
+		self.AppName = version.AppName
+		self.AppVersion = version.AppVersion
+		self.ProtocolVersion = version.ProtocolVersion
+	}
+
+	if status, err := bot.GetStatus(); err != nil {
+		logger.Warn("Failed to get status", "error", err)
+	} else {
+		self.Online = status.Online
+		self.Good = status.Good
+	}
+
+	current.self.Store(&self)
+	logger.Info("Bot identified", "user_id", self.SelfID, "nickname", self.Nickname,
+		"app_name", self.AppName, "app_version", self.AppVersion, "protocol_version", self.ProtocolVersion,
+		"online", self.Online)
+}
+
+// markUnsupported 记录返回 1404 的 action
+func (b *botConn) markUnsupported(action string, resp *types.APIResponse) bool {
+	if resp.RetCode != retCodeUnsupported {
+		return false
+	}
+	b.unsupported.Store(action, struct{}{})
+	return true
+}
@@ -0,0 +1,206 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	types "onebot-go2/pkg/const"
+)
+
+// 推送条目的类型
+const (
+	StreamKindEvent = "event" // 收到的事件
+	StreamKindAPI   = "api"   // 发出的 API 调用
+)
+
+// StreamItem 推送给订阅者的条目
+type StreamItem struct {
+	Kind     string      `json:"kind"` // event 或 api
+	Time     time.Time   `json:"time"`
+	SelfID   int64       `json:"self_id,omitempty"`
+	PostType string      `json:"post_type,omitempty"` // 事件的 post_type，API 调用为空
+	GroupID  int64       `json:"group_id,omitempty"`
+	UserID   int64       `json:"user_id,omitempty"`
+	Data     interface{} `json:"data"` // 解析后的事件或 *APICallRecord
+}
+
+// APICallRecord 一次已结束的 API 调用
+type APICallRecord struct {
+	Action     string          `json:"action"`
+	Params     json.RawMessage `json:"params,omitempty"`
+	Echo       string          `json:"echo"`
+	Status     string          `json:"status"` // OneBot 响应状态，未收到响应时为 timeout 或 error
+	RetCode    int             `json:"retcode"`
+	Error      string          `json:"error,omitempty"`
+	DurationMS float64         `json:"duration_ms"`
+}
+
+// StreamFilter 订阅过滤条件，零值表示不限制
+type StreamFilter struct {
+	Kinds     []string // event、api
+	PostTypes []string // 只对事件生效
+	SelfID    int64
+	GroupID   int64
+	UserID    int64
+}
+
+// Match 判断条目是否满足过滤条件
+func (f StreamFilter) Match(item *StreamItem) bool {
+	if len(f.Kinds) > 0 && !contains(f.Kinds, item.Kind) {
+		return false
+	}
+	if len(f.PostTypes) > 0 && item.Kind == StreamKindEvent && !contains(f.PostTypes, item.PostType) {
+		return false
+	}
+	return (f.SelfID == 0 || f.SelfID == item.SelfID) &&
+		(f.GroupID == 0 || f.GroupID == item.GroupID) &&
+		(f.UserID == 0 || f.UserID == item.UserID)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Stream 将收到的事件和发出的 API 调用推送给订阅者（如 SSE 客户端）
+// 每个订阅者有独立的有界缓冲区，缓冲区满时断开该订阅者，不会阻塞事件分发和 API 调用
+type Stream struct {
+	mu    sync.RWMutex
+	subs  map[*Subscription]struct{}
+	count atomic.Int32
+}
+
+// Subscription 一个订阅者
+type Subscription struct {
+	ch      chan *StreamItem
+	filter  StreamFilter
+	dropped atomic.Bool
+	once    sync.Once
+}
+
+// NewStream 创建推送流
+func NewStream() *Stream {
+	return &Stream{subs: make(map[*Subscription]struct{})}
+}
+
+// Subscribe 添加订阅者，buffer 为缓冲区大小
+func (st *Stream) Subscribe(filter StreamFilter, buffer int) *Subscription {
+	if buffer <= 0 {
+		buffer = 1
+	}
+	sub := &Subscription{ch: make(chan *StreamItem, buffer), filter: filter}
+	st.mu.Lock()
+	st.subs[sub] = struct{}{}
+	st.count.Store(int32(len(st.subs)))
+	st.mu.Unlock()
+	return sub
+}
+
+// Unsubscribe 移除订阅者并关闭其通道，可以重复调用
+func (st *Stream) Unsubscribe(sub *Subscription) {
+	st.mu.Lock()
+	delete(st.subs, sub)
+	st.count.Store(int32(len(st.subs)))
+	st.mu.Unlock()
+	sub.once.Do(func() { close(sub.ch) })
+}
+
+// Active 返回是否有订阅者，没有时发布方可以跳过构造条目
+func (st *Stream) Active() bool {
+	return st.count.Load() > 0
+}
+
+// Publish 推送条目，缓冲区已满的订阅者被断开
+func (st *Stream) Publish(item *StreamItem) {
+	if !st.Active() {
+		return
+	}
+	var slow []*Subscription
+	st.mu.RLock()
+	for sub := range st.subs {
+		if !sub.filter.Match(item) {
+			continue
+		}
+		select {
+		case sub.ch <- item:
+		default:
+			slow = append(slow, sub)
+		}
+	}
+	st.mu.RUnlock()
+
+	for _, sub := range slow {
+		sub.dropped.Store(true)
+		st.Unsubscribe(sub)
+	}
+}
+
+// Items 返回条目通道，订阅被移除或断开后关闭
+func (sub *Subscription) Items() <-chan *StreamItem {
+	return sub.ch
+}
+
+// Dropped 返回订阅是否因缓冲区满被断开
+func (sub *Subscription) Dropped() bool {
+	return sub.dropped.Load()
+}
+
+// eventItem 为收到的事件构造推送条目
+func eventItem(evt interface{}) *StreamItem {
+	item := &StreamItem{Kind: StreamKindEvent, Time: time.Now(), Data: evt}
+	var base *types.Event
+	switch e := evt.(type) {
+	case *types.MessageEvent:
+		base, item.GroupID, item.UserID = &e.Event, e.GroupID, e.UserID
+	case *types.NoticeEvent:
+		base, item.GroupID, item.UserID = &e.Event, e.GroupID, e.UserID
+	case *types.RequestEvent:
+		base, item.GroupID, item.UserID = &e.Event, e.GroupID, e.UserID
+	case *types.MetaEvent:
+		base = &e.Event
+	}
+	if base != nil {
+		item.SelfID, item.PostType = base.SelfID, string(base.PostType)
+	}
+	return item
+}
+
+// apiItem 为已结束的 API 调用构造推送条目，group_id、user_id 取自参数
+func apiItem(selfID int64, record *APICallRecord) *StreamItem {
+	item := &StreamItem{Kind: StreamKindAPI, Time: time.Now(), SelfID: selfID, Data: record}
+	var target struct {
+		GroupID int64 `json:"group_id"`
+		UserID  int64 `json:"user_id"`
+	}
+	if len(record.Params) > 0 && json.Unmarshal(record.Params, &target) == nil {
+		item.GroupID, item.UserID = target.GroupID, target.UserID
+	}
+	return item
+}
+
+// apiRecord 构造 API 调用记录
+func apiRecord(action string, params interface{}, echo string, resp *types.APIResponse, err error, duration time.Duration) *APICallRecord {
+	record := &APICallRecord{Action: action, Echo: echo, DurationMS: float64(duration) / float64(time.Millisecond)}
+	if params != nil {
+		record.Params, _ = json.Marshal(params)
+	}
+	switch {
+	case resp != nil:
+		record.Status, record.RetCode = resp.Status, resp.RetCode
+	case errors.Is(err, ErrCallTimeout):
+		record.Status = "timeout"
+	default:
+		record.Status = "error"
+	}
+	if err != nil {
+		record.Error = err.Error()
+	}
+	return record
+}
@@ -0,0 +1,277 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	types "onebot-go2/pkg/const"
+	"onebot-go2/pkg/event"
+	"onebot-go2/pkg/permission"
+	"onebot-go2/pkg/ratelimit"
+)
+
+// ParamType 命令参数类型
+type ParamType int
+
+const (
+	ParamString   ParamType = iota // 单个词，支持引号包裹含空格的内容
+	ParamInt64                     // 64 位整数
+	ParamDuration                  // 时长，纯数字按秒计算，也支持 10m、1h30m、2d
+	ParamUser                      // 用户，可以是 @ 消息段或 QQ 号
+	ParamRest                      // 剩余的整行文本，必须是最后一个参数
+)
+
+// String 返回参数类型的显示名称
+func (t ParamType) String() string {
+	switch t {
+	case ParamString:
+		return "文本"
+	case ParamInt64:
+		return "整数"
+	case ParamDuration:
+		return "时长"
+	case ParamUser:
+		return "用户"
+	case ParamRest:
+		return "文本"
+	default:
+		return "未知"
+	}
+}
+
+// Param 命令参数声明
+type Param struct {
+	Name        string      // 参数名，用于 Args 取值和用法提示
+	Type        ParamType   // 参数类型
+	Optional    bool        // 是否可选
+	Default     interface{} // 默认值，设置后参数自动视为可选
+	Description string      // 参数说明
+}
+
+// isOptional 判断参数是否可以省略
+func (p Param) isOptional() bool {
+	return p.Optional || p.Default != nil
+}
+
+// placeholder 返回参数在用法中的占位符，如 <user>、[reason...]
+func (p Param) placeholder() string {
+	name := p.Name
+	if p.Type == ParamRest {
+		name += "..."
+	}
+	if p.isOptional() {
+		return "[" + name + "]"
+	}
+	return "<" + name + ">"
+}
+
+// Handler 命令处理函数
+type Handler func(ctx *event.Context[*types.MessageEvent], args *Args) error
+
+// Command 命令声明
+type Command struct {
+	Name        string     // 命令名
+	Aliases     []string   // 别名
+	Description string     // 命令说明
+	Params      []Param    // 参数声明
+	Subcommands []*Command // 子命令
+	Handler     Handler    // 处理函数，只有子命令的命令可以为空
+
+	// Permission 执行命令所需的权限等级，子命令取自身与父命令中较高者
+	Permission permission.Level
+	// Cooldown 命令冷却规则，为空表示不限制；key 会自动加上命令路径
+	// 父命令的冷却同样作用于子命令，所有子命令共享父命令的冷却
+	Cooldown *ratelimit.Rule
+	// Plugin 命令所属插件，通过插件注册时自动设置；插件在群内关闭时命令也不响应
+	Plugin string
+}
+
+// Match 判断名称是否匹配命令名或别名
+func (c *Command) Match(name string) bool {
+	if strings.EqualFold(c.Name, name) {
+		return true
+	}
+	for _, alias := range c.Aliases {
+		if strings.EqualFold(alias, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Subcommand 根据名称或别名查找子命令
+func (c *Command) Subcommand(name string) (*Command, bool) {
+	for _, sub := range c.Subcommands {
+		if sub.Match(name) {
+			return sub, true
+		}
+	}
+	return nil, false
+}
+
+// validate 检查命令声明是否合法
+func (c *Command) validate() error {
+	if c.Name == "" || strings.ContainsAny(c.Name, " \t\n") {
+		return fmt.Errorf("invalid command name %q", c.Name)
+	}
+	if c.Handler == nil && len(c.Subcommands) == 0 {
+		return fmt.Errorf("command %s has neither handler nor subcommands", c.Name)
+	}
+	seen := make(map[string]bool)
+	for i, p := range c.Params {
+		if p.Name == "" {
+			return fmt.Errorf("command %s: param %d has no name", c.Name, i)
+		}
+		if seen[p.Name] {
+			return fmt.Errorf("command %s: duplicate param %s", c.Name, p.Name)
+		}
+		seen[p.Name] = true
+		if p.Type == ParamRest && i != len(c.Params)-1 {
+			return fmt.Errorf("command %s: rest param %s must be the last one", c.Name, p.Name)
+		}
+	}
+	for _, sub := range c.Subcommands {
+		if err := sub.validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UsageLine 返回单行用法，如 "/ban <user> <duration>"
+func (c *Command) UsageLine(prefix string, path ...string) string {
+	names := append(append([]string{}, path...), c.Name)
+	parts := append([]string{prefix + strings.Join(names, " ")}, paramPlaceholders(c.Params)...)
+	if c.Handler == nil && len(c.Subcommands) > 0 {
+		parts = append(parts, "<子命令>")
+	}
+	return strings.Join(parts, " ")
+}
+
+// Usage 返回完整用法说明，包括参数说明和子命令列表
+func (c *Command) Usage(prefix string, path ...string) string {
+	var sb strings.Builder
+	sb.WriteString(c.UsageLine(prefix, path...))
+	if c.Description != "" {
+		sb.WriteString(" - ")
+		sb.WriteString(c.Description)
+	}
+	if len(c.Aliases) > 0 {
+		sb.WriteString("\n别名：")
+		sb.WriteString(strings.Join(c.Aliases, ", "))
+	}
+	for _, p := range c.Params {
+		sb.WriteString(fmt.Sprintf("\n  %s（%s）", p.placeholder(), p.Type))
+		if p.Description != "" {
+			sb.WriteString(" ")
+			sb.WriteString(p.Description)
+		}
+		if p.Default != nil {
+			sb.WriteString(fmt.Sprintf("，默认 %v", p.Default))
+		}
+	}
+	subPath := append(append([]string{}, path...), c.Name)
+	for _, sub := range c.Subcommands {
+		sb.WriteString("\n  ")
+		sb.WriteString(sub.UsageLine(prefix, subPath...))
+		if sub.Description != "" {
+			sb.WriteString(" - ")
+			sb.WriteString(sub.Description)
+		}
+	}
+	return sb.String()
+}
+
+func paramPlaceholders(params []Param) []string {
+	result := make([]string, 0, len(params))
+	for _, p := range params {
+		result = append(result, p.placeholder())
+	}
+	return result
+}
+
+// UsageError 命令用法错误，由 Registry 自动回复给用户
+type UsageError struct {
+	Command *Command // 出错的命令
+	Path    []string // 父命令路径
+	Reason  string   // 错误原因
+
+	required permission.Level
+}
+
+func (e *UsageError) Error() string {
+	return e.Reason
+}
+
+// ============ 解析后的参数 ============
+
+// Args 解析后的命令参数
+type Args struct {
+	registry *Registry
+	command  *Command
+	parents  []*Command // 从最外层开始的父命令，与 path 对应
+	path     []string
+	values   map[string]interface{}
+	required permission.Level
+}
+
+// Registry 返回命令所属的注册表
+func (a *Args) Registry() *Registry {
+	return a.registry
+}
+
+// Command 返回匹配到的命令（子命令时为子命令本身）
+func (a *Args) Command() *Command {
+	return a.command
+}
+
+// Path 返回完整命令路径，如 ["plugin", "on"]
+func (a *Args) Path() []string {
+	return append(append([]string{}, a.path...), a.command.Name)
+}
+
+// Permission 返回执行该命令所需的权限等级
+func (a *Args) Permission() permission.Level {
+	return a.required
+}
+
+// Has 判断参数是否提供（或有默认值）
+func (a *Args) Has(name string) bool {
+	_, ok := a.values[name]
+	return ok
+}
+
+// Get 获取参数原始值
+func (a *Args) Get(name string) (interface{}, bool) {
+	val, ok := a.values[name]
+	return val, ok
+}
+
+// String 获取文本参数，未提供时返回空字符串
+func (a *Args) String(name string) string {
+	val, _ := a.values[name].(string)
+	return val
+}
+
+// Int64 获取整数参数，未提供时返回 0
+func (a *Args) Int64(name string) int64 {
+	switch val := a.values[name].(type) {
+	case int64:
+		return val
+	case int:
+		return int64(val)
+	}
+	return 0
+}
+
+// Duration 获取时长参数，未提供时返回 0
+func (a *Args) Duration(name string) time.Duration {
+	val, _ := a.values[name].(time.Duration)
+	return val
+}
+
+// User 获取用户参数（QQ 号），未提供时返回 0
+func (a *Args) User(name string) int64 {
+	return a.Int64(name)
+}
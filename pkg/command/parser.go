@@ -0,0 +1,293 @@
+package command
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	types "onebot-go2/pkg/const"
+	"onebot-go2/pkg/message"
+)
+
+// quotePairs 支持的引号对
+var quotePairs = map[rune]rune{
+	'"':  '"',
+	'\'': '\'',
+	'“':  '”',
+	'‘':  '’',
+}
+
+// token 命令行中的一个词
+type token struct {
+	text    string         // 文本内容（非文本消息段时为空）
+	segment *types.Message // 非文本消息段，如 at、image
+}
+
+// scanner 按消息段扫描命令行
+// 文本消息段按空白切分并支持引号，非文本消息段各自作为一个词
+type scanner struct {
+	segments types.MessageArray
+	seg      int // 当前消息段下标
+	pos      int // 当前文本消息段内的字节偏移
+}
+
+func newScanner(segments types.MessageArray) *scanner {
+	return &scanner{segments: segments}
+}
+
+func segmentText(seg types.Message) string {
+	text, _ := seg.Data["text"].(string)
+	return text
+}
+
+// skipSpace 跳过空白（可跨越文本消息段）
+func (s *scanner) skipSpace() {
+	for s.seg < len(s.segments) {
+		seg := s.segments[s.seg]
+		if seg.Type != "text" {
+			return
+		}
+		text := segmentText(seg)
+		for s.pos < len(text) {
+			r, size := utf8.DecodeRuneInString(text[s.pos:])
+			if !unicode.IsSpace(r) {
+				return
+			}
+			s.pos += size
+		}
+		s.seg++
+		s.pos = 0
+	}
+}
+
+// next 读取下一个词
+func (s *scanner) next() (token, bool, error) {
+	s.skipSpace()
+	if s.seg >= len(s.segments) {
+		return token{}, false, nil
+	}
+
+	seg := s.segments[s.seg]
+	if seg.Type != "text" {
+		s.seg++
+		s.pos = 0
+		return token{segment: &seg}, true, nil
+	}
+
+	text := segmentText(seg)
+	r, size := utf8.DecodeRuneInString(text[s.pos:])
+	if closing, ok := quotePairs[r]; ok {
+		word, n, err := readQuoted(text[s.pos+size:], closing)
+		if err != nil {
+			return token{}, false, err
+		}
+		s.pos += size + n
+		return token{text: word}, true, nil
+	}
+
+	start := s.pos
+	for s.pos < len(text) {
+		r, size := utf8.DecodeRuneInString(text[s.pos:])
+		if unicode.IsSpace(r) {
+			break
+		}
+		s.pos += size
+	}
+	return token{text: text[start:s.pos]}, true, nil
+}
+
+// readQuoted 读取引号内的内容，返回内容和消耗的字节数（含结束引号）
+// 支持反斜杠转义引号和反斜杠本身
+func readQuoted(text string, closing rune) (string, int, error) {
+	var sb strings.Builder
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		switch {
+		case r == '\\' && i+size < len(text):
+			next, nextSize := utf8.DecodeRuneInString(text[i+size:])
+			if next == closing || next == '\\' {
+				sb.WriteRune(next)
+				i += size + nextSize
+				continue
+			}
+			sb.WriteRune(r)
+		case r == closing:
+			return sb.String(), i + size, nil
+		default:
+			sb.WriteRune(r)
+		}
+		i += size
+	}
+	return "", 0, errors.New("引号未闭合")
+}
+
+// rest 读取剩余的全部内容，非文本消息段按可读形式拼接
+func (s *scanner) rest() string {
+	s.skipSpace()
+	var sb strings.Builder
+	for s.seg < len(s.segments) {
+		seg := s.segments[s.seg]
+		if seg.Type == "text" {
+			sb.WriteString(segmentText(seg)[s.pos:])
+		} else {
+			sb.WriteString(message.String(types.MessageArray{seg}))
+		}
+		s.seg++
+		s.pos = 0
+	}
+	return strings.TrimRightFunc(sb.String(), unicode.IsSpace)
+}
+
+// ============ 参数转换 ============
+
+// bindParams 按声明顺序解析参数
+func bindParams(s *scanner, params []Param) (map[string]interface{}, error) {
+	values := make(map[string]interface{}, len(params))
+
+	for _, p := range params {
+		if p.Type == ParamRest {
+			text := s.rest()
+			if text == "" {
+				if !p.isOptional() {
+					return nil, fmt.Errorf("缺少参数 %s", p.placeholder())
+				}
+				if p.Default != nil {
+					values[p.Name] = p.Default
+				}
+				continue
+			}
+			values[p.Name] = text
+			continue
+		}
+
+		tok, ok, err := s.next()
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			if !p.isOptional() {
+				return nil, fmt.Errorf("缺少参数 %s", p.placeholder())
+			}
+			if p.Default != nil {
+				values[p.Name] = p.Default
+			}
+			continue
+		}
+
+		val, err := convertToken(tok, p.Type)
+		if err != nil {
+			return nil, fmt.Errorf("参数 %s 无效：%v", p.placeholder(), err)
+		}
+		values[p.Name] = val
+	}
+
+	if _, ok, _ := s.next(); ok {
+		return nil, errors.New("参数过多")
+	}
+
+	return values, nil
+}
+
+// convertToken 将词转换为参数类型对应的值
+func convertToken(tok token, typ ParamType) (interface{}, error) {
+	if typ == ParamUser {
+		return parseUser(tok)
+	}
+
+	if tok.segment != nil {
+		return nil, fmt.Errorf("需要%s，但收到了%s", typ, message.String(types.MessageArray{*tok.segment}))
+	}
+
+	switch typ {
+	case ParamString, ParamRest:
+		return tok.text, nil
+	case ParamInt64:
+		n, err := strconv.ParseInt(tok.text, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q 不是整数", tok.text)
+		}
+		return n, nil
+	case ParamDuration:
+		return ParseDuration(tok.text)
+	default:
+		return nil, fmt.Errorf("unsupported param type %d", typ)
+	}
+}
+
+// parseUser 从 at 消息段或 QQ 号解析用户
+func parseUser(tok token) (int64, error) {
+	text := tok.text
+	if tok.segment != nil {
+		if tok.segment.Type != "at" {
+			return 0, fmt.Errorf("需要@用户或 QQ 号，但收到了%s", message.String(types.MessageArray{*tok.segment}))
+		}
+		text = atTarget(tok.segment.Data["qq"])
+		if text == "all" {
+			return 0, errors.New("不能指定全体成员")
+		}
+	}
+
+	userID, err := strconv.ParseInt(strings.TrimPrefix(text, "@"), 10, 64)
+	if err != nil || userID <= 0 {
+		return 0, fmt.Errorf("%q 不是有效的 QQ 号", text)
+	}
+	return userID, nil
+}
+
+// atTarget 返回 at 消息段的 qq 字段
+// 从 JSON 解码时 qq 可能是字符串、float64 或 json.Number，float64 不能直接格式化，否则大号码会变成科学计数法
+func atTarget(qq interface{}) string {
+	switch v := qq.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case json.Number:
+		return v.String()
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case int:
+		return strconv.Itoa(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// ParseDuration 解析时长
+// 纯数字按秒计算，其余格式同 time.ParseDuration，并额外支持 d（天）；时长必须为正数
+func ParseDuration(text string) (time.Duration, error) {
+	d, err := parseDuration(text)
+	if err != nil {
+		return 0, fmt.Errorf("%q 不是有效的时长", text)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("时长 %q 必须大于 0", text)
+	}
+	return d, nil
+}
+
+func parseDuration(text string) (time.Duration, error) {
+	if n, err := strconv.ParseInt(text, 10, 64); err == nil {
+		return multiply(n, time.Second)
+	}
+	if days, ok := strings.CutSuffix(text, "d"); ok {
+		if n, err := strconv.ParseInt(days, 10, 64); err == nil {
+			return multiply(n, 24*time.Hour)
+		}
+	}
+	return time.ParseDuration(text)
+}
+
+// multiply 计算 n 个 unit，溢出时返回错误
+func multiply(n int64, unit time.Duration) (time.Duration, error) {
+	if n > math.MaxInt64/int64(unit) || n < math.MinInt64/int64(unit) {
+		return 0, errors.New("duration overflow")
+	}
+	return time.Duration(n) * unit, nil
+}
@@ -0,0 +1,282 @@
+package command
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	types "onebot-go2/pkg/const"
+	"onebot-go2/pkg/event"
+	"onebot-go2/pkg/ratelimit"
+)
+
+func noop(*event.Context[*types.MessageEvent], *Args) error { return nil }
+
+func testRegistry(t *testing.T) *Registry {
+	t.Helper()
+	r := NewRegistry("/")
+	commands := []*Command{
+		{Name: "echo", Params: []Param{{Name: "text", Type: ParamRest}}, Handler: noop},
+		{Name: "say", Params: []Param{{Name: "a", Type: ParamString}, {Name: "b", Type: ParamString, Optional: true}}, Handler: noop},
+		{Name: "ban", Aliases: []string{"mute"}, Params: []Param{
+			{Name: "user", Type: ParamUser},
+			{Name: "duration", Type: ParamDuration, Default: 10 * time.Minute},
+		}, Handler: noop},
+		{Name: "roll", Params: []Param{{Name: "n", Type: ParamInt64, Default: int64(6)}}, Handler: noop},
+		{Name: "plugin", Subcommands: []*Command{
+			{Name: "on", Params: []Param{{Name: "name", Type: ParamString}}, Handler: noop},
+			{Name: "off", Params: []Param{{Name: "name", Type: ParamString}}, Handler: noop},
+		}},
+	}
+	for _, cmd := range commands {
+		if err := r.Register(cmd); err != nil {
+			t.Fatalf("Register(%s): %v", cmd.Name, err)
+		}
+	}
+	return r
+}
+
+func text(s string) types.Message {
+	return types.Message{Type: "text", Data: map[string]interface{}{"text": s}}
+}
+
+func at(qq interface{}) types.Message {
+	return types.Message{Type: "at", Data: map[string]interface{}{"qq": qq}}
+}
+
+func TestParse(t *testing.T) {
+	r := testRegistry(t)
+
+	tests := []struct {
+		name    string
+		message types.MessageArray
+		path    []string
+		values  map[string]interface{}
+		usage   string // 非空时期望 *UsageError，且 Reason 包含该内容
+	}{
+		{
+			name:    "not a command",
+			message: types.MessageArray{text("hello")},
+		},
+		{
+			name:    "unknown command",
+			message: types.MessageArray{text("/nope")},
+		},
+		{
+			name:    "rest keeps spaces",
+			message: types.MessageArray{text("/echo  hello   world ")},
+			path:    []string{"echo"},
+			values:  map[string]interface{}{"text": "hello   world"},
+		},
+		{
+			name:    "double quotes",
+			message: types.MessageArray{text(`/say "hello world" x`)},
+			path:    []string{"say"},
+			values:  map[string]interface{}{"a": "hello world", "b": "x"},
+		},
+		{
+			name:    "single and full-width quotes",
+			message: types.MessageArray{text("/say 'a b' “你 好”")},
+			path:    []string{"say"},
+			values:  map[string]interface{}{"a": "a b", "b": "你 好"},
+		},
+		{
+			name:    "escaped quote",
+			message: types.MessageArray{text(`/say "a \"b\" \\"`)},
+			path:    []string{"say"},
+			values:  map[string]interface{}{"a": `a "b" \`},
+		},
+		{
+			name:    "unclosed quote",
+			message: types.MessageArray{text(`/say "hello`)},
+			usage:   "引号未闭合",
+		},
+		{
+			name:    "too many args",
+			message: types.MessageArray{text("/say a b c")},
+			usage:   "参数过多",
+		},
+		{
+			name:    "missing required",
+			message: types.MessageArray{text("/say")},
+			usage:   "缺少参数 <a>",
+		},
+		{
+			name:    "leading reply is skipped",
+			message: types.MessageArray{{Type: "reply", Data: map[string]interface{}{"id": "1"}}, text("/roll 20")},
+			path:    []string{"roll"},
+			values:  map[string]interface{}{"n": int64(20)},
+		},
+		{
+			name:    "default value",
+			message: types.MessageArray{text("/roll")},
+			path:    []string{"roll"},
+			values:  map[string]interface{}{"n": int64(6)},
+		},
+		{
+			name:    "invalid int",
+			message: types.MessageArray{text("/roll six")},
+			usage:   `"six" 不是整数`,
+		},
+		{
+			name:    "at with string qq",
+			message: types.MessageArray{text("/ban "), at("10001"), text(" 1h")},
+			path:    []string{"ban"},
+			values:  map[string]interface{}{"user": int64(10001), "duration": time.Hour},
+		},
+		{
+			name:    "at with float64 qq",
+			message: types.MessageArray{text("/mute "), at(float64(3456789012)), text(" 2d")},
+			path:    []string{"ban"},
+			values:  map[string]interface{}{"user": int64(3456789012), "duration": 48 * time.Hour},
+		},
+		{
+			name:    "at with json.Number qq",
+			message: types.MessageArray{text("/ban"), at(json.Number("3456789012"))},
+			path:    []string{"ban"},
+			values:  map[string]interface{}{"user": int64(3456789012), "duration": 10 * time.Minute},
+		},
+		{
+			name:    "user as number",
+			message: types.MessageArray{text("/ban @10001 90")},
+			path:    []string{"ban"},
+			values:  map[string]interface{}{"user": int64(10001), "duration": 90 * time.Second},
+		},
+		{
+			name:    "at all",
+			message: types.MessageArray{text("/ban "), at("all")},
+			usage:   "不能指定全体成员",
+		},
+		{
+			name:    "subcommand",
+			message: types.MessageArray{text("/plugin ON echo")},
+			path:    []string{"plugin", "on"},
+			values:  map[string]interface{}{"name": "echo"},
+		},
+		{
+			name:    "missing subcommand",
+			message: types.MessageArray{text("/plugin")},
+			usage:   "请指定子命令",
+		},
+		{
+			name:    "unknown subcommand",
+			message: types.MessageArray{text("/plugin list")},
+			usage:   "未知的子命令 list",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := event.GroupMessage(100, 200, "")
+			msg.Message = tt.message
+
+			args, err := r.Parse(msg)
+			if tt.usage != "" {
+				var usageErr *UsageError
+				if !errors.As(err, &usageErr) {
+					t.Fatalf("Parse() error = %v, want *UsageError", err)
+				}
+				if !strings.Contains(usageErr.Reason, tt.usage) {
+					t.Errorf("Reason = %q, want it to contain %q", usageErr.Reason, tt.usage)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if tt.path == nil {
+				if args != nil {
+					t.Fatalf("Parse() = %v, want nil", args.Path())
+				}
+				return
+			}
+			if args == nil {
+				t.Fatal("Parse() = nil, want a command")
+			}
+			if !reflect.DeepEqual(args.Path(), tt.path) {
+				t.Errorf("Path() = %v, want %v", args.Path(), tt.path)
+			}
+			if !reflect.DeepEqual(args.values, tt.values) {
+				t.Errorf("values = %v, want %v", args.values, tt.values)
+			}
+		})
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{in: "30", want: 30 * time.Second},
+		{in: "10m", want: 10 * time.Minute},
+		{in: "1h30m", want: 90 * time.Minute},
+		{in: "2d", want: 48 * time.Hour},
+		{in: "abc", wantErr: true},
+		{in: "1x", wantErr: true},
+		{in: "0", wantErr: true},
+		{in: "0s", wantErr: true},
+		{in: "-5", wantErr: true},
+		{in: "-1d", wantErr: true},
+		{in: "-10m", wantErr: true},
+		{in: "106751d", want: 106751 * 24 * time.Hour},
+		{in: "106752d", wantErr: true},
+		{in: "9999999999999d", wantErr: true},
+		{in: "9999999999999", wantErr: true},
+		{in: "9999999999999h", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseDuration(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseDuration(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseDuration(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParentCooldown(t *testing.T) {
+	r := NewRegistry("/")
+	var calls int
+	handler := func(*event.Context[*types.MessageEvent], *Args) error {
+		calls++
+		return nil
+	}
+	err := r.Register(&Command{
+		Name:     "game",
+		Cooldown: ratelimit.NewRule(1, time.Hour, ratelimit.PerUser, ratelimit.Silent()),
+		Subcommands: []*Command{
+			{Name: "start", Handler: handler},
+			{Name: "stop", Handler: handler},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		userID int64
+		text   string
+		calls  int
+	}{
+		{userID: 1, text: "/game start", calls: 1},
+		{userID: 1, text: "/game start", calls: 1}, // 父命令冷却中
+		{userID: 1, text: "/game stop", calls: 1},  // 子命令共享父命令的冷却
+		{userID: 2, text: "/game stop", calls: 2},  // 其他用户不受影响
+	}
+	for i, tt := range tests {
+		ctx, _ := event.NewTestContext(event.GroupMessage(100, tt.userID, tt.text))
+		if err := r.Handle(ctx); err != nil {
+			t.Fatalf("step %d: Handle() error = %v", i, err)
+		}
+		if calls != tt.calls {
+			t.Errorf("step %d (%s by %d): calls = %d, want %d", i, tt.text, tt.userID, calls, tt.calls)
+		}
+	}
+}
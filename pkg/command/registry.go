@@ -0,0 +1,336 @@
+package command
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+
+	types "onebot-go2/pkg/const"
+	"onebot-go2/pkg/event"
+	"onebot-go2/pkg/permission"
+	"onebot-go2/pkg/ratelimit"
+)
+
+// Registry 命令注册表
+// 负责命令匹配、参数解析和用法错误回复，本身实现了 EventHandler[*types.MessageEvent]
+type Registry struct {
+	prefix      string
+	mu          sync.RWMutex
+	commands    []*Command          // 按注册顺序保存，用于生成帮助
+	index       map[string]*Command // 命令名和别名（小写）到命令的映射
+	enabled     map[string]bool     // 启用的命令名，nil 表示全部启用
+	permissions *permission.Manager
+	filter      Filter
+	logger      *slog.Logger
+}
+
+// Filter 命令过滤器，返回 false 时当作命令不存在（不回复）
+// 用于按群、按用户开关命令
+type Filter func(msg *types.MessageEvent, cmd *Command) bool
+
+// NewRegistry 创建命令注册表
+func NewRegistry(prefix string) *Registry {
+	return &Registry{
+		prefix:      prefix,
+		index:       make(map[string]*Command),
+		permissions: permission.NewManager(nil),
+		logger:      slog.Default().With("component", "command"),
+	}
+}
+
+// SetLogger 设置日志记录器，默认使用创建时的 slog.Default()
+func (r *Registry) SetLogger(logger *slog.Logger) *Registry {
+	r.logger = logger.With("component", "command")
+	return r
+}
+
+// Logger 返回注册表的日志记录器
+func (r *Registry) Logger() *slog.Logger {
+	return r.logger
+}
+
+// SetPermissions 设置权限管理器
+func (r *Registry) SetPermissions(m *permission.Manager) *Registry {
+	r.permissions = m
+	return r
+}
+
+// Permissions 返回权限管理器
+func (r *Registry) Permissions() *permission.Manager {
+	return r.permissions
+}
+
+// SetFilter 设置命令过滤器
+func (r *Registry) SetFilter(filter Filter) *Registry {
+	r.mu.Lock()
+	r.filter = filter
+	r.mu.Unlock()
+	return r
+}
+
+// Prefix 返回命令前缀
+func (r *Registry) Prefix() string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.prefix
+}
+
+// SetPrefix 修改命令前缀
+func (r *Registry) SetPrefix(prefix string) {
+	r.mu.Lock()
+	r.prefix = prefix
+	r.mu.Unlock()
+}
+
+// Register 注册命令
+func (r *Registry) Register(cmd *Command) error {
+	if err := cmd.validate(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	names := append([]string{cmd.Name}, cmd.Aliases...)
+	for _, name := range names {
+		if existing, ok := r.index[strings.ToLower(name)]; ok {
+			return fmt.Errorf("command name %s already used by %s", name, existing.Name)
+		}
+	}
+	for _, name := range names {
+		r.index[strings.ToLower(name)] = cmd
+	}
+	r.commands = append(r.commands, cmd)
+
+	r.logger.Debug("Registered command", "command", cmd.Name, "plugin", cmd.Plugin)
+	return nil
+}
+
+// SetEnabled 设置启用的命令（按命令名），为空表示全部启用
+// 未启用的命令不会被匹配，也不会出现在帮助中；返回未注册的命令名
+func (r *Registry) SetEnabled(names []string) (unknown []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(names) == 0 {
+		r.enabled = nil
+		return nil
+	}
+
+	enabled := make(map[string]bool, len(names))
+	for _, name := range names {
+		cmd, ok := r.index[strings.ToLower(name)]
+		if !ok {
+			unknown = append(unknown, name)
+			continue
+		}
+		enabled[cmd.Name] = true
+	}
+	r.enabled = enabled
+	return unknown
+}
+
+// isEnabled 判断命令是否启用，调用方需持有锁
+func (r *Registry) isEnabled(cmd *Command) bool {
+	return r.enabled == nil || r.enabled[cmd.Name]
+}
+
+// Lookup 根据命令名或别名查找已启用的命令
+func (r *Registry) Lookup(name string) (*Command, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	cmd, ok := r.index[strings.ToLower(name)]
+	if !ok || !r.isEnabled(cmd) {
+		return nil, false
+	}
+	return cmd, true
+}
+
+// Commands 返回所有已启用的命令
+func (r *Registry) Commands() []*Command {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := make([]*Command, 0, len(r.commands))
+	for _, cmd := range r.commands {
+		if r.isEnabled(cmd) {
+			result = append(result, cmd)
+		}
+	}
+	return result
+}
+
+// HelpText 根据命令声明生成帮助文本
+func (r *Registry) HelpText() string {
+	var sb strings.Builder
+	sb.WriteString("可用命令：")
+	for _, cmd := range r.Commands() {
+		sb.WriteString("\n")
+		sb.WriteString(cmd.UsageLine(r.Prefix()))
+		if cmd.Description != "" {
+			sb.WriteString(" - ")
+			sb.WriteString(cmd.Description)
+		}
+	}
+	return sb.String()
+}
+
+// Parse 解析消息，返回匹配的命令和参数
+// 消息不是命令或命令不存在时返回 nil, nil；参数错误时返回 *UsageError
+func (r *Registry) Parse(msg *types.MessageEvent) (*Args, error) {
+	segments := commandSegments(msg)
+	if len(segments) == 0 {
+		return nil, nil
+	}
+
+	prefix := r.Prefix()
+	first := segmentText(segments[0])
+	trimmed := strings.TrimLeft(first, " \t")
+	if !strings.HasPrefix(trimmed, prefix) {
+		return nil, nil
+	}
+
+	// 去除前缀后重新组装消息段
+	segments = append(types.MessageArray{{
+		Type: "text",
+		Data: map[string]interface{}{"text": strings.TrimPrefix(trimmed, prefix)},
+	}}, segments[1:]...)
+
+	s := newScanner(segments)
+	tok, ok, err := s.next()
+	if err != nil || !ok || tok.segment != nil {
+		return nil, nil
+	}
+
+	cmd, exists := r.Lookup(tok.text)
+	if !exists || !r.allowed(msg, cmd) {
+		return nil, nil
+	}
+
+	// 逐级匹配子命令
+	var path []string
+	var parents []*Command
+	required := cmd.Permission
+	for len(cmd.Subcommands) > 0 {
+		saved := *s
+		tok, ok, err := s.next()
+		if err != nil {
+			return nil, &UsageError{Command: cmd, Path: path, Reason: err.Error(), required: required}
+		}
+		if ok && tok.segment == nil {
+			if sub, found := cmd.Subcommand(tok.text); found {
+				path = append(path, cmd.Name)
+				parents = append(parents, cmd)
+				cmd = sub
+				required = max(required, sub.Permission)
+				continue
+			}
+		}
+		*s = saved
+		if cmd.Handler == nil {
+			reason := "请指定子命令"
+			if ok {
+				reason = fmt.Sprintf("未知的子命令 %s", tok.text)
+			}
+			return nil, &UsageError{Command: cmd, Path: path, Reason: reason, required: required}
+		}
+		break
+	}
+
+	values, err := bindParams(s, cmd.Params)
+	if err != nil {
+		return nil, &UsageError{Command: cmd, Path: path, Reason: err.Error(), required: required}
+	}
+
+	return &Args{
+		registry: r,
+		command:  cmd,
+		parents:  parents,
+		path:     path,
+		values:   values,
+		required: required,
+	}, nil
+}
+
+// allowed 判断命令是否通过过滤器
+func (r *Registry) allowed(msg *types.MessageEvent, cmd *Command) bool {
+	r.mu.RLock()
+	filter := r.filter
+	r.mu.RUnlock()
+	return filter == nil || filter(msg, cmd)
+}
+
+// commandSegments 获取用于解析命令的消息段
+// 跳过开头的回复消息段；消息段为空时退化为原始消息文本
+func commandSegments(msg *types.MessageEvent) types.MessageArray {
+	segments := msg.Message
+	if len(segments) == 0 && msg.RawMessage != "" {
+		return types.MessageArray{{Type: "text", Data: map[string]interface{}{"text": msg.RawMessage}}}
+	}
+	for len(segments) > 0 && segments[0].Type == "reply" {
+		segments = segments[1:]
+	}
+	if len(segments) == 0 || segments[0].Type != "text" {
+		return nil
+	}
+	return segments
+}
+
+// Handle 处理消息事件
+func (r *Registry) Handle(ctx *event.Context[*types.MessageEvent]) error {
+	args, err := r.Parse(ctx.Event)
+	if err != nil {
+		if usageErr, ok := err.(*UsageError); ok {
+			// 无权限的用户不应看到命令用法
+			if !permission.Allow(r.permissions, ctx, usageErr.required) {
+				return permission.Deny(r.permissions, ctx, usageErr.required)
+			}
+			return r.replyUsage(ctx, usageErr)
+		}
+		return err
+	}
+	if args == nil {
+		return nil
+	}
+
+	logger := ctx.Logger().With("command", strings.Join(args.Path(), " "))
+	if !permission.Allow(r.permissions, ctx, args.required) {
+		logger.Info("Command denied", "required", args.required.String())
+		return permission.Deny(r.permissions, ctx, args.required)
+	}
+
+	// 从最外层命令开始检查冷却，父命令的冷却对所有子命令生效
+	path := args.Path()
+	for i, cmd := range append(append([]*Command{}, args.parents...), args.command) {
+		rule := cmd.Cooldown
+		if rule == nil {
+			continue
+		}
+		if result := rule.Check(ctx.Event, strings.Join(path[:i+1], " ")); !result.Allowed {
+			logger.Info("Command in cooldown", "cooldown", strings.Join(path[:i+1], " "), "retry_after", result.RetryAfter)
+			return ratelimit.Reject(rule, ctx, result)
+		}
+	}
+
+	// 存储命令和参数到上下文，供后续使用
+	ctx.Set("command", args.command.Name)
+	ctx.Set("args", args)
+
+	logger.Info("Executing command", "args", args.values)
+	return args.command.Handler(ctx, args)
+}
+
+// replyUsage 回复用法错误
+func (r *Registry) replyUsage(ctx *event.Context[*types.MessageEvent], err *UsageError) error {
+	_, replyErr := ctx.ReplyText(fmt.Sprintf("%s\n用法：%s", err.Reason, err.Command.Usage(r.Prefix(), err.Path...)))
+	return replyErr
+}
+
+func (r *Registry) Priority() int {
+	return 30 // 较高优先级，在过滤器之后
+}
+
+func (r *Registry) Name() string {
+	return "CommandHandler"
+}
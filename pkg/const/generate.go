@@ -0,0 +1,4 @@
+package types
+
+// Action 常量、WSServer 方法、BotAPI 接口、Context 便捷方法和 TestServer 方法由 actions.yaml 生成
+//go:generate go run ../../cmd/apigen -spec actions.yaml -root ../..
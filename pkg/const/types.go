@@ -72,6 +72,9 @@ type NoticeEvent struct {
 	UserID     int64  `json:"user_id"`
 	GroupID    int64  `json:"group_id,omitempty"`
 	OperatorID int64  `json:"operator_id,omitempty"`
+	MessageID  int32  `json:"message_id,omitempty"` // group_recall / friend_recall 撤回的消息 ID
+	CardNew    string `json:"card_new,omitempty"`   // group_card 新名片
+	CardOld    string `json:"card_old,omitempty"`   // group_card 旧名片
 }
 
 // RequestEvent 请求事件
@@ -117,6 +120,13 @@ type SendMessageResponse struct {
 	MessageID int32 `json:"message_id"`
 }
 
+// SendForwardMsgParams 发送合并转发消息参数（send_group_forward_msg / send_private_forward_msg）
+type SendForwardMsgParams struct {
+	GroupID  int64        `json:"group_id,omitempty"`
+	UserID   int64        `json:"user_id,omitempty"`
+	Messages MessageArray `json:"messages"` // node 消息段
+}
+
 // DeleteMsgParams 撤回消息参数
 type DeleteMsgParams struct {
 	MessageID int32 `json:"message_id"`
@@ -429,6 +439,15 @@ type SetRestartParams struct {
 // CleanCacheParams 清理缓存参数
 type CleanCacheParams struct{}
 
+// SetQQProfileParams 设置登录号资料参数
+type SetQQProfileParams struct {
+	Nickname     string `json:"nickname"`
+	Company      string `json:"company,omitempty"`
+	Email        string `json:"email,omitempty"`
+	College      string `json:"college,omitempty"`
+	PersonalNote string `json:"personal_note,omitempty"`
+}
+
 // APIRequest API请求
 type APIRequest struct {
 	Action string      `json:"action"`
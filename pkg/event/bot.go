@@ -0,0 +1,29 @@
+package event
+
+import (
+	"errors"
+
+	types "onebot-go2/pkg/const"
+)
+
+// BotAPI 接口和 unavailableBot 的方法由 pkg/const/actions.yaml 生成（bot_gen.go）
+
+// Bot 返回当前事件的机器人 API
+// Server 未设置时返回的实例所有调用都返回 "server not available" 错误
+func (c *Context[T]) Bot() BotAPI {
+	if server := c.GetServer(); server != nil {
+		return server
+	}
+	return unavailableBot{}
+}
+
+var errServerUnavailable = errors.New("server not available")
+
+// unavailableBot Server 未设置时 ctx.Bot() 返回的实现
+type unavailableBot struct{}
+
+var _ BotAPI = unavailableBot{}
+
+func (unavailableBot) CallAPI(string, interface{}) (*types.APIResponse, error) {
+	return nil, errServerUnavailable
+}
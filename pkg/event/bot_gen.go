@@ -0,0 +1,229 @@
+// Code generated by apigen from actions.yaml. DO NOT EDIT.
+
+package event
+
+import (
+	types "onebot-go2/pkg/const"
+)
+
+// BotAPI OneBot 的全部 API，处理器通过 ctx.Bot() 调用
+// 与 WSServer 的方法一一对应，internal/server 中的编译期断言保证两者同步
+type BotAPI interface {
+	// 消息相关
+	SendPrivateMsg(userID int64, message types.MessageArray) (*types.SendMessageResponse, error)
+	SendGroupMsg(groupID int64, message types.MessageArray) (*types.SendMessageResponse, error)
+	SendMsg(params *types.SendMessageParams) (*types.SendMessageResponse, error)
+	SendGroupForwardMsg(groupID int64, messages types.MessageArray) (*types.SendMessageResponse, error)
+	SendPrivateForwardMsg(userID int64, messages types.MessageArray) (*types.SendMessageResponse, error)
+	DeleteMsg(messageID int32) error
+	GetMsg(messageID int32) (*types.GetMsgResponse, error)
+	GetForwardMsg(id string) (*types.GetForwardMsgResponse, error)
+	SendLike(userID int64, times int) error
+
+	// 群管理相关
+	SetGroupKick(groupID, userID int64, rejectAddRequest bool) error
+	SetGroupBan(groupID, userID, duration int64) error
+	SetGroupAnonymousBan(groupID int64, flag string, duration int64) error
+	SetGroupWholeBan(groupID int64, enable bool) error
+	SetGroupAdmin(groupID, userID int64, enable bool) error
+	SetGroupAnonymous(groupID int64, enable bool) error
+	SetGroupCard(groupID, userID int64, card string) error
+	SetGroupName(groupID int64, groupName string) error
+	SetGroupLeave(groupID int64, isDismiss bool) error
+	SetGroupSpecialTitle(groupID, userID int64, specialTitle string, duration int64) error
+
+	// 请求处理相关
+	SetFriendAddRequest(flag string, approve bool, remark string) error
+	SetGroupAddRequest(flag, subType string, approve bool, reason string) error
+
+	// 信息获取相关
+	GetLoginInfo() (*types.GetLoginInfoResponse, error)
+	GetStrangerInfo(userID int64, noCache bool) (*types.GetStrangerInfoResponse, error)
+	GetFriendList() (types.GetFriendListResponse, error)
+	GetGroupInfo(groupID int64, noCache bool) (*types.GetGroupInfoResponse, error)
+	GetGroupList() (types.GetGroupListResponse, error)
+	GetGroupMemberInfo(groupID, userID int64, noCache bool) (*types.GetGroupMemberInfoResponse, error)
+	GetGroupMemberList(groupID int64) (types.GetGroupMemberListResponse, error)
+	GetGroupHonorInfo(groupID int64, honorType string) (*types.GetGroupHonorInfoResponse, error)
+	GetCookies(domain string) (*types.GetCookiesResponse, error)
+	GetCsrfToken() (*types.GetCsrfTokenResponse, error)
+	GetCredentials(domain string) (*types.GetCredentialsResponse, error)
+	GetRecord(file, outFormat string) (*types.GetRecordResponse, error)
+	GetImage(file string) (*types.GetImageResponse, error)
+	CanSendImage() (*types.CanSendImageResponse, error)
+	CanSendRecord() (*types.CanSendRecordResponse, error)
+	GetStatus() (*types.GetStatusResponse, error)
+	GetVersionInfo() (*types.GetVersionInfoResponse, error)
+
+	// 其他
+	SetRestart(delay int) error
+	CleanCache() error
+	SetQQProfile(params *types.SetQQProfileParams) error
+
+	// CallAPI 调用任意 action，用于未封装的扩展 API
+	CallAPI(action string, params interface{}) (*types.APIResponse, error)
+}
+
+func (unavailableBot) SendPrivateMsg(int64, types.MessageArray) (*types.SendMessageResponse, error) {
+	return nil, errServerUnavailable
+}
+
+func (unavailableBot) SendGroupMsg(int64, types.MessageArray) (*types.SendMessageResponse, error) {
+	return nil, errServerUnavailable
+}
+
+func (unavailableBot) SendMsg(*types.SendMessageParams) (*types.SendMessageResponse, error) {
+	return nil, errServerUnavailable
+}
+
+func (unavailableBot) SendGroupForwardMsg(int64, types.MessageArray) (*types.SendMessageResponse, error) {
+	return nil, errServerUnavailable
+}
+
+func (unavailableBot) SendPrivateForwardMsg(int64, types.MessageArray) (*types.SendMessageResponse, error) {
+	return nil, errServerUnavailable
+}
+
+func (unavailableBot) DeleteMsg(int32) error {
+	return errServerUnavailable
+}
+
+func (unavailableBot) GetMsg(int32) (*types.GetMsgResponse, error) {
+	return nil, errServerUnavailable
+}
+
+func (unavailableBot) GetForwardMsg(string) (*types.GetForwardMsgResponse, error) {
+	return nil, errServerUnavailable
+}
+
+func (unavailableBot) SendLike(int64, int) error {
+	return errServerUnavailable
+}
+
+func (unavailableBot) SetGroupKick(int64, int64, bool) error {
+	return errServerUnavailable
+}
+
+func (unavailableBot) SetGroupBan(int64, int64, int64) error {
+	return errServerUnavailable
+}
+
+func (unavailableBot) SetGroupAnonymousBan(int64, string, int64) error {
+	return errServerUnavailable
+}
+
+func (unavailableBot) SetGroupWholeBan(int64, bool) error {
+	return errServerUnavailable
+}
+
+func (unavailableBot) SetGroupAdmin(int64, int64, bool) error {
+	return errServerUnavailable
+}
+
+func (unavailableBot) SetGroupAnonymous(int64, bool) error {
+	return errServerUnavailable
+}
+
+func (unavailableBot) SetGroupCard(int64, int64, string) error {
+	return errServerUnavailable
+}
+
+func (unavailableBot) SetGroupName(int64, string) error {
+	return errServerUnavailable
+}
+
+func (unavailableBot) SetGroupLeave(int64, bool) error {
+	return errServerUnavailable
+}
+
+func (unavailableBot) SetGroupSpecialTitle(int64, int64, string, int64) error {
+	return errServerUnavailable
+}
+
+func (unavailableBot) SetFriendAddRequest(string, bool, string) error {
+	return errServerUnavailable
+}
+
+func (unavailableBot) SetGroupAddRequest(string, string, bool, string) error {
+	return errServerUnavailable
+}
+
+func (unavailableBot) GetLoginInfo() (*types.GetLoginInfoResponse, error) {
+	return nil, errServerUnavailable
+}
+
+func (unavailableBot) GetStrangerInfo(int64, bool) (*types.GetStrangerInfoResponse, error) {
+	return nil, errServerUnavailable
+}
+
+func (unavailableBot) GetFriendList() (types.GetFriendListResponse, error) {
+	return nil, errServerUnavailable
+}
+
+func (unavailableBot) GetGroupInfo(int64, bool) (*types.GetGroupInfoResponse, error) {
+	return nil, errServerUnavailable
+}
+
+func (unavailableBot) GetGroupList() (types.GetGroupListResponse, error) {
+	return nil, errServerUnavailable
+}
+
+func (unavailableBot) GetGroupMemberInfo(int64, int64, bool) (*types.GetGroupMemberInfoResponse, error) {
+	return nil, errServerUnavailable
+}
+
+func (unavailableBot) GetGroupMemberList(int64) (types.GetGroupMemberListResponse, error) {
+	return nil, errServerUnavailable
+}
+
+func (unavailableBot) GetGroupHonorInfo(int64, string) (*types.GetGroupHonorInfoResponse, error) {
+	return nil, errServerUnavailable
+}
+
+func (unavailableBot) GetCookies(string) (*types.GetCookiesResponse, error) {
+	return nil, errServerUnavailable
+}
+
+func (unavailableBot) GetCsrfToken() (*types.GetCsrfTokenResponse, error) {
+	return nil, errServerUnavailable
+}
+
+func (unavailableBot) GetCredentials(string) (*types.GetCredentialsResponse, error) {
+	return nil, errServerUnavailable
+}
+
+func (unavailableBot) GetRecord(string, string) (*types.GetRecordResponse, error) {
+	return nil, errServerUnavailable
+}
+
+func (unavailableBot) GetImage(string) (*types.GetImageResponse, error) {
+	return nil, errServerUnavailable
+}
+
+func (unavailableBot) CanSendImage() (*types.CanSendImageResponse, error) {
+	return nil, errServerUnavailable
+}
+
+func (unavailableBot) CanSendRecord() (*types.CanSendRecordResponse, error) {
+	return nil, errServerUnavailable
+}
+
+func (unavailableBot) GetStatus() (*types.GetStatusResponse, error) {
+	return nil, errServerUnavailable
+}
+
+func (unavailableBot) GetVersionInfo() (*types.GetVersionInfoResponse, error) {
+	return nil, errServerUnavailable
+}
+
+func (unavailableBot) SetRestart(int) error {
+	return errServerUnavailable
+}
+
+func (unavailableBot) CleanCache() error {
+	return errServerUnavailable
+}
+
+func (unavailableBot) SetQQProfile(*types.SetQQProfileParams) error {
+	return errServerUnavailable
+}
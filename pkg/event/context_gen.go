@@ -0,0 +1,144 @@
+// Code generated by apigen from actions.yaml. DO NOT EDIT.
+
+package event
+
+import (
+	"fmt"
+
+	types "onebot-go2/pkg/const"
+)
+
+// SendPrivateMsg 发送私聊消息
+func (c *Context[T]) SendPrivateMsg(userID int64, message types.MessageArray) (*types.SendMessageResponse, error) {
+	server := c.GetServer()
+	if server == nil {
+		return nil, fmt.Errorf("server not available")
+	}
+	return server.SendPrivateMsg(userID, message)
+}
+
+// SendGroupMsg 发送群消息
+func (c *Context[T]) SendGroupMsg(groupID int64, message types.MessageArray) (*types.SendMessageResponse, error) {
+	server := c.GetServer()
+	if server == nil {
+		return nil, fmt.Errorf("server not available")
+	}
+	return server.SendGroupMsg(groupID, message)
+}
+
+// SendMsg 发送消息（通用）
+func (c *Context[T]) SendMsg(params *types.SendMessageParams) (*types.SendMessageResponse, error) {
+	server := c.GetServer()
+	if server == nil {
+		return nil, fmt.Errorf("server not available")
+	}
+	return server.SendMsg(params)
+}
+
+// DeleteMsg 撤回消息
+func (c *Context[T]) DeleteMsg(messageID int32) error {
+	server := c.GetServer()
+	if server == nil {
+		return fmt.Errorf("server not available")
+	}
+	return server.DeleteMsg(messageID)
+}
+
+// GetMsg 获取消息
+func (c *Context[T]) GetMsg(messageID int32) (*types.GetMsgResponse, error) {
+	server := c.GetServer()
+	if server == nil {
+		return nil, fmt.Errorf("server not available")
+	}
+	return server.GetMsg(messageID)
+}
+
+// KickGroupMember 踢出群成员
+func (c *Context[T]) KickGroupMember(groupID, userID int64, rejectAddRequest bool) error {
+	server := c.GetServer()
+	if server == nil {
+		return fmt.Errorf("server not available")
+	}
+	return server.SetGroupKick(groupID, userID, rejectAddRequest)
+}
+
+// BanGroupMember 禁言群成员
+func (c *Context[T]) BanGroupMember(groupID, userID, duration int64) error {
+	server := c.GetServer()
+	if server == nil {
+		return fmt.Errorf("server not available")
+	}
+	return server.SetGroupBan(groupID, userID, duration)
+}
+
+// SetGroupCard 设置群名片
+func (c *Context[T]) SetGroupCard(groupID, userID int64, card string) error {
+	server := c.GetServer()
+	if server == nil {
+		return fmt.Errorf("server not available")
+	}
+	return server.SetGroupCard(groupID, userID, card)
+}
+
+// SetGroupName 设置群名
+func (c *Context[T]) SetGroupName(groupID int64, groupName string) error {
+	server := c.GetServer()
+	if server == nil {
+		return fmt.Errorf("server not available")
+	}
+	return server.SetGroupName(groupID, groupName)
+}
+
+// GetLoginInfo 获取登录号信息
+func (c *Context[T]) GetLoginInfo() (*types.GetLoginInfoResponse, error) {
+	server := c.GetServer()
+	if server == nil {
+		return nil, fmt.Errorf("server not available")
+	}
+	return server.GetLoginInfo()
+}
+
+// GetFriendList 获取好友列表
+func (c *Context[T]) GetFriendList() (types.GetFriendListResponse, error) {
+	server := c.GetServer()
+	if server == nil {
+		return nil, fmt.Errorf("server not available")
+	}
+	return server.GetFriendList()
+}
+
+// GetGroupInfo 获取群信息
+func (c *Context[T]) GetGroupInfo(groupID int64) (*types.GetGroupInfoResponse, error) {
+	server := c.GetServer()
+	if server == nil {
+		return nil, fmt.Errorf("server not available")
+	}
+	return server.GetGroupInfo(groupID, false)
+}
+
+// GetGroupList 获取群列表
+func (c *Context[T]) GetGroupList() (types.GetGroupListResponse, error) {
+	server := c.GetServer()
+	if server == nil {
+		return nil, fmt.Errorf("server not available")
+	}
+	return server.GetGroupList()
+}
+
+// GetGroupMemberInfo 获取群成员信息
+func (c *Context[T]) GetGroupMemberInfo(groupID, userID int64) (*types.GetGroupMemberInfoResponse, error) {
+	server := c.GetServer()
+	if server == nil {
+		return nil, fmt.Errorf("server not available")
+	}
+	return server.GetGroupMemberInfo(groupID, userID, false)
+}
+
+// GetGroupMemberList 获取群成员列表
+func (c *Context[T]) GetGroupMemberList(groupID int64) (types.GetGroupMemberListResponse, error) {
+	server := c.GetServer()
+	if server == nil {
+		return nil, fmt.Errorf("server not available")
+	}
+	return server.GetGroupMemberList(groupID)
+}
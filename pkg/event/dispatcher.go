@@ -3,10 +3,13 @@ package event
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"reflect"
 	"sort"
 	"sync"
+
+	"onebot-go2/pkg/storage"
+	"onebot-go2/pkg/trace"
 )
 
 // Dispatcher 事件分发器
@@ -16,12 +19,22 @@ type Dispatcher struct {
 	middlewares  []Middleware
 	async        bool
 	errorHandler ErrorHandler
+	groupFilter  GroupFilter
+	storage      storage.Store
+	namespaces   sync.Map // 命名空间 -> storage.Store
+	logger       *slog.Logger
+	tracer       *trace.Tracer
 }
 
+// DefaultNamespace 未分组处理器使用的存储命名空间
+const DefaultNamespace = "default"
+
 type handlerWrapper struct {
 	handler  interface{}
+	invoke   HandlerFunc[interface{}] // 将通用 Context 转换为具体事件类型后调用处理器
 	priority int
 	name     string
+	group    *Group // 所属分组，为空表示直接注册到 Dispatcher
 }
 
 // ErrorHandler 错误处理函数
@@ -29,13 +42,56 @@ type ErrorHandler func(err error, eventType reflect.Type, handlerName string)
 
 // NewDispatcher 创建新的事件分发器
 func NewDispatcher() *Dispatcher {
-	return &Dispatcher{
+	d := &Dispatcher{
 		handlers: make(map[reflect.Type][]handlerWrapper),
 		async:    false,
-		errorHandler: func(err error, eventType reflect.Type, handlerName string) {
-			log.Printf("[EventDispatcher] Error in handler %s for event %s: %v", handlerName, eventType, err)
-		},
+		storage:  storage.NewMemory(),
+		logger:   slog.Default().With("component", "dispatcher"),
 	}
+	d.errorHandler = func(err error, eventType reflect.Type, handlerName string) {
+		d.logger.Error("Handler failed", "handler", handlerName, "event_type", eventType.String(), "error", err)
+	}
+	return d
+}
+
+// SetLogger 设置日志记录器，默认使用创建时的 slog.Default()
+// 处理器中 ctx.Logger() 在此基础上附加事件字段
+func (d *Dispatcher) SetLogger(logger *slog.Logger) *Dispatcher {
+	d.logger = logger.With("component", "dispatcher")
+	return d
+}
+
+// Logger 返回分发器的日志记录器
+func (d *Dispatcher) Logger() *slog.Logger {
+	return d.logger
+}
+
+// SetTracer 设置追踪器，用于导出事件和处理器的 span
+// 未设置时仍会为每个事件分配 trace ID，只是不导出
+func (d *Dispatcher) SetTracer(tracer *trace.Tracer) *Dispatcher {
+	d.tracer = tracer
+	return d
+}
+
+// SetStorage 设置处理器使用的存储，默认为内存存储（不持久化）
+func (d *Dispatcher) SetStorage(store storage.Store) *Dispatcher {
+	d.mu.Lock()
+	d.storage = store
+	d.namespaces.Clear()
+	d.mu.Unlock()
+	return d
+}
+
+// Storage 返回指定命名空间的存储
+func (d *Dispatcher) Storage(namespace string) storage.Store {
+	if store, ok := d.namespaces.Load(namespace); ok {
+		return store.(storage.Store)
+	}
+	d.mu.RLock()
+	store := storage.Namespace(d.storage, namespace)
+	d.mu.RUnlock()
+	actual, _ := d.namespaces.LoadOrStore(namespace, store)
+	return actual.(storage.Store)
 }
 
 // SetAsync 设置是否异步处理事件
@@ -57,37 +113,68 @@ func (d *Dispatcher) Use(middleware Middleware) *Dispatcher {
 }
 
 // register 内部注册方法（非泛型）
-func (d *Dispatcher) register(eventType reflect.Type, handler interface{}, priority int, name string) error {
-	d.mu.Lock()
-	defer d.mu.Unlock()
-
-	d.handlers[eventType] = append(d.handlers[eventType], handlerWrapper{
+func (d *Dispatcher) register(eventType reflect.Type, handler interface{}, invoke HandlerFunc[interface{}], priority int, name string) error {
+	return d.add(eventType, handlerWrapper{
 		handler:  handler,
+		invoke:   invoke,
 		priority: priority,
 		name:     name,
 	})
+}
+
+func (d *Dispatcher) add(eventType reflect.Type, wrapper handlerWrapper) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.handlers[eventType] = append(d.handlers[eventType], wrapper)
 
-	// 按优先级排序
-	sort.Slice(d.handlers[eventType], func(i, j int) bool {
+	// 按优先级排序，同优先级保持注册顺序
+	sort.SliceStable(d.handlers[eventType], func(i, j int) bool {
 		return d.handlers[eventType][i].priority < d.handlers[eventType][j].priority
 	})
 
-	log.Printf("[EventDispatcher] Registered handler %s for event type %s with priority %d",
-		name, eventType, priority)
+	attrs := []any{"handler", wrapper.name, "event_type", eventType.String(), "priority", wrapper.priority}
+	if wrapper.group != nil {
+		attrs = append(attrs, "group", wrapper.group.name)
+	}
+	d.logger.Debug("Registered handler", attrs...)
 
 	return nil
 }
 
-// Register 注册事件处理器（泛型函数）
-func Register[T any](d *Dispatcher, handler EventHandler[T]) error {
+// Register 注册事件处理器（泛型函数），r 可以是 Dispatcher 或 Group
+func Register[T any](r Registrar, handler EventHandler[T]) error {
 	var event T
 	eventType := reflect.TypeOf(event)
-	return d.register(eventType, handler, handler.Priority(), handler.Name())
+	return r.register(eventType, handler, typedInvoker(handler), handler.Priority(), handler.Name())
+}
+
+// typedInvoker 生成调用具体类型处理器的函数
+// 分发时 Context 以 interface{} 形式经过中间件，这里转换为 Context[T]，中止标记和 Metadata 与原 Context 共享
+func typedInvoker[T any](handler EventHandler[T]) HandlerFunc[interface{}] {
+	return func(c *Context[interface{}]) error {
+		evt, ok := c.Event.(T)
+		if !ok {
+			return fmt.Errorf("handler %s cannot handle event of type %T", handler.Name(), c.Event)
+		}
+		typed := &Context[T]{
+			Context:  c.Context,
+			Event:    evt,
+			Metadata: c.Metadata,
+			shared:   c.shared,
+			server:   c.server,
+			handler:  c.handler,
+			group:    c.group,
+			storage:  c.storage,
+			logger:   c.logger,
+		}
+		return handler.Handle(typed)
+	}
 }
 
 // RegisterFunc 注册函数类型处理器（泛型函数）
-func RegisterFunc[T any](d *Dispatcher, name string, priority int, handler HandlerFunc[T]) error {
-	return Register(d, NewSimpleHandler(name, priority, handler))
+func RegisterFunc[T any](r Registrar, name string, priority int, handler HandlerFunc[T]) error {
+	return Register(r, NewSimpleHandler(name, priority, handler))
 }
 
 // Dispatch 分发事件
@@ -99,11 +186,11 @@ func (d *Dispatcher) Dispatch(ctx context.Context, event interface{}, server int
 	d.mu.RUnlock()
 
 	if !exists || len(wrappers) == 0 {
-		log.Printf("[EventDispatcher] No handlers registered for event type %s", eventType)
+		d.logger.Debug("No handlers registered", "event_type", eventType.String())
 		return nil
 	}
 
-	log.Printf("[EventDispatcher] Dispatching event type %s to %d handler(s)", eventType, len(wrappers))
+	d.logger.Debug("Dispatching event", append(EventAttrs(event), "event_type", eventType.String(), "handlers", len(wrappers))...)
 
 	if d.async {
 		go d.dispatchToHandlers(ctx, event, eventType, wrappers, server)
@@ -114,50 +201,73 @@ func (d *Dispatcher) Dispatch(ctx context.Context, event interface{}, server int
 }
 
 func (d *Dispatcher) dispatchToHandlers(ctx context.Context, event interface{}, eventType reflect.Type, wrappers []handlerWrapper, server interface{}) error {
+	// 每个事件一个 trace，处理器和它们发起的 API 调用是其中的子 span
+	ctx, span := d.tracer.Start(ctx, "event", append(EventAttrs(event), "event_type", eventType.String())...)
+	defer span.End()
+
+	// 每个处理器得到 eventCtx 的浅拷贝，Metadata 和中止标记在同一事件的处理器间共享
+	logger := d.logger.With(EventAttrs(event)...).With("trace_id", span.TraceID())
+	eventCtx := &Context[interface{}]{
+		Context:  ctx,
+		Event:    event,
+		Metadata: make(map[string]interface{}),
+		shared:   new(sharedState),
+		server:   server,
+	}
+
 	for _, wrapper := range wrappers {
-		if err := d.invokeHandler(ctx, event, wrapper, server); err != nil {
+		if eventCtx.IsAborted() {
+			break
+		}
+		if wrapper.group != nil && !d.accepts(wrapper.group, event) {
+			continue
+		}
+		if err := d.invokeHandler(ctx, eventCtx, wrapper, logger); err != nil {
 			if d.errorHandler != nil {
 				d.errorHandler(err, eventType, wrapper.name)
 			}
 		}
 	}
+	if eventCtx.IsAborted() {
+		span.SetAttrs("aborted", true)
+	}
 	return nil
 }
 
-func (d *Dispatcher) invokeHandler(ctx context.Context, event interface{}, wrapper handlerWrapper, server interface{}) (err error) {
+func (d *Dispatcher) invokeHandler(ctx context.Context, base *Context[interface{}], wrapper handlerWrapper, logger *slog.Logger) (err error) {
+	spanCtx, span := d.tracer.Start(ctx, "handler", "handler", wrapper.name)
+	defer func() {
+		span.SetError(err)
+		span.End()
+	}()
 	defer func() {
 		if r := recover(); r != nil {
 			err = fmt.Errorf("panic in handler %s: %v", wrapper.name, r)
 		}
 	}()
 
-	// 使用反射调用处理器
-	handlerValue := reflect.ValueOf(wrapper.handler)
-	handleMethod := handlerValue.MethodByName("Handle")
-
-	if !handleMethod.IsValid() {
-		return fmt.Errorf("handler %s does not have Handle method", wrapper.name)
-	}
-
-	// 创建事件上下文，传入 server
-	eventCtx := &Context[interface{}]{
-		Context:  ctx,
-		Event:    event,
-		Metadata: make(map[string]interface{}),
-		aborted:  false,
-		server:   server, // 添加 server 引用
+	// 复制 Context 后设置当前处理器的分组、存储和日志字段
+	// 超时后仍在运行的处理器继续使用自己的副本，不受后续处理器影响
+	handlerCtx := *base
+	eventCtx := &handlerCtx
+	eventCtx.Context = spanCtx
+	eventCtx.handler = wrapper.name
+	eventCtx.logger = logger.With("handler", wrapper.name, "span_id", span.SpanID())
+	eventCtx.group = wrapper.group
+	if wrapper.group != nil {
+		eventCtx.logger = eventCtx.logger.With("group", wrapper.group.name)
+		span.SetAttrs("group", wrapper.group.name)
+		eventCtx.storage = d.Storage(wrapper.group.name)
+	} else {
+		eventCtx.storage = d.Storage(DefaultNamespace)
 	}
 
-	// 应用中间件
-	finalHandler := func(c *Context[interface{}]) error {
-		results := handleMethod.Call([]reflect.Value{reflect.ValueOf(eventCtx)})
-		if len(results) > 0 && !results[0].IsNil() {
-			return results[0].Interface().(error)
-		}
-		return nil
+	// 应用中间件：全局中间件在外层，分组中间件在内层
+	handler := wrapper.invoke
+	if wrapper.group != nil {
+		handler = wrapper.group.wrap(handler)
 	}
-
-	handler := applyMiddlewares(finalHandler, d.middlewares)
+	handler = applyMiddlewares(handler, d.middlewares)
 	return handler(eventCtx)
 }
 
@@ -0,0 +1,134 @@
+package event
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	types "onebot-go2/pkg/const"
+)
+
+func TestDispatchAbortAndSkip(t *testing.T) {
+	tests := []struct {
+		name       string
+		middleware Middleware
+		first      HandlerFunc[*types.MessageEvent]
+		want       []string
+	}{
+		{
+			name:  "all handlers run",
+			first: func(ctx *Context[*types.MessageEvent]) error { return nil },
+			want:  []string{"first", "second", "third"},
+		},
+		{
+			name: "abort stops later handlers",
+			first: func(ctx *Context[*types.MessageEvent]) error {
+				ctx.Abort()
+				return nil
+			},
+			want: []string{"first"},
+		},
+		{
+			name: "filter skips only the filtered handler",
+			middleware: FilterMiddleware(func(ctx *Context[interface{}]) bool {
+				return ctx.HandlerName() != "second"
+			}),
+			first: func(ctx *Context[*types.MessageEvent]) error { return nil },
+			want:  []string{"first", "third"},
+		},
+		{
+			name:       "timeout skips only the slow handler",
+			middleware: TimeoutMiddleware(20 * time.Millisecond),
+			first: func(ctx *Context[*types.MessageEvent]) error {
+				time.Sleep(100 * time.Millisecond)
+				return nil
+			},
+			want: []string{"second", "third", "first"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewDispatcher()
+			if tt.middleware != nil {
+				d.Use(tt.middleware)
+			}
+
+			var mu sync.Mutex
+			var ran []string
+			record := func(name string) {
+				mu.Lock()
+				ran = append(ran, name)
+				mu.Unlock()
+			}
+			RegisterFunc(d, "first", 1, func(ctx *Context[*types.MessageEvent]) error {
+				err := tt.first(ctx)
+				record("first")
+				return err
+			})
+			for i, name := range []string{"second", "third"} {
+				RegisterFunc(d, name, i+2, func(ctx *Context[*types.MessageEvent]) error {
+					record(name)
+					return nil
+				})
+			}
+
+			if err := d.Dispatch(context.Background(), GroupMessage(100, 10001, "hi"), nil); err != nil {
+				t.Fatal(err)
+			}
+			// 超时的处理器在后台继续运行，等待它结束
+			deadline := time.Now().Add(2 * time.Second)
+			for {
+				mu.Lock()
+				n := len(ran)
+				mu.Unlock()
+				if n >= len(tt.want) || time.Now().After(deadline) {
+					break
+				}
+				time.Sleep(5 * time.Millisecond)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if !reflect.DeepEqual(ran, tt.want) {
+				t.Errorf("handlers ran = %v, want %v", ran, tt.want)
+			}
+		})
+	}
+}
+
+func TestDispatchMetadataAfterTimeout(t *testing.T) {
+	d := NewDispatcher().Use(TimeoutMiddleware(time.Millisecond))
+
+	slowDone := make(chan struct{})
+	RegisterFunc(d, "slow", 1, func(ctx *Context[*types.MessageEvent]) error {
+		defer close(slowDone)
+		// 超时后继续写入共享的 Metadata，与后续处理器同时访问
+		for i := 0; i < 1000; i++ {
+			ctx.Set("slow", i)
+			time.Sleep(time.Microsecond)
+		}
+		return nil
+	})
+	seen := make(chan bool, 1)
+	RegisterFunc(d, "next", 2, func(ctx *Context[*types.MessageEvent]) error {
+		found := false
+		for i := 0; i < 1000; i++ {
+			ctx.Set("next", i)
+			_, ok := ctx.Get("slow")
+			found = found || ok
+		}
+		seen <- found
+		return nil
+	})
+
+	if err := d.Dispatch(context.Background(), GroupMessage(100, 10001, "hi"), nil); err != nil {
+		t.Fatal(err)
+	}
+	<-slowDone
+	if !<-seen {
+		t.Error("later handler did not see metadata set by the timed out handler")
+	}
+}
@@ -0,0 +1,103 @@
+package event
+
+import (
+	"sync/atomic"
+	"time"
+
+	types "onebot-go2/pkg/const"
+)
+
+// 测试用事件构造函数，self_id 为 0（onebottest 注入时填充为机器人 QQ 号）
+
+// fixtureMessageID 构造的消息事件使用的消息 ID
+var fixtureMessageID atomic.Int32
+
+// GroupMessage 构造群消息事件，text 为纯文本消息
+func GroupMessage(groupID, userID int64, text string) *types.MessageEvent {
+	return &types.MessageEvent{
+		Event:       types.Event{Time: time.Now().Unix(), PostType: types.PostTypeMessage},
+		MessageType: types.MessageTypeGroup,
+		SubType:     "normal",
+		MessageID:   fixtureMessageID.Add(1),
+		UserID:      userID,
+		GroupID:     groupID,
+		Message:     textMessage(text),
+		RawMessage:  text,
+		Sender:      types.Sender{UserID: userID, Nickname: "user", Role: "member"},
+	}
+}
+
+// PrivateMessage 构造私聊消息事件，text 为纯文本消息
+func PrivateMessage(userID int64, text string) *types.MessageEvent {
+	return &types.MessageEvent{
+		Event:       types.Event{Time: time.Now().Unix(), PostType: types.PostTypeMessage},
+		MessageType: types.MessageTypePrivate,
+		SubType:     "friend",
+		MessageID:   fixtureMessageID.Add(1),
+		UserID:      userID,
+		Message:     textMessage(text),
+		RawMessage:  text,
+		Sender:      types.Sender{UserID: userID, Nickname: "user"},
+	}
+}
+
+// GroupIncrease 构造群成员增加通知（sub_type 为 approve）
+func GroupIncrease(groupID, userID, operatorID int64) *types.NoticeEvent {
+	return groupNotice("group_increase", "approve", groupID, userID, operatorID)
+}
+
+// GroupDecrease 构造群成员减少通知，subType 为 leave、kick 或 kick_me
+func GroupDecrease(groupID, userID, operatorID int64, subType string) *types.NoticeEvent {
+	return groupNotice("group_decrease", subType, groupID, userID, operatorID)
+}
+
+// GroupBan 构造群禁言通知，subType 为 ban 或 lift_ban
+func GroupBan(groupID, userID, operatorID int64, subType string) *types.NoticeEvent {
+	return groupNotice("group_ban", subType, groupID, userID, operatorID)
+}
+
+// GroupRecall 构造群消息撤回通知
+func GroupRecall(groupID, userID, operatorID int64, messageID int32) *types.NoticeEvent {
+	notice := groupNotice("group_recall", "", groupID, userID, operatorID)
+	notice.MessageID = messageID
+	return notice
+}
+
+// FriendRequest 构造加好友请求
+func FriendRequest(userID int64, comment, flag string) *types.RequestEvent {
+	return &types.RequestEvent{
+		Event:       types.Event{Time: time.Now().Unix(), PostType: types.PostTypeRequest},
+		RequestType: "friend",
+		UserID:      userID,
+		Comment:     comment,
+		Flag:        flag,
+	}
+}
+
+// GroupRequest 构造加群请求，subType 为 add 或 invite
+func GroupRequest(groupID, userID int64, subType, comment, flag string) *types.RequestEvent {
+	return &types.RequestEvent{
+		Event:       types.Event{Time: time.Now().Unix(), PostType: types.PostTypeRequest},
+		RequestType: "group",
+		SubType:     subType,
+		UserID:      userID,
+		GroupID:     groupID,
+		Comment:     comment,
+		Flag:        flag,
+	}
+}
+
+func groupNotice(noticeType, subType string, groupID, userID, operatorID int64) *types.NoticeEvent {
+	return &types.NoticeEvent{
+		Event:      types.Event{Time: time.Now().Unix(), PostType: types.PostTypeNotice},
+		NoticeType: noticeType,
+		SubType:    subType,
+		UserID:     userID,
+		GroupID:    groupID,
+		OperatorID: operatorID,
+	}
+}
+
+func textMessage(text string) types.MessageArray {
+	return types.MessageArray{{Type: "text", Data: map[string]interface{}{"text": text}}}
+}
@@ -0,0 +1,124 @@
+package event
+
+import (
+	"reflect"
+	"sync"
+	"sync/atomic"
+
+	types "onebot-go2/pkg/const"
+	"onebot-go2/pkg/storage"
+)
+
+// Registrar 可注册处理器的目标，Dispatcher 和 Group 都实现了该接口
+type Registrar interface {
+	register(eventType reflect.Type, handler interface{}, invoke HandlerFunc[interface{}], priority int, name string) error
+}
+
+// GroupFilter 分组过滤器，返回 false 时本次事件跳过该分组的处理器
+// 用于实现按群、按用户开关插件
+type GroupFilter func(group *Group, event interface{}) bool
+
+// Group 处理器分组（类似 Gin 的 RouterGroup）
+// 同一分组的处理器可以一起启用/禁用，并共享分组中间件；插件系统为每个插件创建一个分组
+type Group struct {
+	name       string
+	dispatcher *Dispatcher
+	enabled    atomic.Bool
+
+	mu          sync.RWMutex
+	middlewares []Middleware
+}
+
+// Group 创建处理器分组，新分组默认启用
+func (d *Dispatcher) Group(name string) *Group {
+	g := &Group{name: name, dispatcher: d}
+	g.enabled.Store(true)
+	return g
+}
+
+// Name 返回分组名称
+func (g *Group) Name() string {
+	return g.name
+}
+
+// Dispatcher 返回分组所属的分发器
+func (g *Group) Dispatcher() *Dispatcher {
+	return g.dispatcher
+}
+
+// SetEnabled 启用/禁用分组，禁用后分组内的处理器不再被调用
+func (g *Group) SetEnabled(enabled bool) *Group {
+	g.enabled.Store(enabled)
+	return g
+}
+
+// Enabled 判断分组是否启用
+func (g *Group) Enabled() bool {
+	return g.enabled.Load()
+}
+
+// Storage 返回以分组名为命名空间的存储，与分组内处理器的 ctx.Storage() 相同
+func (g *Group) Storage() storage.Store {
+	return g.dispatcher.Storage(g.name)
+}
+
+// Use 添加分组中间件，只作用于分组内的处理器，在全局中间件之后执行
+func (g *Group) Use(middleware Middleware) *Group {
+	g.mu.Lock()
+	g.middlewares = append(g.middlewares, middleware)
+	g.mu.Unlock()
+	return g
+}
+
+// SetGroupFilter 设置分组过滤器，只作用于分组内的处理器
+func (d *Dispatcher) SetGroupFilter(filter GroupFilter) *Dispatcher {
+	d.mu.Lock()
+	d.groupFilter = filter
+	d.mu.Unlock()
+	return d
+}
+
+// accepts 判断分组是否处理该事件
+func (d *Dispatcher) accepts(group *Group, event interface{}) bool {
+	if !group.Enabled() {
+		return false
+	}
+	d.mu.RLock()
+	filter := d.groupFilter
+	d.mu.RUnlock()
+	return filter == nil || filter(group, event)
+}
+
+// SubjectOf 从事件中获取群号和用户 QQ 号，没有时为 0；私聊消息的群号为 0
+func SubjectOf(event interface{}) (groupID, userID int64) {
+	switch e := event.(type) {
+	case *types.MessageEvent:
+		if e.MessageType == types.MessageTypeGroup {
+			return e.GroupID, e.UserID
+		}
+		return 0, e.UserID
+	case *types.NoticeEvent:
+		return e.GroupID, e.UserID
+	case *types.RequestEvent:
+		return e.GroupID, e.UserID
+	}
+	return 0, 0
+}
+
+func (g *Group) register(eventType reflect.Type, handler interface{}, invoke HandlerFunc[interface{}], priority int, name string) error {
+	return g.dispatcher.add(eventType, handlerWrapper{
+		handler:  handler,
+		invoke:   invoke,
+		priority: priority,
+		name:     name,
+		group:    g,
+	})
+}
+
+// wrap 为处理器应用分组中间件
+func (g *Group) wrap(invoke HandlerFunc[interface{}]) HandlerFunc[interface{}] {
+	g.mu.RLock()
+	middlewares := g.middlewares
+	g.mu.RUnlock()
+	return applyMiddlewares(invoke, middlewares)
+}
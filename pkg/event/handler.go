@@ -3,31 +3,32 @@ package event
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	types "onebot-go2/pkg/const"
+	"onebot-go2/pkg/history"
+	"onebot-go2/pkg/storage"
+	"sync"
+	"sync/atomic"
 )
 
 // ServerInterface 定义 Server 接口，用于避免循环依赖
+// 包含 BotAPI 的全部 API 和连接状态
 type ServerInterface interface {
-	SendPrivateMsg(userID int64, message types.MessageArray) (*types.SendMessageResponse, error)
-	SendGroupMsg(groupID int64, message types.MessageArray) (*types.SendMessageResponse, error)
-	SendMsg(params *types.SendMessageParams) (*types.SendMessageResponse, error)
-	DeleteMsg(messageID int32) error
-	GetMsg(messageID int32) (*types.GetMsgResponse, error)
-	SetGroupKick(groupID, userID int64, rejectAddRequest bool) error
-	SetGroupBan(groupID, userID int64, duration int64) error
-	SetGroupWholeBan(groupID int64, enable bool) error
-	SetGroupCard(groupID, userID int64, card string) error
-	SetGroupName(groupID int64, groupName string) error
-	GetGroupInfo(groupID int64, noCache bool) (*types.GetGroupInfoResponse, error)
-	GetGroupMemberInfo(groupID, userID int64, noCache bool) (*types.GetGroupMemberInfoResponse, error)
-	GetGroupMemberList(groupID int64) (types.GetGroupMemberListResponse, error)
-	GetLoginInfo() (*types.GetLoginInfoResponse, error)
-	GetFriendList() (types.GetFriendListResponse, error)
-	GetGroupList() (types.GetGroupListResponse, error)
+	BotAPI
 	IsConnected() bool
 }
 
+// HistoryProvider 提供消息历史的 Server，ctx.History() 通过该接口获取
+type HistoryProvider interface {
+	History() *history.Store
+}
+
+// ContextBinder 可以绑定 context 的 Server
+// GetServer 返回绑定了处理器 Context 的实例，API 调用会携带其中的 trace 信息
+type ContextBinder interface {
+	WithContext(ctx context.Context) ServerInterface
+}
+
 // EventHandler 事件处理器接口
 type EventHandler[T any] interface {
 	// Handle 处理事件
@@ -44,11 +45,26 @@ type Context[T any] struct {
 	// Event 原始事件数据
 	Event T
 	// Metadata 元数据，可以在处理器间传递数据
+	// 同一事件的各处理器共享该 map，超时后仍在运行的处理器也可能写入，请通过 Set/Get 访问
 	Metadata map[string]interface{}
-	// aborted 标记是否中止后续处理器
-	aborted bool
+	// shared 同一事件的各处理器共享的中止标记和 Metadata 锁
+	shared *sharedState
 	// server OneBot 服务器实例（用于调用 API）
 	server interface{}
+	// handler 当前处理器名称
+	handler string
+	// group 当前处理器所属分组（插件），用于确定存储命名空间
+	group *Group
+	// storage 当前处理器可用的存储
+	storage storage.Store
+	// logger 带有事件字段的日志记录器
+	logger *slog.Logger
+}
+
+// sharedState 同一事件的各处理器 Context 共享的状态
+type sharedState struct {
+	aborted atomic.Bool
+	mu      sync.RWMutex // 保护 Metadata
 }
 
 // NewContext 创建新的事件上下文
@@ -57,19 +73,39 @@ func NewContext[T any](ctx context.Context, event T) *Context[T] {
 		Context:  ctx,
 		Event:    event,
 		Metadata: make(map[string]interface{}),
-		aborted:  false,
+		shared:   new(sharedState),
 	}
 }
 
+// WithServer 设置用于调用 API 的 Server，用于在事件分发之外创建的 Context（如定时任务）
+func (c *Context[T]) WithServer(server ServerInterface) *Context[T] {
+	c.server = server
+	return c
+}
+
+// WithStorage 设置 ctx.Storage() 返回的存储，用于在事件分发之外创建的 Context
+func (c *Context[T]) WithStorage(store storage.Store) *Context[T] {
+	c.storage = store
+	return c
+}
+
 // ============ 元数据管理方法 ============
 
 // Set 设置元数据
 func (c *Context[T]) Set(key string, value interface{}) {
+	if c.shared != nil {
+		c.shared.mu.Lock()
+		defer c.shared.mu.Unlock()
+	}
 	c.Metadata[key] = value
 }
 
 // Get 获取元数据
 func (c *Context[T]) Get(key string) (interface{}, bool) {
+	if c.shared != nil {
+		c.shared.mu.RLock()
+		defer c.shared.mu.RUnlock()
+	}
 	val, ok := c.Metadata[key]
 	return val, ok
 }
@@ -83,14 +119,49 @@ func (c *Context[T]) MustGet(key string) interface{} {
 	return val
 }
 
-// Abort 中止后续处理器执行
+// Abort 中止整个事件，后续处理器都不再执行
+// 只想跳过当前处理器（如中间件过滤）时直接返回、不调用 next 即可
 func (c *Context[T]) Abort() {
-	c.aborted = true
+	if c.shared == nil {
+		c.shared = new(sharedState)
+	}
+	c.shared.aborted.Store(true)
 }
 
-// IsAborted 判断是否已中止
+// IsAborted 判断事件是否已中止
 func (c *Context[T]) IsAborted() bool {
-	return c.aborted
+	return c.shared != nil && c.shared.aborted.Load()
+}
+
+// HandlerName 返回当前处理器的名称，可在中间件中使用
+func (c *Context[T]) HandlerName() string {
+	return c.handler
+}
+
+// ============ 存储 ============
+
+// Storage 返回当前处理器的持久化存储
+// 插件的处理器使用以插件名为命名空间的存储，直接注册到 Dispatcher 的处理器使用 DefaultNamespace
+func (c *Context[T]) Storage() storage.Store {
+	if c.storage == nil {
+		c.Logger().Warn("Storage is not set, using a temporary in-memory store")
+		c.storage = storage.NewMemory()
+	}
+	return c.storage
+}
+
+// ============ 消息历史 ============
+
+// History 返回消息历史，包含收到的消息和发送成功的消息
+// Server 未提供消息历史时返回一个空的临时历史
+func (c *Context[T]) History() *history.Store {
+	if provider, ok := c.server.(HistoryProvider); ok {
+		if store := provider.History(); store != nil {
+			return store
+		}
+	}
+	c.Logger().Warn("History is not available, using an empty temporary history")
+	return history.New(1, 0)
 }
 
 // ============ Server 访问方法 ============
@@ -98,17 +169,21 @@ func (c *Context[T]) IsAborted() bool {
 // GetServer 获取 Server 实例
 func (c *Context[T]) GetServer() ServerInterface {
 	if c.server == nil {
-		log.Printf("[Context] Warning: Server is not set")
+		c.Logger().Warn("Server is not set")
 		return nil
 	}
+	if binder, ok := c.server.(ContextBinder); ok && c.Context != nil {
+		return binder.WithContext(c.Context)
+	}
 	if server, ok := c.server.(ServerInterface); ok {
 		return server
 	}
-	log.Printf("[Context] Warning: Server does not implement ServerInterface")
+	c.Logger().Warn("Server does not implement ServerInterface")
 	return nil
 }
 
 // ============ 便捷消息发送方法（类似 Gin）============
+// 直接对应 API 的便捷方法由 pkg/const/actions.yaml 生成（context_gen.go）
 
 // Reply 回复消息（根据事件类型自动判断是私聊还是群聊）
 func (c *Context[T]) Reply(message types.MessageArray) (*types.SendMessageResponse, error) {
@@ -168,71 +243,8 @@ func (c *Context[T]) ReplyWithQuote(message types.MessageArray) (*types.SendMess
 	return nil, fmt.Errorf("cannot determine message type from event")
 }
 
-// SendPrivateMsg 发送私聊消息
-func (c *Context[T]) SendPrivateMsg(userID int64, message types.MessageArray) (*types.SendMessageResponse, error) {
-	server := c.GetServer()
-	if server == nil {
-		return nil, fmt.Errorf("server not available")
-	}
-	return server.SendPrivateMsg(userID, message)
-}
-
-// SendGroupMsg 发送群消息
-func (c *Context[T]) SendGroupMsg(groupID int64, message types.MessageArray) (*types.SendMessageResponse, error) {
-	server := c.GetServer()
-	if server == nil {
-		return nil, fmt.Errorf("server not available")
-	}
-	return server.SendGroupMsg(groupID, message)
-}
-
-// SendMsg 发送消息（通用）
-func (c *Context[T]) SendMsg(params *types.SendMessageParams) (*types.SendMessageResponse, error) {
-	server := c.GetServer()
-	if server == nil {
-		return nil, fmt.Errorf("server not available")
-	}
-	return server.SendMsg(params)
-}
-
-// DeleteMsg 撤回消息
-func (c *Context[T]) DeleteMsg(messageID int32) error {
-	server := c.GetServer()
-	if server == nil {
-		return fmt.Errorf("server not available")
-	}
-	return server.DeleteMsg(messageID)
-}
-
-// GetMsg 获取消息
-func (c *Context[T]) GetMsg(messageID int32) (*types.GetMsgResponse, error) {
-	server := c.GetServer()
-	if server == nil {
-		return nil, fmt.Errorf("server not available")
-	}
-	return server.GetMsg(messageID)
-}
-
 // ============ 群管理便捷方法 ============
 
-// KickGroupMember 踢出群成员
-func (c *Context[T]) KickGroupMember(groupID, userID int64, rejectAddRequest bool) error {
-	server := c.GetServer()
-	if server == nil {
-		return fmt.Errorf("server not available")
-	}
-	return server.SetGroupKick(groupID, userID, rejectAddRequest)
-}
-
-// BanGroupMember 禁言群成员
-func (c *Context[T]) BanGroupMember(groupID, userID int64, duration int64) error {
-	server := c.GetServer()
-	if server == nil {
-		return fmt.Errorf("server not available")
-	}
-	return server.SetGroupBan(groupID, userID, duration)
-}
-
 // UnbanGroupMember 解除禁言
 func (c *Context[T]) UnbanGroupMember(groupID, userID int64) error {
 	return c.BanGroupMember(groupID, userID, 0)
@@ -256,80 +268,6 @@ func (c *Context[T]) UnbanAllGroupMembers(groupID int64) error {
 	return server.SetGroupWholeBan(groupID, false)
 }
 
-// SetGroupCard 设置群名片
-func (c *Context[T]) SetGroupCard(groupID, userID int64, card string) error {
-	server := c.GetServer()
-	if server == nil {
-		return fmt.Errorf("server not available")
-	}
-	return server.SetGroupCard(groupID, userID, card)
-}
-
-// SetGroupName 设置群名
-func (c *Context[T]) SetGroupName(groupID int64, groupName string) error {
-	server := c.GetServer()
-	if server == nil {
-		return fmt.Errorf("server not available")
-	}
-	return server.SetGroupName(groupID, groupName)
-}
-
-// ============ 信息获取便捷方法 ============
-
-// GetGroupInfo 获取群信息
-func (c *Context[T]) GetGroupInfo(groupID int64) (*types.GetGroupInfoResponse, error) {
-	server := c.GetServer()
-	if server == nil {
-		return nil, fmt.Errorf("server not available")
-	}
-	return server.GetGroupInfo(groupID, false)
-}
-
-// GetGroupMemberInfo 获取群成员信息
-func (c *Context[T]) GetGroupMemberInfo(groupID, userID int64) (*types.GetGroupMemberInfoResponse, error) {
-	server := c.GetServer()
-	if server == nil {
-		return nil, fmt.Errorf("server not available")
-	}
-	return server.GetGroupMemberInfo(groupID, userID, false)
-}
-
-// GetGroupMemberList 获取群成员列表
-func (c *Context[T]) GetGroupMemberList(groupID int64) (types.GetGroupMemberListResponse, error) {
-	server := c.GetServer()
-	if server == nil {
-		return nil, fmt.Errorf("server not available")
-	}
-	return server.GetGroupMemberList(groupID)
-}
-
-// GetLoginInfo 获取登录号信息
-func (c *Context[T]) GetLoginInfo() (*types.GetLoginInfoResponse, error) {
-	server := c.GetServer()
-	if server == nil {
-		return nil, fmt.Errorf("server not available")
-	}
-	return server.GetLoginInfo()
-}
-
-// GetFriendList 获取好友列表
-func (c *Context[T]) GetFriendList() (types.GetFriendListResponse, error) {
-	server := c.GetServer()
-	if server == nil {
-		return nil, fmt.Errorf("server not available")
-	}
-	return server.GetFriendList()
-}
-
-// GetGroupList 获取群列表
-func (c *Context[T]) GetGroupList() (types.GetGroupListResponse, error) {
-	server := c.GetServer()
-	if server == nil {
-		return nil, fmt.Errorf("server not available")
-	}
-	return server.GetGroupList()
-}
-
 // ============ 事件相关便捷方法 ============
 
 // GetMessageEvent 获取消息事件（如果当前事件是消息事件）
@@ -0,0 +1,60 @@
+package event
+
+import (
+	"log/slog"
+
+	types "onebot-go2/pkg/const"
+	"onebot-go2/pkg/trace"
+)
+
+// EventAttrs 返回事件的结构化日志字段：post_type、self_id，以及消息、通知、请求事件的 group_id 和 user_id
+func EventAttrs(event interface{}) []any {
+	var base *types.Event
+	var groupID, userID int64
+	switch e := event.(type) {
+	case *types.MessageEvent:
+		base, groupID, userID = &e.Event, e.GroupID, e.UserID
+	case *types.NoticeEvent:
+		base, groupID, userID = &e.Event, e.GroupID, e.UserID
+	case *types.RequestEvent:
+		base, groupID, userID = &e.Event, e.GroupID, e.UserID
+	case *types.MetaEvent:
+		base = &e.Event
+	default:
+		return nil
+	}
+
+	attrs := []any{"post_type", base.PostType, "self_id", base.SelfID}
+	if groupID != 0 {
+		attrs = append(attrs, "group_id", groupID)
+	}
+	if userID != 0 {
+		attrs = append(attrs, "user_id", userID)
+	}
+	return attrs
+}
+
+// Logger 返回当前处理器的日志记录器，已带有事件字段（post_type、group_id、user_id 等）、handler 字段和 trace_id、span_id
+func (c *Context[T]) Logger() *slog.Logger {
+	if c.logger == nil {
+		return slog.Default()
+	}
+	return c.logger
+}
+
+// WithLogger 设置 ctx.Logger() 返回的日志记录器，用于在事件分发之外创建的 Context
+func (c *Context[T]) WithLogger(logger *slog.Logger) *Context[T] {
+	c.logger = logger
+	return c
+}
+
+// TraceID 返回事件的 trace ID，同一事件的所有处理器和它们发起的 API 调用共享
+// Context 不是由 Dispatcher 创建时返回空字符串
+func (c *Context[T]) TraceID() string {
+	return trace.TraceIDFrom(c.Context)
+}
+
+// SpanID 返回当前处理器的 span ID
+func (c *Context[T]) SpanID() string {
+	return trace.FromContext(c.Context).SpanID()
+}
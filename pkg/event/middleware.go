@@ -1,7 +1,6 @@
 package event
 
 import (
-	"log"
 	"time"
 )
 
@@ -22,15 +21,15 @@ func LoggingMiddleware() Middleware {
 	return func(next HandlerFunc[interface{}]) HandlerFunc[interface{}] {
 		return func(ctx *Context[interface{}]) error {
 			start := time.Now()
-			log.Printf("[EventMiddleware] Before handling event")
+			ctx.Logger().Debug("Handling event")
 			
 			err := next(ctx)
 			
 			duration := time.Since(start)
 			if err != nil {
-				log.Printf("[EventMiddleware] After handling event (duration: %v, error: %v)", duration, err)
+				ctx.Logger().Warn("Handled event", "duration", duration, "error", err)
 			} else {
-				log.Printf("[EventMiddleware] After handling event (duration: %v)", duration)
+				ctx.Logger().Debug("Handled event", "duration", duration)
 			}
 			
 			return err
@@ -44,7 +43,7 @@ func RecoveryMiddleware() Middleware {
 		return func(ctx *Context[interface{}]) (err error) {
 			defer func() {
 				if r := recover(); r != nil {
-					log.Printf("[EventMiddleware] Recovered from panic: %v", r)
+					ctx.Logger().Error("Recovered from panic", "panic", r)
 					if e, ok := r.(error); ok {
 						err = e
 					}
@@ -70,8 +69,8 @@ func TimeoutMiddleware(timeout time.Duration) Middleware {
 			case err := <-done:
 				return err
 			case <-time.After(timeout):
-				log.Printf("[EventMiddleware] Handler timeout after %v", timeout)
-				ctx.Abort()
+				// 只放弃等待当前处理器，不影响后续处理器
+				ctx.Logger().Warn("Handler timeout", "timeout", timeout)
 				return nil
 			}
 		}
@@ -79,12 +78,12 @@ func TimeoutMiddleware(timeout time.Duration) Middleware {
 }
 
 // FilterMiddleware 过滤中间件 - 根据条件决定是否执行处理器
+// 被过滤时只跳过当前处理器，后续处理器照常执行
 func FilterMiddleware(filter func(ctx *Context[interface{}]) bool) Middleware {
 	return func(next HandlerFunc[interface{}]) HandlerFunc[interface{}] {
 		return func(ctx *Context[interface{}]) error {
 			if !filter(ctx) {
-				log.Printf("[EventMiddleware] Event filtered out")
-				ctx.Abort()
+				ctx.Logger().Debug("Event filtered out")
 				return nil
 			}
 			return next(ctx)
@@ -92,7 +91,8 @@ func FilterMiddleware(filter func(ctx *Context[interface{}]) bool) Middleware {
 	}
 }
 
-// RateLimitMiddleware 限流中间件
+// RateLimitMiddleware 全局限流中间件，所有事件排队等待同一个 ticker
+// 按用户/群限流请使用 ratelimit.Middleware
 func RateLimitMiddleware(maxPerSecond int) Middleware {
 	ticker := time.NewTicker(time.Second / time.Duration(maxPerSecond))
 	
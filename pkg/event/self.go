@@ -0,0 +1,80 @@
+package event
+
+import (
+	"errors"
+	"time"
+
+	types "onebot-go2/pkg/const"
+)
+
+// ErrUnsupported 已连接的 OneBot 实现不支持该 action（retcode 1404）
+// 某个 action 返回过 1404 后，同一连接上的后续调用不再发送，直接返回该错误
+var ErrUnsupported = errors.New("action not supported by the OneBot implementation")
+
+// Self 机器人身份信息
+// WSServer 在连接建立后调用 get_login_info、get_version_info 和 get_status 获取，重新连接时刷新
+type Self struct {
+	SelfID          int64     `json:"self_id"`
+	Nickname        string    `json:"nickname"`
+	AppName         string    `json:"app_name"`         // OneBot 实现名称，如 Lagrange.OneBot
+	AppVersion      string    `json:"app_version"`      // OneBot 实现版本
+	ProtocolVersion string    `json:"protocol_version"` // OneBot 协议版本，如 v11
+	Online          bool      `json:"online"`
+	Good            bool      `json:"good"`
+	ConnectedAt     time.Time `json:"connected_at"`
+	Unsupported     []string  `json:"unsupported,omitempty"` // 返回过 1404 的 action
+}
+
+// Identified 是否已获取到身份信息，连接刚建立或 get_login_info 失败时为 false
+func (s Self) Identified() bool {
+	return s.Nickname != "" || s.AppName != ""
+}
+
+// Supports 是否支持 action，只有返回过 1404 的 action 视为不支持
+func (s Self) Supports(action string) bool {
+	for _, unsupported := range s.Unsupported {
+		if unsupported == action {
+			return false
+		}
+	}
+	return true
+}
+
+// SelfProvider 提供已连接机器人身份信息的 Server
+type SelfProvider interface {
+	Self() Self
+}
+
+// Self 返回处理当前事件的机器人的身份信息
+// Server 未提供身份信息时只填充事件中的 self_id
+func (c *Context[T]) Self() Self {
+	if provider, ok := c.server.(SelfProvider); ok {
+		return provider.Self()
+	}
+	return Self{SelfID: selfIDOf(any(c.Event))}
+}
+
+// FromSelf 判断当前消息是否由机器人自己发出
+func (c *Context[T]) FromSelf() bool {
+	userID, ok := c.GetUserID()
+	if !ok {
+		return false
+	}
+	selfID := c.Self().SelfID
+	return selfID != 0 && userID == selfID
+}
+
+// selfIDOf 返回事件中的 self_id
+func selfIDOf(evt interface{}) int64 {
+	switch e := evt.(type) {
+	case *types.MessageEvent:
+		return e.SelfID
+	case *types.NoticeEvent:
+		return e.SelfID
+	case *types.RequestEvent:
+		return e.SelfID
+	case *types.MetaEvent:
+		return e.SelfID
+	}
+	return 0
+}
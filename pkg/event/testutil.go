@@ -0,0 +1,462 @@
+package event
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	types "onebot-go2/pkg/const"
+	"onebot-go2/pkg/storage"
+)
+
+// TestSelfID TestServer 默认的机器人 QQ 号
+const TestSelfID int64 = 10000
+
+// TestCall TestServer 记录的一次 API 调用
+type TestCall struct {
+	Action string
+	// Params 调用参数，如 *types.SendMessageParams、*types.SetGroupBanParams
+	Params interface{}
+}
+
+// TestServer 用于处理器单元测试的内存 ServerInterface 实现
+// 记录所有调用，发送消息返回递增的消息 ID，群信息和群成员信息返回预先设定的数据
+type TestServer struct {
+	mu        sync.Mutex
+	selfID    int64
+	calls     []TestCall
+	groups    map[int64]types.GroupInfo
+	members   map[[2]int64]types.GroupMemberInfo
+	friends   []types.FriendInfo
+	errors    map[string]error
+	messageID int32
+}
+
+var (
+	_ ServerInterface = (*TestServer)(nil)
+	_ SelfProvider    = (*TestServer)(nil)
+)
+
+// NewTestServer 创建测试用 Server
+func NewTestServer() *TestServer {
+	return &TestServer{
+		selfID:  TestSelfID,
+		groups:  make(map[int64]types.GroupInfo),
+		members: make(map[[2]int64]types.GroupMemberInfo),
+		errors:  make(map[string]error),
+	}
+}
+
+// ============ 设定响应 ============
+
+// SetSelfID 设置 GetLoginInfo 和 Self 返回的机器人 QQ 号
+func (s *TestServer) SetSelfID(selfID int64) *TestServer {
+	s.mu.Lock()
+	s.selfID = selfID
+	s.mu.Unlock()
+	return s
+}
+
+// Self 返回与 GetLoginInfo、GetVersionInfo、GetStatus 一致的身份信息（ctx.Self()），不记录调用
+func (s *TestServer) Self() Self {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return Self{
+		SelfID:          s.selfID,
+		Nickname:        "test",
+		AppName:         "test",
+		AppVersion:      "0.0.0",
+		ProtocolVersion: "v11",
+		Online:          true,
+		Good:            true,
+	}
+}
+
+// AddGroup 设定 GetGroupInfo 和 GetGroupList 返回的群信息
+func (s *TestServer) AddGroup(info types.GroupInfo) *TestServer {
+	s.mu.Lock()
+	s.groups[info.GroupID] = info
+	s.mu.Unlock()
+	return s
+}
+
+// AddMember 设定 GetGroupMemberInfo 和 GetGroupMemberList 返回的群成员信息
+func (s *TestServer) AddMember(member types.GroupMemberInfo) *TestServer {
+	s.mu.Lock()
+	s.members[[2]int64{member.GroupID, member.UserID}] = member
+	s.mu.Unlock()
+	return s
+}
+
+// AddFriend 设定 GetFriendList 返回的好友
+func (s *TestServer) AddFriend(friend types.FriendInfo) *TestServer {
+	s.mu.Lock()
+	s.friends = append(s.friends, friend)
+	s.mu.Unlock()
+	return s
+}
+
+// Fail 设定 action 返回 err，调用仍会被记录；err 为 nil 时取消
+func (s *TestServer) Fail(action string, err error) *TestServer {
+	s.mu.Lock()
+	if err == nil {
+		delete(s.errors, action)
+	} else {
+		s.errors[action] = err
+	}
+	s.mu.Unlock()
+	return s
+}
+
+// ============ 调用记录 ============
+
+// Calls 返回所有调用
+func (s *TestServer) Calls() []TestCall {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]TestCall(nil), s.calls...)
+}
+
+// CallsTo 返回指定 action 的调用
+func (s *TestServer) CallsTo(action string) []TestCall {
+	var calls []TestCall
+	for _, call := range s.Calls() {
+		if call.Action == action {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// Sent 返回发送的消息，send_private_msg、send_group_msg 和 send_msg 统一为 SendMessageParams
+func (s *TestServer) Sent() []types.SendMessageParams {
+	var sent []types.SendMessageParams
+	for _, call := range s.Calls() {
+		if params, ok := call.Params.(*types.SendMessageParams); ok {
+			sent = append(sent, *params)
+		}
+	}
+	return sent
+}
+
+// SentTexts 返回发送的消息中文本段拼接的纯文本
+func (s *TestServer) SentTexts() []string {
+	var texts []string
+	for _, params := range s.Sent() {
+		texts = append(texts, plainText(params.Message))
+	}
+	return texts
+}
+
+// Bans 返回禁言调用（duration 为 0 表示解除禁言）
+func (s *TestServer) Bans() []types.SetGroupBanParams {
+	var bans []types.SetGroupBanParams
+	for _, call := range s.CallsTo(types.ActionSetGroupBan) {
+		bans = append(bans, *call.Params.(*types.SetGroupBanParams))
+	}
+	return bans
+}
+
+// Kicks 返回踢人调用
+func (s *TestServer) Kicks() []types.SetGroupKickParams {
+	var kicks []types.SetGroupKickParams
+	for _, call := range s.CallsTo(types.ActionSetGroupKick) {
+		kicks = append(kicks, *call.Params.(*types.SetGroupKickParams))
+	}
+	return kicks
+}
+
+// Reset 清空调用记录，设定的响应保留
+func (s *TestServer) Reset() {
+	s.mu.Lock()
+	s.calls = nil
+	s.mu.Unlock()
+}
+
+// record 记录调用，返回为 action 设定的错误
+func (s *TestServer) record(action string, params interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls = append(s.calls, TestCall{Action: action, Params: params})
+	return s.errors[action]
+}
+
+func (s *TestServer) send(action string, params *types.SendMessageParams) (*types.SendMessageResponse, error) {
+	if err := s.record(action, params); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messageID++
+	return &types.SendMessageResponse{MessageID: s.messageID}, nil
+}
+
+// ============ ServerInterface ============
+// 只记录调用的方法由 pkg/const/actions.yaml 生成（testutil_gen.go），这里是需要返回设定数据的方法
+
+// SendPrivateMsg 发送私聊消息
+func (s *TestServer) SendPrivateMsg(userID int64, message types.MessageArray) (*types.SendMessageResponse, error) {
+	return s.send(types.ActionSendPrivateMsg, &types.SendMessageParams{
+		MessageType: types.MessageTypePrivate,
+		UserID:      userID,
+		Message:     message,
+	})
+}
+
+// SendGroupMsg 发送群消息
+func (s *TestServer) SendGroupMsg(groupID int64, message types.MessageArray) (*types.SendMessageResponse, error) {
+	return s.send(types.ActionSendGroupMsg, &types.SendMessageParams{
+		MessageType: types.MessageTypeGroup,
+		GroupID:     groupID,
+		Message:     message,
+	})
+}
+
+// SendMsg 发送消息
+func (s *TestServer) SendMsg(params *types.SendMessageParams) (*types.SendMessageResponse, error) {
+	copied := *params
+	return s.send(types.ActionSendMsg, &copied)
+}
+
+// GetMsg 获取消息，测试 Server 不保存消息，总是返回错误
+func (s *TestServer) GetMsg(messageID int32) (*types.GetMsgResponse, error) {
+	if err := s.record(types.ActionGetMsg, &types.GetMsgParams{MessageID: messageID}); err != nil {
+		return nil, err
+	}
+	return nil, fmt.Errorf("message %d not found", messageID)
+}
+
+// GetGroupInfo 获取群信息，未通过 AddGroup 设定的群返回错误
+func (s *TestServer) GetGroupInfo(groupID int64, noCache bool) (*types.GetGroupInfoResponse, error) {
+	if err := s.record(types.ActionGetGroupInfo, &types.GetGroupInfoParams{GroupID: groupID, NoCache: noCache}); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	info, ok := s.groups[groupID]
+	if !ok {
+		return nil, fmt.Errorf("group %d not found", groupID)
+	}
+	resp := types.GetGroupInfoResponse(info)
+	return &resp, nil
+}
+
+// GetGroupMemberInfo 获取群成员信息，未通过 AddMember 设定的成员返回错误
+func (s *TestServer) GetGroupMemberInfo(groupID, userID int64, noCache bool) (*types.GetGroupMemberInfoResponse, error) {
+	params := &types.GetGroupMemberInfoParams{GroupID: groupID, UserID: userID, NoCache: noCache}
+	if err := s.record(types.ActionGetGroupMemberInfo, params); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	member, ok := s.members[[2]int64{groupID, userID}]
+	if !ok {
+		return nil, fmt.Errorf("member %d not found in group %d", userID, groupID)
+	}
+	resp := types.GetGroupMemberInfoResponse(member)
+	return &resp, nil
+}
+
+// GetGroupMemberList 获取群成员列表
+func (s *TestServer) GetGroupMemberList(groupID int64) (types.GetGroupMemberListResponse, error) {
+	if err := s.record(types.ActionGetGroupMemberList, &types.GetGroupMemberListParams{GroupID: groupID}); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var list types.GetGroupMemberListResponse
+	for key, member := range s.members {
+		if key[0] == groupID {
+			list = append(list, member)
+		}
+	}
+	return list, nil
+}
+
+// GetLoginInfo 获取登录号信息
+func (s *TestServer) GetLoginInfo() (*types.GetLoginInfoResponse, error) {
+	if err := s.record(types.ActionGetLoginInfo, nil); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return &types.GetLoginInfoResponse{UserID: s.selfID, Nickname: "test"}, nil
+}
+
+// GetFriendList 获取好友列表
+func (s *TestServer) GetFriendList() (types.GetFriendListResponse, error) {
+	if err := s.record(types.ActionGetFriendList, nil); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append(types.GetFriendListResponse(nil), s.friends...), nil
+}
+
+// GetGroupList 获取群列表
+func (s *TestServer) GetGroupList() (types.GetGroupListResponse, error) {
+	if err := s.record(types.ActionGetGroupList, nil); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var list types.GetGroupListResponse
+	for _, info := range s.groups {
+		list = append(list, info)
+	}
+	return list, nil
+}
+
+// GetStrangerInfo 获取陌生人信息，返回只有 QQ 号的信息
+func (s *TestServer) GetStrangerInfo(userID int64, noCache bool) (*types.GetStrangerInfoResponse, error) {
+	if err := s.record(types.ActionGetStrangerInfo, &types.GetStrangerInfoParams{UserID: userID, NoCache: noCache}); err != nil {
+		return nil, err
+	}
+	return &types.GetStrangerInfoResponse{UserID: userID}, nil
+}
+
+// GetGroupHonorInfo 获取群荣誉信息，返回空信息
+func (s *TestServer) GetGroupHonorInfo(groupID int64, honorType string) (*types.GetGroupHonorInfoResponse, error) {
+	if err := s.record(types.ActionGetGroupHonorInfo, &types.GetGroupHonorInfoParams{GroupID: groupID, Type: honorType}); err != nil {
+		return nil, err
+	}
+	return &types.GetGroupHonorInfoResponse{GroupID: groupID}, nil
+}
+
+// GetRecord 获取语音，返回原文件名
+func (s *TestServer) GetRecord(file, outFormat string) (*types.GetRecordResponse, error) {
+	if err := s.record(types.ActionGetRecord, &types.GetRecordParams{File: file, OutFormat: outFormat}); err != nil {
+		return nil, err
+	}
+	return &types.GetRecordResponse{File: file}, nil
+}
+
+// GetImage 获取图片，返回原文件名
+func (s *TestServer) GetImage(file string) (*types.GetImageResponse, error) {
+	if err := s.record(types.ActionGetImage, &types.GetImageParams{File: file}); err != nil {
+		return nil, err
+	}
+	return &types.GetImageResponse{File: file}, nil
+}
+
+// CanSendImage 检查是否可以发送图片，总是可以
+func (s *TestServer) CanSendImage() (*types.CanSendImageResponse, error) {
+	if err := s.record(types.ActionCanSendImage, nil); err != nil {
+		return nil, err
+	}
+	return &types.CanSendImageResponse{Yes: true}, nil
+}
+
+// CanSendRecord 检查是否可以发送语音，总是可以
+func (s *TestServer) CanSendRecord() (*types.CanSendRecordResponse, error) {
+	if err := s.record(types.ActionCanSendRecord, nil); err != nil {
+		return nil, err
+	}
+	return &types.CanSendRecordResponse{Yes: true}, nil
+}
+
+// GetStatus 获取运行状态，总是在线
+func (s *TestServer) GetStatus() (*types.GetStatusResponse, error) {
+	if err := s.record(types.ActionGetStatus, nil); err != nil {
+		return nil, err
+	}
+	return &types.GetStatusResponse{Online: true, Good: true}, nil
+}
+
+// GetVersionInfo 获取版本信息
+func (s *TestServer) GetVersionInfo() (*types.GetVersionInfoResponse, error) {
+	if err := s.record(types.ActionGetVersionInfo, nil); err != nil {
+		return nil, err
+	}
+	return &types.GetVersionInfoResponse{AppName: "test", AppVersion: "0.0.0", ProtocolVersion: "v11"}, nil
+}
+
+// CallAPI 调用任意 action，记录原始参数并返回成功的空响应
+func (s *TestServer) CallAPI(action string, params interface{}) (*types.APIResponse, error) {
+	if err := s.record(action, params); err != nil {
+		return nil, err
+	}
+	return &types.APIResponse{Status: "ok"}, nil
+}
+
+// IsConnected 总是返回 true
+func (s *TestServer) IsConnected() bool {
+	return true
+}
+
+// plainText 返回消息中文本段拼接的纯文本
+func plainText(message types.MessageArray) string {
+	text := ""
+	for _, seg := range message {
+		if seg.Type == "text" {
+			if s, ok := seg.Data["text"].(string); ok {
+				text += s
+			}
+		}
+	}
+	return text
+}
+
+// ============ 测试 Context ============
+
+// TestOption NewTestContext 的选项
+type TestOption func(*testOptions)
+
+type testOptions struct {
+	ctx     context.Context
+	server  *TestServer
+	storage storage.Store
+	setup   []func(*TestServer)
+}
+
+// WithTestServer 使用已有的 TestServer，多个 Context 可以共享调用记录
+func WithTestServer(server *TestServer) TestOption {
+	return func(o *testOptions) { o.server = server }
+}
+
+// WithTestStorage 设置 ctx.Storage() 返回的存储，默认为新的内存存储
+func WithTestStorage(store storage.Store) TestOption {
+	return func(o *testOptions) { o.storage = store }
+}
+
+// WithTestContext 设置 Context 内嵌的 context.Context，默认为 context.Background()
+func WithTestContext(ctx context.Context) TestOption {
+	return func(o *testOptions) { o.ctx = ctx }
+}
+
+// WithGroupInfo 设定 GetGroupInfo 返回的群信息
+func WithGroupInfo(info types.GroupInfo) TestOption {
+	return func(o *testOptions) {
+		o.setup = append(o.setup, func(s *TestServer) { s.AddGroup(info) })
+	}
+}
+
+// WithGroupMember 设定 GetGroupMemberInfo 返回的群成员信息
+func WithGroupMember(member types.GroupMemberInfo) TestOption {
+	return func(o *testOptions) {
+		o.setup = append(o.setup, func(s *TestServer) { s.AddMember(member) })
+	}
+}
+
+// NewTestContext 创建用于处理器单元测试的 Context，API 调用由返回的 TestServer 记录
+//
+//	ctx, srv := event.NewTestContext(event.GroupMessage(123, 456, "/ping"))
+//	handler.Handle(ctx)
+//	// srv.SentTexts() == []string{"Pong!"}
+func NewTestContext[T any](event T, opts ...TestOption) (*Context[T], *TestServer) {
+	o := testOptions{ctx: context.Background()}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.server == nil {
+		o.server = NewTestServer()
+	}
+	if o.storage == nil {
+		o.storage = storage.NewMemory()
+	}
+	for _, setup := range o.setup {
+		setup(o.server)
+	}
+	ctx := NewContext(o.ctx, event).WithServer(o.server).WithStorage(o.storage)
+	return ctx, o.server
+}
@@ -0,0 +1,141 @@
+// Code generated by apigen from actions.yaml. DO NOT EDIT.
+
+package event
+
+import (
+	types "onebot-go2/pkg/const"
+)
+
+// SendGroupForwardMsg 发送群合并转发消息
+func (s *TestServer) SendGroupForwardMsg(groupID int64, messages types.MessageArray) (*types.SendMessageResponse, error) {
+	if err := s.record(types.ActionSendGroupForwardMsg, &types.SendForwardMsgParams{GroupID: groupID, Messages: messages}); err != nil {
+		return nil, err
+	}
+	return &types.SendMessageResponse{}, nil
+}
+
+// SendPrivateForwardMsg 发送私聊合并转发消息
+func (s *TestServer) SendPrivateForwardMsg(userID int64, messages types.MessageArray) (*types.SendMessageResponse, error) {
+	if err := s.record(types.ActionSendPrivateForwardMsg, &types.SendForwardMsgParams{UserID: userID, Messages: messages}); err != nil {
+		return nil, err
+	}
+	return &types.SendMessageResponse{}, nil
+}
+
+// DeleteMsg 撤回消息
+func (s *TestServer) DeleteMsg(messageID int32) error {
+	return s.record(types.ActionDeleteMsg, &types.DeleteMsgParams{MessageID: messageID})
+}
+
+// GetForwardMsg 获取合并转发消息
+func (s *TestServer) GetForwardMsg(id string) (*types.GetForwardMsgResponse, error) {
+	if err := s.record(types.ActionGetForwardMsg, &types.GetForwardMsgParams{ID: id}); err != nil {
+		return nil, err
+	}
+	return &types.GetForwardMsgResponse{}, nil
+}
+
+// SendLike 发送好友赞
+func (s *TestServer) SendLike(userID int64, times int) error {
+	return s.record(types.ActionSendLike, &types.SendLikeParams{UserID: userID, Times: times})
+}
+
+// SetGroupKick 群组踢人
+func (s *TestServer) SetGroupKick(groupID, userID int64, rejectAddRequest bool) error {
+	return s.record(types.ActionSetGroupKick, &types.SetGroupKickParams{GroupID: groupID, UserID: userID, RejectAddRequest: rejectAddRequest})
+}
+
+// SetGroupBan 群组单人禁言
+func (s *TestServer) SetGroupBan(groupID, userID, duration int64) error {
+	return s.record(types.ActionSetGroupBan, &types.SetGroupBanParams{GroupID: groupID, UserID: userID, Duration: duration})
+}
+
+// SetGroupAnonymousBan 群组匿名用户禁言
+func (s *TestServer) SetGroupAnonymousBan(groupID int64, flag string, duration int64) error {
+	return s.record(types.ActionSetGroupAnonymousBan, &types.SetGroupAnonymousBanParams{GroupID: groupID, Flag: flag, Duration: duration})
+}
+
+// SetGroupWholeBan 群组全员禁言
+func (s *TestServer) SetGroupWholeBan(groupID int64, enable bool) error {
+	return s.record(types.ActionSetGroupWholeBan, &types.SetGroupWholeBanParams{GroupID: groupID, Enable: enable})
+}
+
+// SetGroupAdmin 设置群管理员
+func (s *TestServer) SetGroupAdmin(groupID, userID int64, enable bool) error {
+	return s.record(types.ActionSetGroupAdmin, &types.SetGroupAdminParams{GroupID: groupID, UserID: userID, Enable: enable})
+}
+
+// SetGroupAnonymous 设置群匿名
+func (s *TestServer) SetGroupAnonymous(groupID int64, enable bool) error {
+	return s.record(types.ActionSetGroupAnonymous, &types.SetGroupAnonymousParams{GroupID: groupID, Enable: enable})
+}
+
+// SetGroupCard 设置群名片（群备注）
+func (s *TestServer) SetGroupCard(groupID, userID int64, card string) error {
+	return s.record(types.ActionSetGroupCard, &types.SetGroupCardParams{GroupID: groupID, UserID: userID, Card: card})
+}
+
+// SetGroupName 设置群名
+func (s *TestServer) SetGroupName(groupID int64, groupName string) error {
+	return s.record(types.ActionSetGroupName, &types.SetGroupNameParams{GroupID: groupID, GroupName: groupName})
+}
+
+// SetGroupLeave 退出群组
+func (s *TestServer) SetGroupLeave(groupID int64, isDismiss bool) error {
+	return s.record(types.ActionSetGroupLeave, &types.SetGroupLeaveParams{GroupID: groupID, IsDismiss: isDismiss})
+}
+
+// SetGroupSpecialTitle 设置群组专属头衔
+func (s *TestServer) SetGroupSpecialTitle(groupID, userID int64, specialTitle string, duration int64) error {
+	return s.record(types.ActionSetGroupSpecialTitle, &types.SetGroupSpecialTitleParams{GroupID: groupID, UserID: userID, SpecialTitle: specialTitle, Duration: duration})
+}
+
+// SetFriendAddRequest 处理加好友请求
+func (s *TestServer) SetFriendAddRequest(flag string, approve bool, remark string) error {
+	return s.record(types.ActionSetFriendAddRequest, &types.SetFriendAddRequestParams{Flag: flag, Approve: approve, Remark: remark})
+}
+
+// SetGroupAddRequest 处理加群请求/邀请
+func (s *TestServer) SetGroupAddRequest(flag, subType string, approve bool, reason string) error {
+	return s.record(types.ActionSetGroupAddRequest, &types.SetGroupAddRequestParams{Flag: flag, SubType: subType, Approve: approve, Reason: reason})
+}
+
+// GetCookies 获取Cookies
+func (s *TestServer) GetCookies(domain string) (*types.GetCookiesResponse, error) {
+	if err := s.record(types.ActionGetCookies, &types.GetCookiesParams{Domain: domain}); err != nil {
+		return nil, err
+	}
+	return &types.GetCookiesResponse{}, nil
+}
+
+// GetCsrfToken 获取CSRF Token
+func (s *TestServer) GetCsrfToken() (*types.GetCsrfTokenResponse, error) {
+	if err := s.record(types.ActionGetCsrfToken, nil); err != nil {
+		return nil, err
+	}
+	return &types.GetCsrfTokenResponse{}, nil
+}
+
+// GetCredentials 获取QQ相关接口凭证
+func (s *TestServer) GetCredentials(domain string) (*types.GetCredentialsResponse, error) {
+	if err := s.record(types.ActionGetCredentials, &types.GetCredentialsParams{Domain: domain}); err != nil {
+		return nil, err
+	}
+	return &types.GetCredentialsResponse{}, nil
+}
+
+// SetRestart 重启OneBot实现
+func (s *TestServer) SetRestart(delay int) error {
+	return s.record(types.ActionSetRestart, &types.SetRestartParams{Delay: delay})
+}
+
+// CleanCache 清理缓存
+func (s *TestServer) CleanCache() error {
+	return s.record(types.ActionCleanCache, &types.CleanCacheParams{})
+}
+
+// SetQQProfile 设置登录号资料（需要扩展API）
+func (s *TestServer) SetQQProfile(params *types.SetQQProfileParams) error {
+	copied := *params
+	return s.record(types.ActionSetQQProfile, &copied)
+}
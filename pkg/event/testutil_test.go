@@ -0,0 +1,165 @@
+package event
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	types "onebot-go2/pkg/const"
+)
+
+// moderate 测试用处理器：群管理员发送 "/ban <QQ>" 或 "/kick <QQ>"，回复中带上群名和目标的群名片
+func moderate(ctx *Context[*types.MessageEvent]) error {
+	cmd, arg, ok := strings.Cut(ctx.Event.RawMessage, " ")
+	if !ok || (cmd != "/ban" && cmd != "/kick") {
+		return nil
+	}
+	target, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil {
+		return err
+	}
+	groupID := ctx.Event.GroupID
+
+	sender, err := ctx.GetGroupMemberInfo(groupID, ctx.Event.UserID)
+	if err != nil {
+		return err
+	}
+	if sender.Role != "owner" && sender.Role != "admin" {
+		_, err := ctx.ReplyText("权限不足")
+		return err
+	}
+	group, err := ctx.GetGroupInfo(groupID)
+	if err != nil {
+		return err
+	}
+	member, err := ctx.GetGroupMemberInfo(groupID, target)
+	if err != nil {
+		return err
+	}
+
+	if cmd == "/ban" {
+		err = ctx.BanGroupMember(groupID, target, 600)
+	} else {
+		err = ctx.KickGroupMember(groupID, target, false)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = ctx.ReplyText(fmt.Sprintf("%s: 已处理 %s", group.GroupName, member.Card))
+	return err
+}
+
+func TestNewTestContext(t *testing.T) {
+	group := WithGroupInfo(types.GroupInfo{GroupID: 100, GroupName: "测试群", MemberCount: 3})
+	admin := WithGroupMember(types.GroupMemberInfo{GroupID: 100, UserID: 10001, Role: "admin"})
+	member := WithGroupMember(types.GroupMemberInfo{GroupID: 100, UserID: 10002, Role: "member"})
+	target := WithGroupMember(types.GroupMemberInfo{GroupID: 100, UserID: 20000, Card: "小明", Role: "member"})
+
+	tests := []struct {
+		name      string
+		event     *types.MessageEvent
+		opts      []TestOption
+		wantErr   bool
+		wantTexts []string
+		wantBans  []types.SetGroupBanParams
+		wantKicks []types.SetGroupKickParams
+	}{
+		{
+			name:      "ban",
+			event:     GroupMessage(100, 10001, "/ban 20000"),
+			opts:      []TestOption{group, admin, target},
+			wantTexts: []string{"测试群: 已处理 小明"},
+			wantBans:  []types.SetGroupBanParams{{GroupID: 100, UserID: 20000, Duration: 600}},
+		},
+		{
+			name:      "kick",
+			event:     GroupMessage(100, 10001, "/kick 20000"),
+			opts:      []TestOption{group, admin, target},
+			wantTexts: []string{"测试群: 已处理 小明"},
+			wantKicks: []types.SetGroupKickParams{{GroupID: 100, UserID: 20000}},
+		},
+		{
+			name:      "not an admin",
+			event:     GroupMessage(100, 10002, "/ban 20000"),
+			opts:      []TestOption{group, member, target},
+			wantTexts: []string{"权限不足"},
+		},
+		{
+			// 未设定的群成员信息返回错误
+			name:    "unknown sender",
+			event:   GroupMessage(100, 10003, "/ban 20000"),
+			opts:    []TestOption{group, target},
+			wantErr: true,
+		},
+		{
+			// 未设定的群信息返回错误，不执行操作
+			name:    "unknown group",
+			event:   GroupMessage(100, 10001, "/kick 20000"),
+			opts:    []TestOption{admin, target},
+			wantErr: true,
+		},
+		{
+			name:  "other message",
+			event: GroupMessage(100, 10001, "hello"),
+			opts:  []TestOption{group, admin},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, srv := NewTestContext(tt.event, tt.opts...)
+			if err := moderate(ctx); (err != nil) != tt.wantErr {
+				t.Fatalf("handler error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := srv.SentTexts(); !reflect.DeepEqual(got, tt.wantTexts) {
+				t.Errorf("SentTexts() = %v, want %v", got, tt.wantTexts)
+			}
+			if got := srv.Bans(); !reflect.DeepEqual(got, tt.wantBans) {
+				t.Errorf("Bans() = %v, want %v", got, tt.wantBans)
+			}
+			if got := srv.Kicks(); !reflect.DeepEqual(got, tt.wantKicks) {
+				t.Errorf("Kicks() = %v, want %v", got, tt.wantKicks)
+			}
+			// 回复发往事件所在的群
+			for _, sent := range srv.Sent() {
+				if sent.GroupID != 100 {
+					t.Errorf("sent %+v, want group 100", sent)
+				}
+			}
+		})
+	}
+}
+
+func TestNewTestContextSharedServer(t *testing.T) {
+	srv := NewTestServer().
+		AddGroup(types.GroupInfo{GroupID: 100, GroupName: "测试群"}).
+		AddMember(types.GroupMemberInfo{GroupID: 100, UserID: 10001, Role: "owner"}).
+		AddMember(types.GroupMemberInfo{GroupID: 100, UserID: 20000, Card: "小明"})
+
+	for _, text := range []string{"/ban 20000", "/kick 20000"} {
+		ctx, got := NewTestContext(GroupMessage(100, 10001, text), WithTestServer(srv))
+		if got != srv {
+			t.Fatal("NewTestContext() did not use the given server")
+		}
+		if err := moderate(ctx); err != nil {
+			t.Fatalf("%s: %v", text, err)
+		}
+	}
+	if len(srv.Bans()) != 1 || len(srv.Kicks()) != 1 || len(srv.SentTexts()) != 2 {
+		t.Errorf("calls = %v", srv.Calls())
+	}
+
+	// 设定的错误仍记录调用
+	srv.Reset()
+	srv.Fail(types.ActionSetGroupKick, errors.New("no permission"))
+	ctx, _ := NewTestContext(GroupMessage(100, 10001, "/kick 20000"), WithTestServer(srv))
+	if err := moderate(ctx); err == nil || err.Error() != "no permission" {
+		t.Errorf("handler error = %v, want no permission", err)
+	}
+	if len(srv.Kicks()) != 1 || len(srv.Sent()) != 0 {
+		t.Errorf("calls after failed kick = %v", srv.Calls())
+	}
+}
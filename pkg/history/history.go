@@ -0,0 +1,248 @@
+package history
+
+import (
+	"sync"
+	"time"
+
+	types "onebot-go2/pkg/const"
+)
+
+// DefaultMaxRecords 默认最多保留的消息条数
+const DefaultMaxRecords = 10000
+
+// Record 一条消息记录
+type Record struct {
+	MessageID   int32
+	Time        time.Time
+	MessageType types.MessageType
+	GroupID     int64 // 群号，私聊消息为 0
+	UserID      int64 // 收到的消息为发送者；发出的私聊消息为接收者；发出的群消息为 0
+	Sender      types.Sender
+	Message     types.MessageArray
+	RawMessage  string
+	Outgoing    bool // 是否为机器人发出的消息
+	Recalled    bool // 是否已被撤回
+}
+
+// FromEvent 由收到的消息事件生成记录
+func FromEvent(evt *types.MessageEvent) Record {
+	rec := Record{
+		MessageID:   evt.MessageID,
+		Time:        time.Now(),
+		MessageType: evt.MessageType,
+		UserID:      evt.UserID,
+		Sender:      evt.Sender,
+		Message:     evt.Message,
+		RawMessage:  evt.RawMessage,
+	}
+	if evt.Time > 0 {
+		rec.Time = time.Unix(evt.Time, 0)
+	}
+	if evt.MessageType == types.MessageTypeGroup {
+		rec.GroupID = evt.GroupID
+	}
+	return rec
+}
+
+// FromSent 由发送成功的消息生成记录，messageID 为 SendMessageResponse 中的消息 ID
+func FromSent(params types.SendMessageParams, messageID int32) Record {
+	rec := Record{
+		MessageID:   messageID,
+		Time:        time.Now(),
+		MessageType: params.MessageType,
+		Message:     params.Message,
+		Outgoing:    true,
+	}
+	// send_msg 未指定 message_type 时按 group_id 判断
+	if rec.MessageType == "" {
+		rec.MessageType = types.MessageTypePrivate
+		if params.GroupID != 0 {
+			rec.MessageType = types.MessageTypeGroup
+		}
+	}
+	if rec.MessageType == types.MessageTypeGroup {
+		rec.GroupID = params.GroupID
+	} else {
+		rec.UserID = params.UserID
+	}
+	return rec
+}
+
+// Query 查询条件，零值字段表示不限制
+type Query struct {
+	MessageType types.MessageType
+	GroupID     int64
+	UserID      int64
+	Since       time.Time // 包含
+	Until       time.Time // 不包含
+	Outgoing    *bool     // 只查询发出（true）或收到（false）的消息
+	Limit       int       // 最多返回最近的 Limit 条
+}
+
+func (q Query) match(rec *Record) bool {
+	switch {
+	case q.MessageType != "" && rec.MessageType != q.MessageType:
+		return false
+	case q.GroupID != 0 && rec.GroupID != q.GroupID:
+		return false
+	case q.UserID != 0 && rec.UserID != q.UserID:
+		return false
+	case !q.Since.IsZero() && rec.Time.Before(q.Since):
+		return false
+	case !q.Until.IsZero() && !rec.Time.Before(q.Until):
+		return false
+	case q.Outgoing != nil && rec.Outgoing != *q.Outgoing:
+		return false
+	}
+	return true
+}
+
+// Store 有上限的消息历史，只保存在内存中
+//
+// 记录按 Time 排序保存（客户端上报的时间可能乱序），超过 maxRecords 条时丢弃 Time 最早的消息，
+// 早于 maxAge 的消息在写入和查询时清理。
+// 返回的 Record 与存储共享 Message，调用方不应修改。
+type Store struct {
+	maxRecords int
+	maxAge     time.Duration
+	now        func() time.Time
+
+	mu      sync.RWMutex
+	records []*Record // 环形缓冲区，按 Time 排序，Time 相同时按写入顺序
+	head    int       // Time 最早的一条记录的下标
+	size    int
+	byID    map[int32]*Record
+}
+
+// New 创建消息历史，maxRecords <= 0 时使用 DefaultMaxRecords，maxAge <= 0 表示不按时间清理
+func New(maxRecords int, maxAge time.Duration) *Store {
+	if maxRecords <= 0 {
+		maxRecords = DefaultMaxRecords
+	}
+	return &Store{
+		maxRecords: maxRecords,
+		maxAge:     maxAge,
+		now:        time.Now,
+		records:    make([]*Record, maxRecords),
+		byID:       make(map[int32]*Record),
+	}
+}
+
+// Add 写入一条记录，已存在相同 MessageID 时 Get 返回后写入的一条
+func (s *Store) Add(rec Record) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r := &rec
+	if s.expired(r) {
+		return
+	}
+	s.prune()
+	if s.size == s.maxRecords {
+		// 已满时新记录比所有记录都早，它就是应当丢弃的一条
+		if r.Time.Before(s.at(0).Time) {
+			return
+		}
+		s.removeHead()
+	}
+	// 从最新的记录向前找到插入位置，按时间顺序到达的消息不需要移动
+	i := s.size
+	for ; i > 0 && s.at(i-1).Time.After(r.Time); i-- {
+		s.records[(s.head+i)%s.maxRecords] = s.at(i - 1)
+	}
+	s.records[(s.head+i)%s.maxRecords] = r
+	s.size++
+	if r.MessageID != 0 {
+		s.byID[r.MessageID] = r
+	}
+}
+
+// at 返回按时间排序的第 i 条记录，调用方需持有锁
+func (s *Store) at(i int) *Record {
+	return s.records[(s.head+i)%s.maxRecords]
+}
+
+// removeHead 移除最早的一条记录，调用方需持有写锁
+func (s *Store) removeHead() {
+	r := s.records[s.head]
+	if s.byID[r.MessageID] == r {
+		delete(s.byID, r.MessageID)
+	}
+	s.records[s.head] = nil
+	s.head = (s.head + 1) % s.maxRecords
+	s.size--
+}
+
+// prune 清理过期记录，调用方需持有写锁
+func (s *Store) prune() {
+	if s.maxAge <= 0 {
+		return
+	}
+	// 记录按时间排序，过期的记录都在最前面
+	cutoff := s.now().Add(-s.maxAge)
+	for s.size > 0 && s.at(0).Time.Before(cutoff) {
+		s.removeHead()
+	}
+}
+
+// expired 判断记录是否已超过保留时间
+func (s *Store) expired(r *Record) bool {
+	return s.maxAge > 0 && r.Time.Before(s.now().Add(-s.maxAge))
+}
+
+// Get 按消息 ID 查询
+func (s *Store) Get(messageID int32) (Record, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	r, ok := s.byID[messageID]
+	if !ok || s.expired(r) {
+		return Record{}, false
+	}
+	return *r, true
+}
+
+// Query 按条件查询，结果按时间从早到晚排列
+func (s *Store) Query(q Query) []Record {
+	s.mu.Lock()
+	s.prune()
+	s.mu.Unlock()
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	// 从最新的记录向前扫描，满足 Limit 后停止
+	var matched []Record
+	for i := s.size - 1; i >= 0; i-- {
+		r := s.at(i)
+		if !q.match(r) || s.expired(r) {
+			continue
+		}
+		matched = append(matched, *r)
+		if q.Limit > 0 && len(matched) >= q.Limit {
+			break
+		}
+	}
+	for i, j := 0, len(matched)-1; i < j; i, j = i+1, j-1 {
+		matched[i], matched[j] = matched[j], matched[i]
+	}
+	return matched
+}
+
+// MarkRecalled 将消息标记为已撤回，消息不在历史中时返回 false
+func (s *Store) MarkRecalled(messageID int32) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r, ok := s.byID[messageID]
+	if ok {
+		r.Recalled = true
+	}
+	return ok
+}
+
+// Len 返回当前保存的记录数
+func (s *Store) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.prune()
+	return s.size
+}
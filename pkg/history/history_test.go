@@ -0,0 +1,184 @@
+package history
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+var base = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+// newStore 创建当前时间固定为 *now 的消息历史
+func newStore(maxRecords int, maxAge time.Duration, now *time.Time) *Store {
+	s := New(maxRecords, maxAge)
+	s.now = func() time.Time { return *now }
+	return s
+}
+
+// at 返回 base 之后 sec 秒的群消息记录
+func at(id int32, sec int) Record {
+	return Record{MessageID: id, Time: base.Add(time.Duration(sec) * time.Second), GroupID: 100, RawMessage: "m"}
+}
+
+func ids(records []Record) []int32 {
+	var out []int32
+	for _, r := range records {
+		out = append(out, r.MessageID)
+	}
+	return out
+}
+
+func TestStoreCapacity(t *testing.T) {
+	tests := []struct {
+		name  string
+		add   []Record
+		want  []int32 // Query 结果
+		gone  []int32 // Get 查不到的消息
+		limit int
+	}{
+		{
+			name: "drops the earliest",
+			add:  []Record{at(1, 1), at(2, 2), at(3, 3), at(4, 4)},
+			want: []int32{2, 3, 4},
+			gone: []int32{1},
+		},
+		{
+			// 乱序到达时按 Time 丢弃，而不是按写入顺序
+			name: "drops the earliest by time",
+			add:  []Record{at(1, 3), at(2, 1), at(3, 2), at(4, 4)},
+			want: []int32{3, 1, 4},
+			gone: []int32{2},
+		},
+		{
+			// 已满时比所有记录都早的新记录直接丢弃
+			name: "too old to keep",
+			add:  []Record{at(1, 2), at(2, 3), at(3, 4), at(4, 1)},
+			want: []int32{1, 2, 3},
+			gone: []int32{4},
+		},
+		{
+			name:  "limit returns the latest",
+			add:   []Record{at(1, 1), at(2, 3), at(3, 2)},
+			want:  []int32{3, 2},
+			limit: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := base.Add(time.Minute)
+			s := newStore(3, 0, &now)
+			for _, rec := range tt.add {
+				s.Add(rec)
+			}
+			if got := ids(s.Query(Query{Limit: tt.limit})); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Query() = %v, want %v", got, tt.want)
+			}
+			for _, id := range tt.gone {
+				if _, ok := s.Get(id); ok {
+					t.Errorf("Get(%d) found a dropped record", id)
+				}
+			}
+			for _, id := range tt.want {
+				if _, ok := s.Get(id); !ok {
+					t.Errorf("Get(%d) missed", id)
+				}
+			}
+		})
+	}
+}
+
+func TestStoreMaxAge(t *testing.T) {
+	now := base.Add(10 * time.Second)
+	s := newStore(10, 5*time.Second, &now)
+
+	s.Add(at(1, 2)) // 已过期，不写入
+	s.Add(at(2, 8))
+	s.Add(at(3, 6)) // 上报时间较早的记录排在较新的记录之前
+	s.Add(at(4, 9))
+	if got := ids(s.Query(Query{})); !reflect.DeepEqual(got, []int32{3, 2, 4}) {
+		t.Fatalf("Query() = %v, want [3 2 4]", got)
+	}
+	if _, ok := s.Get(1); ok {
+		t.Error("Get(1) found an expired record")
+	}
+
+	// 时间推进后，较早的记录即使写入较晚也会被清理
+	now = base.Add(12 * time.Second)
+	if got := s.Len(); got != 2 {
+		t.Errorf("Len() = %d, want 2", got)
+	}
+	if _, ok := s.Get(3); ok {
+		t.Error("Get(3) found an expired record")
+	}
+	if got := ids(s.Query(Query{})); !reflect.DeepEqual(got, []int32{2, 4}) {
+		t.Errorf("Query() = %v, want [2 4]", got)
+	}
+
+	now = base.Add(time.Minute)
+	if got := s.Len(); got != 0 {
+		t.Errorf("Len() = %d, want 0", got)
+	}
+	if len(s.byID) != 0 {
+		t.Errorf("byID still holds %d expired records", len(s.byID))
+	}
+}
+
+func TestStoreDuplicateMessageID(t *testing.T) {
+	now := base.Add(time.Minute)
+	s := newStore(3, 0, &now)
+
+	first := at(1, 1)
+	first.RawMessage = "first"
+	second := at(1, 2)
+	second.RawMessage = "second"
+	s.Add(first)
+	s.Add(second)
+	if r, ok := s.Get(1); !ok || r.RawMessage != "second" {
+		t.Fatalf("Get(1) = %+v, %v, want the later record", r, ok)
+	}
+	if !s.MarkRecalled(1) {
+		t.Fatal("MarkRecalled(1) = false")
+	}
+	if r, _ := s.Get(1); !r.Recalled {
+		t.Error("the later record was not marked recalled")
+	}
+
+	// 丢弃较早的同 ID 记录不影响按 ID 查询较新的一条
+	s.Add(at(2, 3))
+	s.Add(at(3, 4))
+	if r, ok := s.Get(1); !ok || r.RawMessage != "second" {
+		t.Errorf("Get(1) after the first record was dropped = %+v, %v", r, ok)
+	}
+	s.Add(at(4, 5))
+	if _, ok := s.Get(1); ok {
+		t.Error("Get(1) found a record after both were dropped")
+	}
+}
+
+func TestQueryFilters(t *testing.T) {
+	now := base.Add(time.Minute)
+	s := newStore(10, 0, &now)
+	outgoing := true
+	s.Add(Record{MessageID: 1, Time: base, MessageType: "group", GroupID: 100, UserID: 10001})
+	s.Add(Record{MessageID: 2, Time: base.Add(time.Second), MessageType: "group", GroupID: 100, Outgoing: true})
+	s.Add(Record{MessageID: 3, Time: base.Add(2 * time.Second), MessageType: "private", UserID: 10001})
+	s.Add(Record{MessageID: 4, Time: base.Add(3 * time.Second), MessageType: "group", GroupID: 200, UserID: 10002})
+
+	tests := []struct {
+		name  string
+		query Query
+		want  []int32
+	}{
+		{name: "group", query: Query{GroupID: 100}, want: []int32{1, 2}},
+		{name: "user", query: Query{UserID: 10001}, want: []int32{1, 3}},
+		{name: "type", query: Query{MessageType: "private"}, want: []int32{3}},
+		{name: "outgoing", query: Query{Outgoing: &outgoing}, want: []int32{2}},
+		{name: "since and until", query: Query{Since: base.Add(time.Second), Until: base.Add(3 * time.Second)}, want: []int32{2, 3}},
+	}
+	for _, tt := range tests {
+		if got := ids(s.Query(tt.query)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: Query() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,361 @@
+// Package infocache 缓存群信息、群成员信息和好友列表
+//
+// 缓存按机器人（self_id）隔离，条目在 TTL 到期后失效；
+// 收到群成员增减、管理员变动和群名片变更通知时，对应条目被更新或失效。
+// 所有方法在接收者为 nil 时按未命中处理，未启用缓存的组件无需判断。
+package infocache
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	types "onebot-go2/pkg/const"
+)
+
+// 缓存条目的种类，用于命中统计
+const (
+	KindGroup      = "group"
+	KindGroupList  = "group_list"
+	KindMember     = "member"
+	KindMemberList = "member_list"
+	KindFriendList = "friend_list"
+)
+
+// Config 缓存配置
+type Config struct {
+	GroupTTL  time.Duration // 群信息和群列表的有效期
+	MemberTTL time.Duration // 群成员信息和群成员列表的有效期
+	FriendTTL time.Duration // 好友列表的有效期
+}
+
+// Counts 命中统计
+type Counts struct {
+	Hits   uint64 `json:"hits"`
+	Misses uint64 `json:"misses"`
+}
+
+// Cache 信息缓存
+type Cache struct {
+	config Config
+
+	mu    sync.Mutex
+	bots  map[int64]*botCache
+	stats map[string]*Counts
+}
+
+type entry[T any] struct {
+	value   T
+	expires time.Time
+}
+
+func (e entry[T]) valid(now time.Time) bool {
+	return now.Before(e.expires)
+}
+
+// botCache 一个机器人的缓存
+// 群成员列表只保存 user_id，成员信息统一保存在 members 中，
+// 这样通知更新某个成员时列表中的信息也随之更新
+type botCache struct {
+	groups      map[int64]entry[types.GroupInfo]
+	groupList   *entry[[]int64]
+	members     map[[2]int64]entry[types.GroupMemberInfo]
+	memberLists map[int64]entry[[]int64]
+	friendList  *entry[[]types.FriendInfo]
+}
+
+// New 创建缓存
+func New(config Config) *Cache {
+	return &Cache{
+		config: config,
+		bots:   make(map[int64]*botCache),
+		stats:  make(map[string]*Counts),
+	}
+}
+
+// bot 返回机器人的缓存，调用时需持有 c.mu
+func (c *Cache) bot(selfID int64) *botCache {
+	b, ok := c.bots[selfID]
+	if !ok {
+		b = &botCache{
+			groups:      make(map[int64]entry[types.GroupInfo]),
+			members:     make(map[[2]int64]entry[types.GroupMemberInfo]),
+			memberLists: make(map[int64]entry[[]int64]),
+		}
+		c.bots[selfID] = b
+	}
+	return b
+}
+
+// count 记录一次查询，调用时需持有 c.mu
+func (c *Cache) count(kind string, hit bool) bool {
+	counts, ok := c.stats[kind]
+	if !ok {
+		counts = &Counts{}
+		c.stats[kind] = counts
+	}
+	if hit {
+		counts.Hits++
+	} else {
+		counts.Misses++
+	}
+	return hit
+}
+
+// Group 返回缓存的群信息
+func (c *Cache) Group(selfID, groupID int64) (types.GroupInfo, bool) {
+	if c == nil {
+		return types.GroupInfo{}, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.bot(selfID).groups[groupID]
+	if !c.count(KindGroup, ok && e.valid(time.Now())) {
+		return types.GroupInfo{}, false
+	}
+	return e.value, true
+}
+
+// SetGroup 缓存群信息
+func (c *Cache) SetGroup(selfID int64, info types.GroupInfo) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.bot(selfID).groups[info.GroupID] = entry[types.GroupInfo]{info, time.Now().Add(c.config.GroupTTL)}
+}
+
+// GroupList 返回缓存的群列表
+func (c *Cache) GroupList(selfID int64) ([]types.GroupInfo, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	b := c.bot(selfID)
+	now := time.Now()
+	if b.groupList == nil || !b.groupList.valid(now) {
+		c.count(KindGroupList, false)
+		return nil, false
+	}
+	list := make([]types.GroupInfo, 0, len(b.groupList.value))
+	for _, groupID := range b.groupList.value {
+		e, ok := b.groups[groupID]
+		if !ok || !e.valid(now) {
+			c.count(KindGroupList, false)
+			return nil, false
+		}
+		list = append(list, e.value)
+	}
+	c.count(KindGroupList, true)
+	return list, true
+}
+
+// SetGroupList 缓存群列表，同时缓存列表中每个群的信息
+func (c *Cache) SetGroupList(selfID int64, list []types.GroupInfo) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	b := c.bot(selfID)
+	expires := time.Now().Add(c.config.GroupTTL)
+	ids := make([]int64, len(list))
+	for i, info := range list {
+		ids[i] = info.GroupID
+		b.groups[info.GroupID] = entry[types.GroupInfo]{info, expires}
+	}
+	b.groupList = &entry[[]int64]{ids, expires}
+}
+
+// Member 返回缓存的群成员信息
+func (c *Cache) Member(selfID, groupID, userID int64) (types.GroupMemberInfo, bool) {
+	if c == nil {
+		return types.GroupMemberInfo{}, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.bot(selfID).members[[2]int64{groupID, userID}]
+	if !c.count(KindMember, ok && e.valid(time.Now())) {
+		return types.GroupMemberInfo{}, false
+	}
+	return e.value, true
+}
+
+// SetMember 缓存群成员信息
+func (c *Cache) SetMember(selfID int64, info types.GroupMemberInfo) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := [2]int64{info.GroupID, info.UserID}
+	c.bot(selfID).members[key] = entry[types.GroupMemberInfo]{info, time.Now().Add(c.config.MemberTTL)}
+}
+
+// MemberList 返回缓存的群成员列表
+// 列表中任一成员的信息已失效时按未命中处理
+func (c *Cache) MemberList(selfID, groupID int64) ([]types.GroupMemberInfo, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	b := c.bot(selfID)
+	now := time.Now()
+	ids, ok := b.memberLists[groupID]
+	if !ok || !ids.valid(now) {
+		c.count(KindMemberList, false)
+		return nil, false
+	}
+	list := make([]types.GroupMemberInfo, 0, len(ids.value))
+	for _, userID := range ids.value {
+		e, ok := b.members[[2]int64{groupID, userID}]
+		if !ok || !e.valid(now) {
+			c.count(KindMemberList, false)
+			return nil, false
+		}
+		list = append(list, e.value)
+	}
+	c.count(KindMemberList, true)
+	return list, true
+}
+
+// SetMemberList 缓存群成员列表，同时缓存列表中每个成员的信息
+func (c *Cache) SetMemberList(selfID, groupID int64, list []types.GroupMemberInfo) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	b := c.bot(selfID)
+	expires := time.Now().Add(c.config.MemberTTL)
+	ids := make([]int64, len(list))
+	for i, info := range list {
+		ids[i] = info.UserID
+		b.members[[2]int64{groupID, info.UserID}] = entry[types.GroupMemberInfo]{info, expires}
+	}
+	b.memberLists[groupID] = entry[[]int64]{ids, expires}
+}
+
+// FriendList 返回缓存的好友列表
+func (c *Cache) FriendList(selfID int64) ([]types.FriendInfo, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e := c.bot(selfID).friendList
+	if !c.count(KindFriendList, e != nil && e.valid(time.Now())) {
+		return nil, false
+	}
+	return append([]types.FriendInfo(nil), e.value...), true
+}
+
+// SetFriendList 缓存好友列表
+func (c *Cache) SetFriendList(selfID int64, list []types.FriendInfo) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	list = append([]types.FriendInfo(nil), list...)
+	c.bot(selfID).friendList = &entry[[]types.FriendInfo]{list, time.Now().Add(c.config.FriendTTL)}
+}
+
+// Reset 清空机器人的缓存，机器人重新连接时调用
+func (c *Cache) Reset(selfID int64) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.bots, selfID)
+}
+
+// Observe 根据通知更新缓存
+//   - group_increase：群成员数变化，群信息、群列表和群成员列表失效
+//   - group_decrease：删除该成员，群信息、群列表和群成员列表失效；机器人被踢出或退群时删除整个群
+//   - group_admin：更新成员的角色
+//   - group_card：更新成员的群名片
+//   - friend_add：好友列表失效
+func (c *Cache) Observe(selfID int64, evt interface{}) {
+	notice, ok := evt.(*types.NoticeEvent)
+	if c == nil || !ok {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	b := c.bot(selfID)
+	key := [2]int64{notice.GroupID, notice.UserID}
+
+	switch notice.NoticeType {
+	case "group_increase":
+		b.invalidateGroup(notice.GroupID)
+	case "group_decrease":
+		b.invalidateGroup(notice.GroupID)
+		delete(b.members, key)
+		if notice.SubType == "kick_me" || notice.UserID == selfID {
+			for k := range b.members {
+				if k[0] == notice.GroupID {
+					delete(b.members, k)
+				}
+			}
+		}
+	case "group_admin":
+		if e, ok := b.members[key]; ok {
+			e.value.Role = "member"
+			if notice.SubType == "set" {
+				e.value.Role = "admin"
+			}
+			b.members[key] = e
+		}
+	case "group_card":
+		if e, ok := b.members[key]; ok {
+			e.value.Card = notice.CardNew
+			b.members[key] = e
+		}
+	case "friend_add":
+		b.friendList = nil
+	}
+}
+
+// invalidateGroup 群信息、群列表和群成员列表失效
+func (b *botCache) invalidateGroup(groupID int64) {
+	delete(b.groups, groupID)
+	delete(b.memberLists, groupID)
+	b.groupList = nil
+}
+
+// Stats 返回各种条目的命中统计，键为 Kind 常量
+func (c *Cache) Stats() map[string]Counts {
+	stats := make(map[string]Counts)
+	if c == nil {
+		return stats
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for kind, counts := range c.stats {
+		stats[kind] = *counts
+	}
+	return stats
+}
+
+type noCacheKey struct{}
+
+// WithNoCache 返回跳过缓存的 context
+// 通过绑定该 context 的 Server（Server.WithContext）发起的查询直接调用 API，结果仍会写入缓存，
+// 用于没有 noCache 参数的 get_group_list、get_group_member_list 和 get_friend_list
+func WithNoCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noCacheKey{}, true)
+}
+
+// NoCache 判断 ctx 是否要求跳过缓存
+func NoCache(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+	noCache, _ := ctx.Value(noCacheKey{}).(bool)
+	return noCache
+}
@@ -0,0 +1,246 @@
+package infocache
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	types "onebot-go2/pkg/const"
+)
+
+const selfID = 10000
+
+func notice(noticeType, subType string, groupID, userID int64) *types.NoticeEvent {
+	return &types.NoticeEvent{NoticeType: noticeType, SubType: subType, GroupID: groupID, UserID: userID}
+}
+
+// filled 返回缓存了群 100、200 的信息、群列表和成员列表的缓存
+// 群 100 的成员为 10001（管理员）和 10002，群 200 的成员为 10001
+func filled() *Cache {
+	c := New(Config{GroupTTL: time.Hour, MemberTTL: time.Hour, FriendTTL: time.Hour})
+	c.SetGroupList(selfID, []types.GroupInfo{{GroupID: 100, GroupName: "a"}, {GroupID: 200, GroupName: "b"}})
+	c.SetMemberList(selfID, 100, []types.GroupMemberInfo{
+		{GroupID: 100, UserID: 10001, Card: "一号", Role: "admin"},
+		{GroupID: 100, UserID: 10002, Card: "二号", Role: "member"},
+	})
+	c.SetMemberList(selfID, 200, []types.GroupMemberInfo{{GroupID: 200, UserID: 10001, Role: "member"}})
+	c.SetFriendList(selfID, []types.FriendInfo{{UserID: 10001}})
+	return c
+}
+
+// cached 缓存中仍有效的条目
+type cached struct {
+	group100, groupList, memberList100, memberList200, friendList bool
+	members                                                       map[[2]int64]types.GroupMemberInfo
+}
+
+func snapshot(c *Cache) cached {
+	var s cached
+	_, s.group100 = c.Group(selfID, 100)
+	_, s.groupList = c.GroupList(selfID)
+	_, s.memberList100 = c.MemberList(selfID, 100)
+	_, s.memberList200 = c.MemberList(selfID, 200)
+	_, s.friendList = c.FriendList(selfID)
+	s.members = make(map[[2]int64]types.GroupMemberInfo)
+	for _, key := range [][2]int64{{100, 10001}, {100, 10002}, {200, 10001}} {
+		if m, ok := c.Member(selfID, key[0], key[1]); ok {
+			s.members[key] = m
+		}
+	}
+	return s
+}
+
+func TestObserve(t *testing.T) {
+	admin := types.GroupMemberInfo{GroupID: 100, UserID: 10001, Card: "一号", Role: "admin"}
+	member := types.GroupMemberInfo{GroupID: 100, UserID: 10002, Card: "二号", Role: "member"}
+	other := types.GroupMemberInfo{GroupID: 200, UserID: 10001, Role: "member"}
+	with := func(m types.GroupMemberInfo, f func(*types.GroupMemberInfo)) types.GroupMemberInfo {
+		f(&m)
+		return m
+	}
+
+	tests := []struct {
+		name   string
+		selfID int64
+		evt    interface{}
+		want   cached
+	}{
+		{
+			name: "group_increase invalidates the group",
+			evt:  notice("group_increase", "approve", 100, 10003),
+			want: cached{memberList200: true, friendList: true, members: map[[2]int64]types.GroupMemberInfo{
+				{100, 10001}: admin, {100, 10002}: member, {200, 10001}: other,
+			}},
+		},
+		{
+			name: "group_decrease removes the member",
+			evt:  notice("group_decrease", "leave", 100, 10002),
+			want: cached{memberList200: true, friendList: true, members: map[[2]int64]types.GroupMemberInfo{
+				{100, 10001}: admin, {200, 10001}: other,
+			}},
+		},
+		{
+			// 机器人被踢出：删除整个群的成员，其他群不受影响
+			name: "group_decrease kick_me drops the group",
+			evt:  notice("group_decrease", "kick_me", 100, selfID),
+			want: cached{memberList200: true, friendList: true, members: map[[2]int64]types.GroupMemberInfo{
+				{200, 10001}: other,
+			}},
+		},
+		{
+			name: "group_decrease of the bot itself drops the group",
+			evt:  notice("group_decrease", "leave", 100, selfID),
+			want: cached{memberList200: true, friendList: true, members: map[[2]int64]types.GroupMemberInfo{
+				{200, 10001}: other,
+			}},
+		},
+		{
+			name: "group_admin set",
+			evt:  notice("group_admin", "set", 100, 10002),
+			want: cached{group100: true, groupList: true, memberList100: true, memberList200: true, friendList: true,
+				members: map[[2]int64]types.GroupMemberInfo{
+					{100, 10001}: admin,
+					{100, 10002}: with(member, func(m *types.GroupMemberInfo) { m.Role = "admin" }),
+					{200, 10001}: other,
+				}},
+		},
+		{
+			// 只更新对应群的成员
+			name: "group_admin unset",
+			evt:  notice("group_admin", "unset", 100, 10001),
+			want: cached{group100: true, groupList: true, memberList100: true, memberList200: true, friendList: true,
+				members: map[[2]int64]types.GroupMemberInfo{
+					{100, 10001}: with(admin, func(m *types.GroupMemberInfo) { m.Role = "member" }),
+					{100, 10002}: member,
+					{200, 10001}: other,
+				}},
+		},
+		{
+			name: "group_card",
+			evt: &types.NoticeEvent{NoticeType: "group_card", GroupID: 100, UserID: 10001,
+				CardOld: "一号", CardNew: "新名片"},
+			want: cached{group100: true, groupList: true, memberList100: true, memberList200: true, friendList: true,
+				members: map[[2]int64]types.GroupMemberInfo{
+					{100, 10001}: with(admin, func(m *types.GroupMemberInfo) { m.Card = "新名片" }),
+					{100, 10002}: member,
+					{200, 10001}: other,
+				}},
+		},
+		{
+			// 未缓存的成员不会被创建
+			name: "group_card of an uncached member",
+			evt:  &types.NoticeEvent{NoticeType: "group_card", GroupID: 100, UserID: 10009, CardNew: "x"},
+			want: cached{group100: true, groupList: true, memberList100: true, memberList200: true, friendList: true,
+				members: map[[2]int64]types.GroupMemberInfo{{100, 10001}: admin, {100, 10002}: member, {200, 10001}: other}},
+		},
+		{
+			name: "friend_add",
+			evt:  notice("friend_add", "", 0, 10003),
+			want: cached{group100: true, groupList: true, memberList100: true, memberList200: true,
+				members: map[[2]int64]types.GroupMemberInfo{{100, 10001}: admin, {100, 10002}: member, {200, 10001}: other}},
+		},
+		{
+			// 其他机器人的通知不影响本机器人的缓存
+			name:   "other bot",
+			selfID: 20000,
+			evt:    notice("group_decrease", "kick_me", 100, 20000),
+			want: cached{group100: true, groupList: true, memberList100: true, memberList200: true, friendList: true,
+				members: map[[2]int64]types.GroupMemberInfo{{100, 10001}: admin, {100, 10002}: member, {200, 10001}: other}},
+		},
+		{
+			name: "not a notice",
+			evt:  &types.MessageEvent{GroupID: 100, UserID: 10001},
+			want: cached{group100: true, groupList: true, memberList100: true, memberList200: true, friendList: true,
+				members: map[[2]int64]types.GroupMemberInfo{{100, 10001}: admin, {100, 10002}: member, {200, 10001}: other}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := filled()
+			observer := tt.selfID
+			if observer == 0 {
+				observer = selfID
+			}
+			c.Observe(observer, tt.evt)
+			if got := snapshot(c); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("cache after Observe =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestObserveUpdatesMemberList(t *testing.T) {
+	c := filled()
+	c.Observe(selfID, &types.NoticeEvent{NoticeType: "group_card", GroupID: 100, UserID: 10002, CardNew: "新"})
+	list, ok := c.MemberList(selfID, 100)
+	if !ok || len(list) != 2 || list[1].Card != "新" {
+		t.Errorf("MemberList() = %v, %v, want the updated card", list, ok)
+	}
+}
+
+func TestExpiry(t *testing.T) {
+	c := New(Config{GroupTTL: 20 * time.Millisecond, MemberTTL: time.Hour, FriendTTL: 20 * time.Millisecond})
+	c.SetGroupList(selfID, []types.GroupInfo{{GroupID: 100}})
+	c.SetFriendList(selfID, []types.FriendInfo{{UserID: 10001}})
+	if _, ok := c.GroupList(selfID); !ok {
+		t.Fatal("GroupList() missed before expiry")
+	}
+	time.Sleep(30 * time.Millisecond)
+	if _, ok := c.Group(selfID, 100); ok {
+		t.Error("Group() hit after expiry")
+	}
+	if _, ok := c.GroupList(selfID); ok {
+		t.Error("GroupList() hit after expiry")
+	}
+	if _, ok := c.FriendList(selfID); ok {
+		t.Error("FriendList() hit after expiry")
+	}
+}
+
+func TestMemberListMissesWhenAnyMemberExpired(t *testing.T) {
+	c := filled()
+	if _, ok := c.MemberList(selfID, 100); !ok {
+		t.Fatal("MemberList() missed before expiry")
+	}
+
+	// 列表本身仍有效，只有一个成员的信息过期
+	b := c.bots[selfID]
+	key := [2]int64{100, 10002}
+	e := b.members[key]
+	e.expires = time.Now().Add(-time.Second)
+	b.members[key] = e
+
+	if _, ok := c.MemberList(selfID, 100); ok {
+		t.Error("MemberList() hit with an expired member")
+	}
+	if _, ok := c.Member(selfID, 100, 10001); !ok {
+		t.Error("Member() of a valid member missed")
+	}
+	if _, ok := c.MemberList(selfID, 200); !ok {
+		t.Error("MemberList() of another group missed")
+	}
+
+	// 重新缓存该成员后列表恢复命中
+	c.SetMember(selfID, types.GroupMemberInfo{GroupID: 100, UserID: 10002})
+	if _, ok := c.MemberList(selfID, 100); !ok {
+		t.Error("MemberList() missed after the member was refreshed")
+	}
+
+	want := Counts{Hits: 3, Misses: 1}
+	if got := c.Stats()[KindMemberList]; got != want {
+		t.Errorf("Stats()[member_list] = %+v, want %+v", got, want)
+	}
+}
+
+func TestNilCache(t *testing.T) {
+	var c *Cache
+	c.SetGroup(selfID, types.GroupInfo{GroupID: 100})
+	c.Observe(selfID, notice("group_increase", "approve", 100, 10001))
+	if _, ok := c.Group(selfID, 100); ok {
+		t.Error("nil cache hit")
+	}
+	if stats := c.Stats(); len(stats) != 0 {
+		t.Errorf("Stats() = %v, want empty", stats)
+	}
+}
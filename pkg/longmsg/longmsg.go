@@ -0,0 +1,338 @@
+// Package longmsg 处理超长的发出消息
+//
+// QQ 会拒绝或截断过长的文本。注册为发出消息的拦截器后，消息中文本的长度（字符数）超过 MaxLength 时：
+//   - ModeSplit：在行或消息段边界拆分为多条消息依次发送，图片、@ 等消息段不会被拆开，
+//     回复消息段只保留在第一条；拆分后超过 MaxParts 条时改为合并转发
+//   - ModeForward：转换为合并转发消息（node 消息段），每个节点的文本同样不超过 MaxLength，
+//     通过 send_group_forward_msg / send_private_forward_msg 发送；发送失败（实现不支持合并转发等）时改为拆分发送
+//
+// 默认处理方式来自配置，单次发送可以通过 WithMode 指定：
+//
+//	ctx.Context = longmsg.WithMode(ctx.Context, longmsg.ModeForward)
+//	ctx.ReplyText(helpText)
+package longmsg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	types "onebot-go2/pkg/const"
+	"onebot-go2/pkg/message"
+	"onebot-go2/pkg/outbound"
+)
+
+// Mode 超长消息的处理方式
+type Mode string
+
+const (
+	ModeOff     Mode = "off"     // 不处理，原样发送
+	ModeSplit   Mode = "split"   // 拆分为多条消息
+	ModeForward Mode = "forward" // 转换为合并转发消息
+)
+
+// Valid 是否为有效的处理方式
+func (m Mode) Valid() bool {
+	switch m {
+	case ModeOff, ModeSplit, ModeForward:
+		return true
+	}
+	return false
+}
+
+type modeKey struct{}
+
+// WithMode 返回携带超长消息处理方式的 context
+// 通过绑定该 context 的 Server 发出的消息按该方式处理，覆盖配置中的 mode
+func WithMode(ctx context.Context, mode Mode) context.Context {
+	return context.WithValue(ctx, modeKey{}, mode)
+}
+
+// ModeOf 返回 ctx 中的处理方式，未设置时 ok 为 false
+func ModeOf(ctx context.Context) (mode Mode, ok bool) {
+	if ctx == nil {
+		return "", false
+	}
+	mode, ok = ctx.Value(modeKey{}).(Mode)
+	return mode, ok
+}
+
+// Config 超长消息处理配置
+type Config struct {
+	Mode            Mode   // 默认处理方式
+	MaxLength       int    // 每条消息文本的最大字符数，0 表示不限制
+	MaxParts        int    // 拆分的最大条数，超过时改为合并转发，0 表示不限制
+	ForwardNickname string // 合并转发节点显示的昵称，为空时使用机器人昵称
+}
+
+// Splitter 超长消息处理器
+type Splitter struct {
+	mu       sync.RWMutex
+	config   Config
+	nickname func(selfID int64) string
+	observer func(mode string)
+}
+
+// New 创建超长消息处理器
+func New(config Config) *Splitter {
+	return &Splitter{config: config}
+}
+
+// SetConfig 替换配置，用于热重载
+func (s *Splitter) SetConfig(config Config) {
+	s.mu.Lock()
+	s.config = config
+	s.mu.Unlock()
+}
+
+// SetNickname 设置获取机器人昵称的函数，用于合并转发节点
+func (s *Splitter) SetNickname(nickname func(selfID int64) string) *Splitter {
+	s.mu.Lock()
+	s.nickname = nickname
+	s.mu.Unlock()
+	return s
+}
+
+// SetObserver 设置每条超长消息最终处理方式（split 或 forward）的回调，用于导出指标
+// 合并转发失败后改为拆分发送的记为 split
+func (s *Splitter) SetObserver(observer func(mode string)) *Splitter {
+	s.mu.Lock()
+	s.observer = observer
+	s.mu.Unlock()
+	return s
+}
+
+// Interceptor 返回发出消息的拦截器
+// 应在发送限流之前注册，使拆分后的每条消息分别排队
+func (s *Splitter) Interceptor() outbound.Interceptor {
+	return func(next outbound.SendFunc) outbound.SendFunc {
+		return func(msg *outbound.Message) (*types.SendMessageResponse, error) {
+			if !msg.IsSend() {
+				return next(msg)
+			}
+			s.mu.RLock()
+			config, nickname, observer := s.config, s.nickname, s.observer
+			s.mu.RUnlock()
+
+			mode := config.Mode
+			if m, ok := ModeOf(msg); ok {
+				mode = m
+			}
+			if mode == ModeOff || mode == "" || config.MaxLength <= 0 ||
+				Length(msg.Params.Message) <= config.MaxLength || isForward(msg.Params.Message) {
+				return next(msg)
+			}
+
+			parts := Split(msg.Params.Message, config.MaxLength)
+			if mode == ModeSplit && config.MaxParts > 0 && len(parts) > config.MaxParts {
+				mode = ModeForward
+			}
+
+			if mode == ModeForward {
+				name := config.ForwardNickname
+				if name == "" && nickname != nil {
+					name = nickname(msg.SelfID)
+				}
+				if name == "" {
+					name = strconv.FormatInt(msg.SelfID, 10)
+				}
+				resp, err := sendForward(next, msg, Forward(parts, msg.SelfID, name))
+				// 被拦截器拒绝或 context 已取消时拆分发送同样会失败
+				if err == nil || errors.Is(err, outbound.ErrVetoed) || (msg.Context != nil && msg.Err() != nil) {
+					observe(observer, ModeForward)
+					return resp, err
+				}
+			}
+			observe(observer, ModeSplit)
+			return sendParts(next, msg, parts)
+		}
+	}
+}
+
+// observe 记录超长消息最终的处理方式
+func observe(observer func(mode string), mode Mode) {
+	if observer != nil {
+		observer(string(mode))
+	}
+}
+
+// sendForward 通过合并转发 action 发送 node 消息段
+func sendForward(next outbound.SendFunc, msg *outbound.Message, nodes types.MessageArray) (*types.SendMessageResponse, error) {
+	forward := *msg
+	forward.Action = types.ActionSendPrivateForwardMsg
+	if msg.IsGroup() {
+		forward.Action = types.ActionSendGroupForwardMsg
+	}
+	forward.Params.Message = nodes
+	forward.Params.AutoEscape = false
+	return next(&forward)
+}
+
+// sendParts 依次发送拆分后的消息，返回第一条的消息 ID
+// 某一条发送失败时停止发送，已发送的消息不会撤回
+func sendParts(next outbound.SendFunc, msg *outbound.Message, parts []types.MessageArray) (*types.SendMessageResponse, error) {
+	var first *types.SendMessageResponse
+	for i, part := range parts {
+		partMsg := *msg
+		partMsg.Params.Message = part
+		resp, err := next(&partMsg)
+		if err != nil {
+			return first, fmt.Errorf("send part %d of %d: %w", i+1, len(parts), err)
+		}
+		if first == nil {
+			first = resp
+		}
+	}
+	return first, nil
+}
+
+// Length 返回消息中文本消息段的字符数，其他消息段不计入
+func Length(messages types.MessageArray) int {
+	length := 0
+	for _, msg := range messages {
+		length += utf8.RuneCountInString(textOf(msg))
+	}
+	return length
+}
+
+// Split 将消息拆分为文本不超过 maxLength 个字符的多条消息
+// 优先在换行和消息段边界拆分，单行超长时才在行内拆分；非文本消息段保持完整，
+// 回复消息段只保留在第一条消息中。maxLength 不大于 0 或未超长时返回原消息
+func Split(messages types.MessageArray, maxLength int) []types.MessageArray {
+	if maxLength <= 0 || Length(messages) <= maxLength {
+		return []types.MessageArray{messages}
+	}
+
+	s := &splitter{max: maxLength}
+	var reply *types.Message
+	for i, msg := range messages {
+		switch msg.Type {
+		case "reply":
+			if reply == nil {
+				reply = &messages[i]
+			}
+		case "text":
+			for _, line := range strings.SplitAfter(textOf(msg), "\n") {
+				s.addLine(line)
+			}
+		default:
+			s.flushText()
+			s.current = append(s.current, msg)
+		}
+	}
+	s.flushPart()
+
+	if reply != nil && len(s.parts) > 0 {
+		s.parts[0] = append(types.MessageArray{*reply}, s.parts[0]...)
+	}
+	return s.parts
+}
+
+// Forward 将拆分后的消息转换为合并转发消息，每条消息为一个节点
+// 回复消息段在合并转发中没有意义，会被移除
+func Forward(parts []types.MessageArray, userID int64, nickname string) types.MessageArray {
+	builder := message.NewBuilder()
+	for _, part := range parts {
+		content := make(types.MessageArray, 0, len(part))
+		for _, msg := range part {
+			if msg.Type != "reply" {
+				content = append(content, msg)
+			}
+		}
+		if len(content) > 0 {
+			builder.Node(userID, nickname, content)
+		}
+	}
+	return builder.Build()
+}
+
+// splitter 拆分过程中的状态
+type splitter struct {
+	max     int
+	parts   []types.MessageArray
+	current types.MessageArray // 正在构造的消息
+	text    strings.Builder    // 尚未加入 current 的文本
+	length  int                // current 和 text 的文本字符数
+}
+
+// addLine 添加一行文本（包含结尾的换行），放不下时开始新的消息
+func (s *splitter) addLine(line string) {
+	n := utf8.RuneCountInString(line)
+	if n == 0 {
+		return
+	}
+	if s.length > 0 && s.length+n > s.max {
+		s.flushPart()
+	}
+	// 单行超长时按字符拆分
+	for n > s.max {
+		runes := []rune(line)
+		s.text.WriteString(string(runes[:s.max]))
+		s.length += s.max
+		s.flushPart()
+		line = string(runes[s.max:])
+		n -= s.max
+	}
+	s.text.WriteString(line)
+	s.length += n
+}
+
+// flushText 将累积的文本作为一个文本消息段加入当前消息
+func (s *splitter) flushText() {
+	if s.text.Len() == 0 {
+		return
+	}
+	s.current = append(s.current, textSegment(s.text.String()))
+	s.text.Reset()
+}
+
+// flushPart 结束当前消息，去掉首尾的换行，空消息不保留
+func (s *splitter) flushPart() {
+	s.flushText()
+	part := s.current
+	if len(part) > 0 && part[0].Type == "text" {
+		part[0] = textSegment(strings.TrimLeft(textOf(part[0]), "\n"))
+	}
+	if last := len(part) - 1; last >= 0 && part[last].Type == "text" {
+		part[last] = textSegment(strings.TrimRight(textOf(part[last]), "\n"))
+	}
+	trimmed := part[:0]
+	for _, msg := range part {
+		if msg.Type != "text" || textOf(msg) != "" {
+			trimmed = append(trimmed, msg)
+		}
+	}
+	if len(trimmed) > 0 {
+		s.parts = append(s.parts, trimmed)
+	}
+	s.current = nil
+	s.length = 0
+}
+
+// isForward 消息是否已经是合并转发消息
+func isForward(messages types.MessageArray) bool {
+	for _, msg := range messages {
+		if msg.Type == "node" || msg.Type == "forward" {
+			return true
+		}
+	}
+	return false
+}
+
+// textOf 返回文本消息段的内容，其他消息段返回空字符串
+func textOf(msg types.Message) string {
+	if msg.Type != "text" {
+		return ""
+	}
+	text, _ := msg.Data["text"].(string)
+	return text
+}
+
+// textSegment 创建文本消息段
+func textSegment(text string) types.Message {
+	return message.Text(text)[0]
+}
@@ -0,0 +1,379 @@
+package longmsg
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	types "onebot-go2/pkg/const"
+	"onebot-go2/pkg/message"
+	"onebot-go2/pkg/outbound"
+)
+
+// render 将消息转换为便于比较的字符串，非文本消息段显示为 [type]
+func render(messages types.MessageArray) string {
+	var sb strings.Builder
+	for _, msg := range messages {
+		if msg.Type == "text" {
+			sb.WriteString(textOf(msg))
+		} else {
+			sb.WriteString("[" + msg.Type + "]")
+		}
+	}
+	return sb.String()
+}
+
+func renderParts(parts []types.MessageArray) []string {
+	out := make([]string, len(parts))
+	for i, part := range parts {
+		out[i] = render(part)
+	}
+	return out
+}
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		name     string
+		messages types.MessageArray
+		max      int
+		want     []string
+	}{
+		{
+			name:     "under limit",
+			messages: message.Text("abc"),
+			max:      5,
+			want:     []string{"abc"},
+		},
+		{
+			name:     "no limit",
+			messages: message.Text(strings.Repeat("a", 100)),
+			max:      0,
+			want:     []string{strings.Repeat("a", 100)},
+		},
+		{
+			name:     "split at newlines",
+			messages: message.Text("aaa\nbbb\nccc"),
+			max:      5,
+			want:     []string{"aaa", "bbb", "ccc"},
+		},
+		{
+			name:     "lines are packed",
+			messages: message.Text("a\nb\nc\nd\ne"),
+			max:      4,
+			want:     []string{"a\nb", "c\nd", "e"},
+		},
+		{
+			name:     "long line split by characters",
+			messages: message.Text("abcdefghijkl"),
+			max:      5,
+			want:     []string{"abcde", "fghij", "kl"},
+		},
+		{
+			name:     "counts runes not bytes",
+			messages: message.Text("一二三四五六七"),
+			max:      5,
+			want:     []string{"一二三四五", "六七"},
+		},
+		{
+			name:     "blank lines are dropped at boundaries",
+			messages: message.Text("aaa\n\n\nbbb"),
+			max:      4,
+			want:     []string{"aaa", "bbb"},
+		},
+		{
+			name:     "non-text segments stay whole",
+			messages: message.NewBuilder().Text("aaaa").Image("a.png").Text("bbbb").Build(),
+			max:      5,
+			want:     []string{"aaaa[image]", "bbbb"},
+		},
+		{
+			name:     "non-text segments do not count",
+			messages: message.NewBuilder().At(1).Text("abc").Image("a.png").Text("de").Build(),
+			max:      5,
+			want:     []string{"[at]abc[image]de"},
+		},
+		{
+			name:     "reply only in first part",
+			messages: message.NewBuilder().Reply(1).Text("abcdefgh").Build(),
+			max:      5,
+			want:     []string{"[reply]abcde", "fgh"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parts := Split(tt.messages, tt.max)
+			if got := renderParts(parts); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Split() = %q, want %q", got, tt.want)
+			}
+			for i, part := range parts {
+				if tt.max > 0 && Length(part) > tt.max {
+					t.Errorf("part %d has %d characters, want at most %d", i, Length(part), tt.max)
+				}
+			}
+		})
+	}
+}
+
+func TestForward(t *testing.T) {
+	parts := []types.MessageArray{
+		message.NewBuilder().Reply(1).Text("abc").Build(),
+		message.Reply(2, "")[:1], // 只有回复消息段的节点被省略
+		message.Text("def"),
+	}
+	nodes := Forward(parts, 10001, "bot")
+	if len(nodes) != 2 {
+		t.Fatalf("Forward() returned %d nodes, want 2", len(nodes))
+	}
+	for i, want := range []string{"abc", "def"} {
+		node := nodes[i]
+		if node.Type != "node" || node.Data["user_id"] != "10001" || node.Data["nickname"] != "bot" {
+			t.Errorf("node %d = %v", i, node.Data)
+		}
+		if got := render(node.Data["content"].(types.MessageArray)); got != want {
+			t.Errorf("node %d content = %q, want %q", i, got, want)
+		}
+	}
+}
+
+func TestInterceptor(t *testing.T) {
+	long := strings.Repeat("a", 12) // 拆分为 3 条
+
+	tests := []struct {
+		name     string
+		config   Config
+		ctxMode  Mode
+		msg      outbound.Message
+		want     []string // 传给下一个拦截器的每条消息
+		observed []string
+	}{
+		{
+			name:   "short message untouched",
+			config: Config{Mode: ModeSplit, MaxLength: 5},
+			msg:    outbound.Message{Action: types.ActionSendGroupMsg, Params: types.SendMessageParams{Message: message.Text("abc")}},
+			want:   []string{"abc"},
+		},
+		{
+			name:     "split",
+			config:   Config{Mode: ModeSplit, MaxLength: 5},
+			msg:      outbound.Message{Action: types.ActionSendGroupMsg, Params: types.SendMessageParams{Message: message.Text(long)}},
+			want:     []string{"aaaaa", "aaaaa", "aa"},
+			observed: []string{"split"},
+		},
+		{
+			name:     "too many parts falls back to forward",
+			config:   Config{Mode: ModeSplit, MaxLength: 5, MaxParts: 2},
+			msg:      outbound.Message{Action: types.ActionSendGroupMsg, SelfID: 10001, Params: types.SendMessageParams{Message: message.Text(long)}},
+			want:     []string{"[node][node][node]"},
+			observed: []string{"forward"},
+		},
+		{
+			name:     "context mode overrides config",
+			config:   Config{Mode: ModeSplit, MaxLength: 5},
+			ctxMode:  ModeForward,
+			msg:      outbound.Message{Action: types.ActionSendGroupMsg, Params: types.SendMessageParams{Message: message.Text(long)}},
+			want:     []string{"[node][node][node]"},
+			observed: []string{"forward"},
+		},
+		{
+			name:    "context mode off",
+			config:  Config{Mode: ModeSplit, MaxLength: 5},
+			ctxMode: ModeOff,
+			msg:     outbound.Message{Action: types.ActionSendGroupMsg, Params: types.SendMessageParams{Message: message.Text(long)}},
+			want:    []string{long},
+		},
+		{
+			name:   "forward messages are not wrapped again",
+			config: Config{Mode: ModeForward, MaxLength: 5},
+			msg: outbound.Message{Action: types.ActionSendGroupMsg, Params: types.SendMessageParams{
+				Message: message.NewBuilder().Node(1, "a", message.Text(long)).Build(),
+			}},
+			want: []string{"[node]"},
+		},
+		{
+			name:   "delete passes through",
+			config: Config{Mode: ModeSplit, MaxLength: 5},
+			msg:    outbound.Message{Action: types.ActionDeleteMsg, MessageID: 1},
+			want:   []string{""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var observed []string
+			s := New(tt.config).SetObserver(func(mode string) { observed = append(observed, mode) })
+
+			var sent []string
+			next := func(msg *outbound.Message) (*types.SendMessageResponse, error) {
+				sent = append(sent, render(msg.Params.Message))
+				return &types.SendMessageResponse{MessageID: int32(len(sent))}, nil
+			}
+
+			msg := tt.msg
+			msg.Context = context.Background()
+			if tt.ctxMode != "" {
+				msg.Context = WithMode(msg.Context, tt.ctxMode)
+			}
+			resp, err := s.Interceptor()(next)(&msg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.MessageID != 1 {
+				t.Errorf("MessageID = %d, want the first part's ID", resp.MessageID)
+			}
+			if !reflect.DeepEqual(sent, tt.want) {
+				t.Errorf("sent = %q, want %q", sent, tt.want)
+			}
+			if !reflect.DeepEqual(observed, tt.observed) {
+				t.Errorf("observed = %v, want %v", observed, tt.observed)
+			}
+		})
+	}
+}
+
+func TestForwardNickname(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   string
+		nickname func(int64) string
+		want     string
+	}{
+		{name: "config", config: "助手", nickname: func(int64) string { return "bot" }, want: "助手"},
+		{name: "bot nickname", nickname: func(int64) string { return "bot" }, want: "bot"},
+		{name: "self id", want: "10001"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New(Config{Mode: ModeForward, MaxLength: 5, ForwardNickname: tt.config})
+			if tt.nickname != nil {
+				s.SetNickname(tt.nickname)
+			}
+			var got string
+			next := func(msg *outbound.Message) (*types.SendMessageResponse, error) {
+				got, _ = msg.Params.Message[0].Data["nickname"].(string)
+				return &types.SendMessageResponse{}, nil
+			}
+			msg := &outbound.Message{
+				Context: context.Background(),
+				Action:  types.ActionSendPrivateMsg,
+				SelfID:  10001,
+				Params:  types.SendMessageParams{Message: message.Text("abcdefgh")},
+			}
+			if _, err := s.Interceptor()(next)(msg); err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("nickname = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitStopsOnError(t *testing.T) {
+	s := New(Config{Mode: ModeSplit, MaxLength: 2})
+	calls := 0
+	next := func(msg *outbound.Message) (*types.SendMessageResponse, error) {
+		calls++
+		if calls == 2 {
+			return nil, errors.New("rejected")
+		}
+		return &types.SendMessageResponse{MessageID: int32(calls)}, nil
+	}
+	msg := &outbound.Message{
+		Context: context.Background(),
+		Action:  types.ActionSendGroupMsg,
+		Params:  types.SendMessageParams{Message: message.Text("aabbcc")},
+	}
+	resp, err := s.Interceptor()(next)(msg)
+	if err == nil || !strings.Contains(err.Error(), "send part 2 of 3") {
+		t.Errorf("error = %v, want part 2 of 3 to fail", err)
+	}
+	if resp == nil || resp.MessageID != 1 {
+		t.Errorf("resp = %v, want the first part's response", resp)
+	}
+	if calls != 2 {
+		t.Errorf("calls = %d, want sending to stop after the failure", calls)
+	}
+}
+
+func TestForwardAction(t *testing.T) {
+	long := strings.Repeat("a", 12)
+
+	tests := []struct {
+		name       string
+		msgType    types.MessageType
+		forwardErr error
+		want       []string // action: 消息
+		wantErr    error
+		observed   string
+	}{
+		{
+			name:     "group",
+			msgType:  types.MessageTypeGroup,
+			want:     []string{"send_group_forward_msg: [node][node][node]"},
+			observed: "forward",
+		},
+		{
+			name:     "private",
+			msgType:  types.MessageTypePrivate,
+			want:     []string{"send_private_forward_msg: [node][node][node]"},
+			observed: "forward",
+		},
+		{
+			name:       "unsupported falls back to split",
+			msgType:    types.MessageTypeGroup,
+			forwardErr: errors.New("API error: unknown action"),
+			want: []string{
+				"send_group_forward_msg: [node][node][node]",
+				"send_msg: aaaaa", "send_msg: aaaaa", "send_msg: aa",
+			},
+			observed: "split",
+		},
+		{
+			name:       "vetoed is not retried",
+			msgType:    types.MessageTypeGroup,
+			forwardErr: outbound.Veto("muted"),
+			want:       []string{"send_group_forward_msg: [node][node][node]"},
+			wantErr:    outbound.ErrVetoed,
+			observed:   "forward",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var observed []string
+			s := New(Config{Mode: ModeForward, MaxLength: 5}).SetObserver(func(mode string) { observed = append(observed, mode) })
+
+			var sent []string
+			next := func(msg *outbound.Message) (*types.SendMessageResponse, error) {
+				sent = append(sent, msg.Action+": "+render(msg.Params.Message))
+				if msg.IsForward() && tt.forwardErr != nil {
+					return nil, tt.forwardErr
+				}
+				return &types.SendMessageResponse{MessageID: int32(len(sent))}, nil
+			}
+
+			msg := &outbound.Message{
+				Context: context.Background(),
+				Action:  types.ActionSendMsg,
+				Params:  types.SendMessageParams{MessageType: tt.msgType, GroupID: 100, UserID: 10001, Message: message.Text(long)},
+			}
+			_, err := s.Interceptor()(next)(msg)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("error = %v, want %v", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(sent, tt.want) {
+				t.Errorf("sent = %q, want %q", sent, tt.want)
+			}
+			if !reflect.DeepEqual(observed, []string{tt.observed}) {
+				t.Errorf("observed = %v, want [%s]", observed, tt.observed)
+			}
+		})
+	}
+}
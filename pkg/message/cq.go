@@ -0,0 +1,88 @@
+package message
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+
+	types "onebot-go2/pkg/const"
+)
+
+// Decode 按 OneBot 11 的规则解析 JSON 中的 message 字段
+// 可以是消息段数组、单个消息段或字符串；字符串按 CQ 码解析，autoEscape 为 true 时作为纯文本
+func Decode(raw json.RawMessage, autoEscape bool) (types.MessageArray, error) {
+	if len(raw) == 0 {
+		return nil, errors.New("message is required")
+	}
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		// 字符串消息转换为消息段后不再需要转义
+		if autoEscape {
+			return Text(text), nil
+		}
+		return ParseCQ(text), nil
+	}
+	var messages types.MessageArray
+	if err := json.Unmarshal(raw, &messages); err == nil {
+		return messages, nil
+	}
+	var segment types.Message
+	if err := json.Unmarshal(raw, &segment); err == nil && segment.Type != "" {
+		return types.MessageArray{segment}, nil
+	}
+	return nil, errors.New("message must be a string, a segment or an array of segments")
+}
+
+// ParseCQ 将 CQ 码字符串（如 "你好[CQ:at,qq=123]"）解析为消息数组
+// 文本和参数中的 &amp; &#91; &#93; &#44; 会被还原，格式不完整的 CQ 码按文本处理
+func ParseCQ(s string) types.MessageArray {
+	messages := make(types.MessageArray, 0)
+	var text strings.Builder
+	flush := func() {
+		if text.Len() > 0 {
+			messages = append(messages, types.Message{
+				Type: "text",
+				Data: map[string]interface{}{"text": unescapeCQ(text.String())},
+			})
+			text.Reset()
+		}
+	}
+
+	for len(s) > 0 {
+		start := strings.Index(s, "[CQ:")
+		if start < 0 {
+			text.WriteString(s)
+			break
+		}
+		end := strings.IndexByte(s[start:], ']')
+		if end < 0 {
+			text.WriteString(s)
+			break
+		}
+		text.WriteString(s[:start])
+		code := s[start+len("[CQ:") : start+end]
+		s = s[start+end+1:]
+
+		parts := strings.Split(code, ",")
+		if parts[0] == "" {
+			text.WriteString("[CQ:" + code + "]")
+			continue
+		}
+		segment := types.Message{Type: parts[0], Data: map[string]interface{}{}}
+		for _, part := range parts[1:] {
+			key, value, _ := strings.Cut(part, "=")
+			segment.Data[key] = unescapeCQ(value)
+		}
+		flush()
+		messages = append(messages, segment)
+	}
+	flush()
+	return messages
+}
+
+// cqUnescaper 还原 CQ 码中的转义字符
+var cqUnescaper = strings.NewReplacer("&#91;", "[", "&#93;", "]", "&#44;", ",", "&amp;", "&")
+
+func unescapeCQ(s string) string {
+	return cqUnescaper.Replace(s)
+}
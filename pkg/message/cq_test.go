@@ -0,0 +1,93 @@
+package message
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	types "onebot-go2/pkg/const"
+)
+
+func TestParseCQ(t *testing.T) {
+	text := func(s string) types.Message {
+		return types.Message{Type: "text", Data: map[string]interface{}{"text": s}}
+	}
+	segment := func(typ string, data map[string]interface{}) types.Message {
+		return types.Message{Type: typ, Data: data}
+	}
+
+	tests := []struct {
+		in   string
+		want types.MessageArray
+	}{
+		{in: "", want: types.MessageArray{}},
+		{in: "hello", want: types.MessageArray{text("hello")}},
+		{
+			in: "hi [CQ:at,qq=10001] there",
+			want: types.MessageArray{
+				text("hi "),
+				segment("at", map[string]interface{}{"qq": "10001"}),
+				text(" there"),
+			},
+		},
+		{
+			in: "[CQ:face,id=1][CQ:image,file=a.png,url=http://x/?a=1&amp;b=2]",
+			want: types.MessageArray{
+				segment("face", map[string]interface{}{"id": "1"}),
+				segment("image", map[string]interface{}{"file": "a.png", "url": "http://x/?a=1&b=2"}),
+			},
+		},
+		{
+			in:   "&#91;not a code&#93; &amp;&#44;",
+			want: types.MessageArray{text("[not a code] &,")},
+		},
+		{
+			in:   "[CQ:shake]",
+			want: types.MessageArray{segment("shake", map[string]interface{}{})},
+		},
+		{
+			in:   "broken [CQ:at,qq=1",
+			want: types.MessageArray{text("broken [CQ:at,qq=1")},
+		},
+		{
+			in:   "empty [CQ:] type",
+			want: types.MessageArray{text("empty [CQ:] type")},
+		},
+	}
+
+	for _, tt := range tests {
+		if got := ParseCQ(tt.in); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseCQ(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		raw        string
+		autoEscape bool
+		want       types.MessageArray
+		wantErr    bool
+	}{
+		{raw: `"a[CQ:face,id=1]"`, want: types.MessageArray{
+			{Type: "text", Data: map[string]interface{}{"text": "a"}},
+			{Type: "face", Data: map[string]interface{}{"id": "1"}},
+		}},
+		{raw: `"a[CQ:face,id=1]"`, autoEscape: true, want: Text("a[CQ:face,id=1]")},
+		{raw: `[{"type":"text","data":{"text":"x"}}]`, want: Text("x")},
+		{raw: `{"type":"text","data":{"text":"x"}}`, want: Text("x")},
+		{raw: ``, wantErr: true},
+		{raw: `{}`, wantErr: true},
+		{raw: `42`, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := Decode(json.RawMessage(tt.raw), tt.autoEscape)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Decode(%s) error = %v, wantErr %v", tt.raw, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Decode(%s) = %v, want %v", tt.raw, got, tt.want)
+		}
+	}
+}
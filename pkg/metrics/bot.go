@@ -0,0 +1,195 @@
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	types "onebot-go2/pkg/const"
+	"onebot-go2/pkg/event"
+)
+
+// Bot 机器人运行指标
+// 所有方法在接收者为 nil 时不做任何事，未启用指标的组件无需判断
+type Bot struct {
+	registry *Registry
+
+	EventsReceived  *CounterVec   // 收到的事件，按 post_type、detail_type、sub_type
+	HandlerCalls    *CounterVec   // 处理器调用次数，按 handler
+	HandlerErrors   *CounterVec   // 处理器返回错误的次数，按 handler
+	HandlerDuration *HistogramVec // 处理器耗时，按 handler
+	APICalls        *CounterVec   // API 调用次数，按 action、retcode
+	APIDuration     *HistogramVec // API 调用耗时，按 action
+	PendingCalls    *Gauge        // 等待响应的 API 调用数
+	Connected       *Gauge        // OneBot 客户端是否已连接
+	Reconnects      *Counter      // 重新连接次数（不含首次连接）
+	OutboundQueue   *Gauge        // 等待写入连接的消息数
+	CacheRequests   *CounterVec   // 信息缓存查询次数，按 kind、result（hit、miss）
+	Throttled       *CounterVec   // 经过发送限流的消息数，按 result（immediate、delayed、dropped）
+	ThrottleWait    *Histogram    // 发送限流的排队时间
+	LongMessages    *CounterVec   // 超长消息处理次数，按 mode（split、forward）
+}
+
+// NewBot 在 registry 中注册机器人运行指标
+func NewBot(registry *Registry) *Bot {
+	return &Bot{
+		registry: registry,
+		EventsReceived: registry.NewCounter("onebot_events_received_total",
+			"Events received from the OneBot client.", "post_type", "detail_type", "sub_type"),
+		HandlerCalls: registry.NewCounter("onebot_handler_calls_total",
+			"Event handler invocations.", "handler"),
+		HandlerErrors: registry.NewCounter("onebot_handler_errors_total",
+			"Event handler invocations that returned an error.", "handler"),
+		HandlerDuration: registry.NewHistogram("onebot_handler_duration_seconds",
+			"Event handler latency.", nil, "handler"),
+		APICalls: registry.NewCounter("onebot_api_calls_total",
+			"OneBot API calls by action and retcode (timeout or error when no response was received).", "action", "retcode"),
+		APIDuration: registry.NewHistogram("onebot_api_call_duration_seconds",
+			"OneBot API call latency.", nil, "action"),
+		PendingCalls: registry.NewGauge("onebot_api_pending_calls",
+			"OneBot API calls waiting for a response.").With(),
+		Connected: registry.NewGauge("onebot_connected",
+			"Whether a OneBot client is connected (1) or not (0).").With(),
+		Reconnects: registry.NewCounter("onebot_reconnects_total",
+			"OneBot client connections established after the first one.").With(),
+		OutboundQueue: registry.NewGauge("onebot_outbound_queue_depth",
+			"Messages waiting to be written to the OneBot connection.").With(),
+		CacheRequests: registry.NewCounter("onebot_cache_requests_total",
+			"Info cache lookups by kind and result (hit or miss).", "kind", "result"),
+		Throttled: registry.NewCounter("onebot_throttle_messages_total",
+			"Outgoing messages passed through the send throttle by result (immediate, delayed or dropped).", "result"),
+		ThrottleWait: registry.NewHistogram("onebot_throttle_wait_seconds",
+			"Time outgoing messages waited in the send throttle queue.", nil).With(),
+		LongMessages: registry.NewCounter("onebot_long_messages_total",
+			"Outgoing messages over the length limit by handling mode (split or forward).", "mode"),
+	}
+}
+
+// Registry 返回指标所在的注册表
+func (b *Bot) Registry() *Registry {
+	if b == nil {
+		return nil
+	}
+	return b.registry
+}
+
+// ObserveEvent 记录收到的事件
+func (b *Bot) ObserveEvent(evt interface{}) {
+	if b == nil {
+		return
+	}
+	var postType types.PostType
+	var detailType, subType string
+	switch e := evt.(type) {
+	case *types.MessageEvent:
+		postType, detailType, subType = e.PostType, string(e.MessageType), e.SubType
+	case *types.NoticeEvent:
+		postType, detailType, subType = e.PostType, e.NoticeType, e.SubType
+	case *types.RequestEvent:
+		postType, detailType, subType = e.PostType, e.RequestType, e.SubType
+	case *types.MetaEvent:
+		postType, detailType, subType = e.PostType, e.MetaEventType, e.SubType
+	default:
+		return
+	}
+	b.EventsReceived.With(string(postType), detailType, subType).Inc()
+}
+
+// ObserveAPICall 记录一次 API 调用，resp 为空表示未收到响应（超时或发送失败）
+func (b *Bot) ObserveAPICall(action string, resp *types.APIResponse, timeout bool, duration time.Duration) {
+	if b == nil {
+		return
+	}
+	retcode := "error"
+	switch {
+	case resp != nil:
+		retcode = strconv.Itoa(resp.RetCode)
+	case timeout:
+		retcode = "timeout"
+	}
+	b.APICalls.With(action, retcode).Inc()
+	b.APIDuration.With(action).Observe(duration.Seconds())
+}
+
+// SetConnected 记录连接状态，reconnect 表示不是首次连接
+func (b *Bot) SetConnected(connected, reconnect bool) {
+	if b == nil {
+		return
+	}
+	if connected {
+		b.Connected.Set(1)
+		if reconnect {
+			b.Reconnects.Inc()
+		}
+	} else {
+		b.Connected.Set(0)
+	}
+}
+
+// AddPendingCalls 调整等待响应的 API 调用数
+func (b *Bot) AddPendingCalls(delta int) {
+	if b == nil {
+		return
+	}
+	b.PendingCalls.Add(float64(delta))
+}
+
+// AddOutbound 调整等待写入连接的消息数
+func (b *Bot) AddOutbound(delta int) {
+	if b == nil {
+		return
+	}
+	b.OutboundQueue.Add(float64(delta))
+}
+
+// ObserveCache 记录一次信息缓存查询
+func (b *Bot) ObserveCache(kind string, hit bool) {
+	if b == nil {
+		return
+	}
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	b.CacheRequests.With(kind, result).Inc()
+}
+
+// ObserveThrottle 记录一条消息的发送限流结果
+func (b *Bot) ObserveThrottle(result string, wait time.Duration) {
+	if b == nil {
+		return
+	}
+	b.Throttled.With(result).Inc()
+	if result != "dropped" {
+		b.ThrottleWait.Observe(wait.Seconds())
+	}
+}
+
+// ObserveLongMessage 记录一条超长消息的处理方式
+func (b *Bot) ObserveLongMessage(mode string) {
+	if b == nil {
+		return
+	}
+	b.LongMessages.With(mode).Inc()
+}
+
+// Middleware 返回记录处理器调用次数、错误和耗时的中间件
+// 应作为第一个中间件注册，以便记录被 RecoveryMiddleware 恢复的 panic
+func (b *Bot) Middleware() event.Middleware {
+	return func(next event.HandlerFunc[interface{}]) event.HandlerFunc[interface{}] {
+		return func(ctx *event.Context[interface{}]) error {
+			if b == nil {
+				return next(ctx)
+			}
+			start := time.Now()
+			err := next(ctx)
+
+			handler := ctx.HandlerName()
+			b.HandlerCalls.With(handler).Inc()
+			if err != nil {
+				b.HandlerErrors.With(handler).Inc()
+			}
+			b.HandlerDuration.With(handler).Observe(time.Since(start).Seconds())
+			return err
+		}
+	}
+}
@@ -0,0 +1,423 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultBuckets 默认的耗时直方图分桶（秒）
+var DefaultBuckets = []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Registry 指标注册表，以 Prometheus 文本格式输出所有指标，不依赖 Prometheus 客户端库
+type Registry struct {
+	mu      sync.RWMutex
+	metrics []collector
+	names   map[string]bool
+}
+
+// collector 可输出的指标
+type collector interface {
+	name() string
+	write(w *bufio.Writer)
+}
+
+// NewRegistry 创建指标注册表
+func NewRegistry() *Registry {
+	return &Registry{names: make(map[string]bool)}
+}
+
+func (r *Registry) add(c collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.names[c.name()] {
+		panic(fmt.Sprintf("metrics: %s already registered", c.name()))
+	}
+	r.names[c.name()] = true
+	r.metrics = append(r.metrics, c)
+}
+
+// WriteTo 以 Prometheus 文本格式输出所有指标
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.RLock()
+	metrics := append([]collector{}, r.metrics...)
+	r.mu.RUnlock()
+
+	cw := &countingWriter{w: w}
+	bw := bufio.NewWriter(cw)
+	for _, m := range metrics {
+		m.write(bw)
+	}
+	err := bw.Flush()
+	return cw.n, err
+}
+
+// ServeHTTP 实现 http.Handler，用于挂载 /metrics
+func (r *Registry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	r.WriteTo(w)
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// ============ 指标基础 ============
+
+// desc 指标名称、说明和标签
+type desc struct {
+	metricName string
+	help       string
+	kind       string // counter, gauge, histogram
+	labels     []string
+}
+
+func (d *desc) name() string {
+	return d.metricName
+}
+
+func (d *desc) writeHeader(w *bufio.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n", d.metricName, escapeHelp(d.help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", d.metricName, d.kind)
+}
+
+// vec 按标签值分组的序列
+type vec[S any] struct {
+	desc
+	mu     sync.RWMutex
+	series map[string]*entry[S]
+	create func() *S
+}
+
+type entry[S any] struct {
+	values []string
+	series *S
+}
+
+func newVec[S any](name, help, kind string, labels []string, create func() *S) *vec[S] {
+	return &vec[S]{
+		desc:   desc{metricName: name, help: help, kind: kind, labels: labels},
+		series: make(map[string]*entry[S]),
+		create: create,
+	}
+}
+
+// with 返回标签值对应的序列，不存在时创建
+func (v *vec[S]) with(values []string) *S {
+	if len(values) != len(v.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", v.metricName, len(v.labels), len(values)))
+	}
+	key := strings.Join(values, "\xff")
+
+	v.mu.RLock()
+	e, ok := v.series[key]
+	v.mu.RUnlock()
+	if ok {
+		return e.series
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if e, ok := v.series[key]; ok {
+		return e.series
+	}
+	e = &entry[S]{values: append([]string{}, values...), series: v.create()}
+	v.series[key] = e
+	return e.series
+}
+
+// sorted 返回按标签值排序的序列，保证输出稳定
+func (v *vec[S]) sorted() []*entry[S] {
+	v.mu.RLock()
+	entries := make([]*entry[S], 0, len(v.series))
+	for _, e := range v.series {
+		entries = append(entries, e)
+	}
+	v.mu.RUnlock()
+	sort.Slice(entries, func(i, j int) bool {
+		return strings.Join(entries[i].values, "\xff") < strings.Join(entries[j].values, "\xff")
+	})
+	return entries
+}
+
+// ============ Counter ============
+
+// Counter 只增不减的计数器
+type Counter struct {
+	value atomicFloat
+}
+
+// Inc 加 1
+func (c *Counter) Inc() {
+	c.value.add(1)
+}
+
+// Add 增加 delta，delta 必须非负
+func (c *Counter) Add(delta float64) {
+	if delta < 0 {
+		panic("metrics: counter cannot decrease")
+	}
+	c.value.add(delta)
+}
+
+// Value 返回当前值
+func (c *Counter) Value() float64 {
+	return c.value.load()
+}
+
+// CounterVec 带标签的计数器
+type CounterVec struct {
+	*vec[Counter]
+}
+
+// NewCounter 创建并注册计数器，labels 为标签名
+func (r *Registry) NewCounter(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{newVec(name, help, "counter", labels, func() *Counter { return &Counter{} })}
+	r.add(c)
+	return c
+}
+
+// With 返回标签值对应的计数器，标签值的顺序与创建时的标签名一致
+func (c *CounterVec) With(values ...string) *Counter {
+	return c.with(values)
+}
+
+func (c *CounterVec) write(w *bufio.Writer) {
+	c.writeHeader(w)
+	for _, e := range c.sorted() {
+		writeSample(w, c.metricName, c.labels, e.values, "", "", e.series.Value())
+	}
+}
+
+// ============ Gauge ============
+
+// Gauge 可增可减的数值
+type Gauge struct {
+	value atomicFloat
+}
+
+// Set 设置为 v
+func (g *Gauge) Set(v float64) {
+	g.value.store(v)
+}
+
+// Add 增加 delta（可以为负数）
+func (g *Gauge) Add(delta float64) {
+	g.value.add(delta)
+}
+
+// Inc 加 1
+func (g *Gauge) Inc() {
+	g.value.add(1)
+}
+
+// Dec 减 1
+func (g *Gauge) Dec() {
+	g.value.add(-1)
+}
+
+// Value 返回当前值
+func (g *Gauge) Value() float64 {
+	return g.value.load()
+}
+
+// GaugeVec 带标签的数值
+type GaugeVec struct {
+	*vec[Gauge]
+}
+
+// NewGauge 创建并注册数值指标，labels 为标签名
+func (r *Registry) NewGauge(name, help string, labels ...string) *GaugeVec {
+	g := &GaugeVec{newVec(name, help, "gauge", labels, func() *Gauge { return &Gauge{} })}
+	r.add(g)
+	return g
+}
+
+// With 返回标签值对应的数值
+func (g *GaugeVec) With(values ...string) *Gauge {
+	return g.with(values)
+}
+
+func (g *GaugeVec) write(w *bufio.Writer) {
+	g.writeHeader(w)
+	for _, e := range g.sorted() {
+		writeSample(w, g.metricName, g.labels, e.values, "", "", e.series.Value())
+	}
+}
+
+// gaugeFunc 输出时调用函数取值的数值指标
+type gaugeFunc struct {
+	desc
+	fn func() float64
+}
+
+// NewGaugeFunc 注册输出时调用 fn 取值的数值指标
+func (r *Registry) NewGaugeFunc(name, help string, fn func() float64) {
+	r.add(&gaugeFunc{desc: desc{metricName: name, help: help, kind: "gauge"}, fn: fn})
+}
+
+func (g *gaugeFunc) write(w *bufio.Writer) {
+	g.writeHeader(w)
+	writeSample(w, g.metricName, nil, nil, "", "", g.fn())
+}
+
+// ============ Histogram ============
+
+// Histogram 直方图，记录观测值的分布
+type Histogram struct {
+	upper  []float64 // 各桶上限（不含 +Inf）
+	counts []atomic.Uint64
+	count  atomic.Uint64
+	sum    atomicFloat
+}
+
+// Observe 记录一个观测值
+func (h *Histogram) Observe(v float64) {
+	i := sort.SearchFloat64s(h.upper, v)
+	if i < len(h.counts) {
+		h.counts[i].Add(1)
+	}
+	h.count.Add(1)
+	h.sum.add(v)
+}
+
+// Count 返回观测次数
+func (h *Histogram) Count() uint64 {
+	return h.count.Load()
+}
+
+// Sum 返回观测值之和
+func (h *Histogram) Sum() float64 {
+	return h.sum.load()
+}
+
+// HistogramVec 带标签的直方图
+type HistogramVec struct {
+	*vec[Histogram]
+}
+
+// NewHistogram 创建并注册直方图，buckets 为空时使用 DefaultBuckets
+func (r *Registry) NewHistogram(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	upper := append([]float64{}, buckets...)
+	sort.Float64s(upper)
+	h := &HistogramVec{
+		vec: newVec(name, help, "histogram", labels, func() *Histogram {
+			return &Histogram{upper: upper, counts: make([]atomic.Uint64, len(upper))}
+		}),
+	}
+	r.add(h)
+	return h
+}
+
+// With 返回标签值对应的直方图
+func (h *HistogramVec) With(values ...string) *Histogram {
+	return h.with(values)
+}
+
+func (h *HistogramVec) write(w *bufio.Writer) {
+	h.writeHeader(w)
+	for _, e := range h.sorted() {
+		hist := e.series
+		// 先读总数再读各桶，保证各桶累计值不超过 _count
+		count := hist.count.Load()
+		var cumulative uint64
+		for i, upper := range hist.upper {
+			cumulative += hist.counts[i].Load()
+			writeSample(w, h.metricName+"_bucket", h.labels, e.values, "le", formatFloat(upper), float64(min(cumulative, count)))
+		}
+		writeSample(w, h.metricName+"_bucket", h.labels, e.values, "le", "+Inf", float64(count))
+		writeSample(w, h.metricName+"_sum", h.labels, e.values, "", "", hist.Sum())
+		writeSample(w, h.metricName+"_count", h.labels, e.values, "", "", float64(count))
+	}
+}
+
+// ============ 输出格式 ============
+
+func writeSample(w *bufio.Writer, name string, labels, values []string, extraLabel, extraValue string, v float64) {
+	w.WriteString(name)
+	if len(labels) > 0 || extraLabel != "" {
+		w.WriteByte('{')
+		for i, label := range labels {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			fmt.Fprintf(w, `%s="%s"`, label, escapeLabel(values[i]))
+		}
+		if extraLabel != "" {
+			if len(labels) > 0 {
+				w.WriteByte(',')
+			}
+			fmt.Fprintf(w, `%s="%s"`, extraLabel, extraValue)
+		}
+		w.WriteByte('}')
+	}
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(v))
+	w.WriteByte('\n')
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeLabel(s string) string {
+	return labelEscaper.Replace(s)
+}
+
+func escapeHelp(s string) string {
+	return helpEscaper.Replace(s)
+}
+
+// ============ 原子浮点数 ============
+
+type atomicFloat struct {
+	bits atomic.Uint64
+}
+
+func (f *atomicFloat) load() float64 {
+	return math.Float64frombits(f.bits.Load())
+}
+
+func (f *atomicFloat) store(v float64) {
+	f.bits.Store(math.Float64bits(v))
+}
+
+func (f *atomicFloat) add(delta float64) {
+	for {
+		old := f.bits.Load()
+		next := math.Float64bits(math.Float64frombits(old) + delta)
+		if f.bits.CompareAndSwap(old, next) {
+			return
+		}
+	}
+}
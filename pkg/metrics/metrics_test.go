@@ -0,0 +1,150 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	types "onebot-go2/pkg/const"
+	"onebot-go2/pkg/event"
+)
+
+func TestWriteTo(t *testing.T) {
+	r := NewRegistry()
+	requests := r.NewCounter("test_requests_total", "Requests by path.\nSecond line with a \\ backslash.", "path", "code")
+	temperature := r.NewGauge("test_temperature", "Current temperature.")
+	latency := r.NewHistogram("test_latency_seconds", "Request latency.", []float64{0.5, 0.1, 1}, "path")
+	r.NewGaugeFunc("test_answer", "The answer.", func() float64 { return 42 })
+
+	requests.With(`/a"b`, "200").Add(3)
+	requests.With("/back\\slash", "500").Inc()
+	requests.With("/new\nline", "200").Inc()
+	temperature.With().Set(-1.5)
+	for _, v := range []float64{0.05, 0.1, 0.3, 2} {
+		latency.With("/").Observe(v)
+	}
+	latency.With("/empty")
+
+	var b strings.Builder
+	n, err := r.WriteTo(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if int(n) != b.Len() {
+		t.Errorf("WriteTo() = %d bytes, wrote %d", n, b.Len())
+	}
+
+	// 标签值中的反斜杠、引号和换行被转义，序列按标签值排序；
+	// 分桶按上限排序且为累计值，0.1 落在 le="0.1" 桶中
+	want := `# HELP test_requests_total Requests by path.\nSecond line with a \\ backslash.
+# TYPE test_requests_total counter
+test_requests_total{path="/a\"b",code="200"} 3
+test_requests_total{path="/back\\slash",code="500"} 1
+test_requests_total{path="/new\nline",code="200"} 1
+# HELP test_temperature Current temperature.
+# TYPE test_temperature gauge
+test_temperature -1.5
+# HELP test_latency_seconds Request latency.
+# TYPE test_latency_seconds histogram
+test_latency_seconds_bucket{path="/",le="0.1"} 2
+test_latency_seconds_bucket{path="/",le="0.5"} 3
+test_latency_seconds_bucket{path="/",le="1"} 3
+test_latency_seconds_bucket{path="/",le="+Inf"} 4
+test_latency_seconds_sum{path="/"} 2.45
+test_latency_seconds_count{path="/"} 4
+test_latency_seconds_bucket{path="/empty",le="0.1"} 0
+test_latency_seconds_bucket{path="/empty",le="0.5"} 0
+test_latency_seconds_bucket{path="/empty",le="1"} 0
+test_latency_seconds_bucket{path="/empty",le="+Inf"} 0
+test_latency_seconds_sum{path="/empty"} 0
+test_latency_seconds_count{path="/empty"} 0
+# HELP test_answer The answer.
+# TYPE test_answer gauge
+test_answer 42
+`
+	if got := b.String(); got != want {
+		t.Errorf("WriteTo() output:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestServeHTTP(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounter("test_total", "Test.").With().Inc()
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	if want := "# HELP test_total Test.\n# TYPE test_total counter\ntest_total 1\n"; w.Body.String() != want {
+		t.Errorf("body = %q, want %q", w.Body, want)
+	}
+}
+
+func TestDuplicateRegistration(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounter("test_total", "Test.")
+	defer func() {
+		if recover() == nil {
+			t.Error("registering a metric twice did not panic")
+		}
+	}()
+	r.NewGauge("test_total", "Test.")
+}
+
+func TestBotMiddleware(t *testing.T) {
+	r := NewRegistry()
+	bot := NewBot(r)
+
+	d := event.NewDispatcher().Use(bot.Middleware())
+	event.RegisterFunc(d, "ok", 1, func(ctx *event.Context[*types.MessageEvent]) error {
+		time.Sleep(2 * time.Millisecond)
+		return nil
+	})
+	event.RegisterFunc(d, "fails", 2, func(ctx *event.Context[*types.MessageEvent]) error {
+		return errors.New("boom")
+	})
+	for i := 0; i < 2; i++ {
+		d.Dispatch(context.Background(), event.GroupMessage(100, 10001, "hi"), nil)
+	}
+
+	var b strings.Builder
+	if _, err := r.WriteTo(&b); err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+	for _, line := range []string{
+		`onebot_handler_calls_total{handler="fails"} 2`,
+		`onebot_handler_calls_total{handler="ok"} 2`,
+		`onebot_handler_errors_total{handler="fails"} 2`,
+		`onebot_handler_duration_seconds_bucket{handler="ok",le="0.001"} 0`,
+		`onebot_handler_duration_seconds_bucket{handler="ok",le="+Inf"} 2`,
+		`onebot_handler_duration_seconds_count{handler="ok"} 2`,
+		`onebot_handler_duration_seconds_count{handler="fails"} 2`,
+	} {
+		if !strings.Contains(out, line+"\n") {
+			t.Errorf("output is missing %s", line)
+		}
+	}
+	if strings.Contains(out, `onebot_handler_errors_total{handler="ok"}`) {
+		t.Error("a handler without errors has an error series")
+	}
+	if sum := bot.HandlerDuration.With("ok").Sum(); sum < 0.004 {
+		t.Errorf("handler duration sum = %v, want at least 4ms", sum)
+	}
+
+	// 未启用指标时中间件直接调用处理器
+	var disabled *Bot
+	called := false
+	err := disabled.Middleware()(func(*event.Context[interface{}]) error {
+		called = true
+		return nil
+	})(event.NewContext[interface{}](context.Background(), nil))
+	if err != nil || !called {
+		t.Errorf("nil Bot middleware: called = %v, err = %v", called, err)
+	}
+}
@@ -0,0 +1,482 @@
+// Package onebottest 提供进程内的假 OneBot 客户端，用于不依赖拉格朗日的集成测试
+//
+// 假客户端通过 WebSocket 连接 WSServer（与真实客户端相同的反向 WebSocket 方式），
+// 可以注入事件、按 action 设定响应或错误，并记录机器人发出的每个 API 调用：
+//
+//	srv := server.NewWSServer("")
+//	// 注册处理器、插件...
+//	bot := onebottest.Start(t, srv)
+//	bot.Inject(event.GroupMessage(123, 456, "/ping"))
+//	bot.AssertGroupMessage(123, "Pong!")
+package onebottest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+
+	"onebot-go2/internal/server"
+	types "onebot-go2/pkg/const"
+	"onebot-go2/pkg/message"
+)
+
+// DefaultSelfID 假客户端默认的机器人 QQ 号
+const DefaultSelfID int64 = 10000
+
+// DefaultTimeout 断言等待 API 调用的默认时间
+const DefaultTimeout = 2 * time.Second
+
+// Responder 为一次 API 调用生成响应数据，返回错误时响应失败状态（*Error 可指定 retcode）
+type Responder func(call Call) (interface{}, error)
+
+// Error 失败的 API 响应
+type Error struct {
+	RetCode int
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("retcode %d: %s", e.RetCode, e.Message)
+}
+
+// Call 机器人发出的一次 API 调用
+type Call struct {
+	Action string
+	Params json.RawMessage
+	Echo   string
+	Time   time.Time
+}
+
+// Decode 将参数解析到 v
+func (c Call) Decode(v interface{}) error {
+	return json.Unmarshal(c.Params, v)
+}
+
+// Target 返回参数中的 group_id 和 user_id
+func (c Call) Target() (groupID, userID int64) {
+	var target struct {
+		GroupID int64 `json:"group_id"`
+		UserID  int64 `json:"user_id"`
+	}
+	c.Decode(&target)
+	return target.GroupID, target.UserID
+}
+
+// Message 返回参数中的消息（send_msg、send_group_msg、send_private_msg），
+// 合并转发 action 返回 messages 中的 node 消息段
+func (c Call) Message() types.MessageArray {
+	var params struct {
+		Message  types.MessageArray `json:"message"`
+		Messages types.MessageArray `json:"messages"`
+	}
+	c.Decode(&params)
+	if c.Action == types.ActionSendGroupForwardMsg || c.Action == types.ActionSendPrivateForwardMsg {
+		return params.Messages
+	}
+	return params.Message
+}
+
+// Text 返回消息中文本段拼接的纯文本
+func (c Call) Text() string {
+	var b strings.Builder
+	for _, seg := range c.Message() {
+		if seg.Type == "text" {
+			if text, ok := seg.Data["text"].(string); ok {
+				b.WriteString(text)
+			}
+		}
+	}
+	return b.String()
+}
+
+// String 返回调用的简要描述，用于断言失败信息
+func (c Call) String() string {
+	if msg := c.Message(); len(msg) > 0 {
+		return fmt.Sprintf("%s %s %q", c.Action, c.Params, message.String(msg))
+	}
+	return fmt.Sprintf("%s %s", c.Action, c.Params)
+}
+
+// Client 假 OneBot 客户端
+type Client struct {
+	t      testing.TB
+	conn   *websocket.Conn
+	selfID int64
+
+	writeMu sync.Mutex
+
+	mu         sync.Mutex
+	responders map[string]Responder
+	calls      []Call
+	changed    chan struct{} // 每次记录新调用时关闭并替换，用于等待
+	messageID  int32
+	done       chan struct{}
+}
+
+// Option 客户端选项
+type Option func(*options)
+
+type options struct {
+	selfID int64
+	token  string
+}
+
+// WithSelfID 设置机器人 QQ 号（X-Self-ID），默认 DefaultSelfID
+func WithSelfID(selfID int64) Option {
+	return func(o *options) { o.selfID = selfID }
+}
+
+// WithToken 设置连接使用的 access token
+func WithToken(token string) Option {
+	return func(o *options) { o.token = token }
+}
+
+// Start 在测试 HTTP 服务器上运行 srv 的 WebSocket 端点并连接，等待连接时的身份查询完成，测试结束时关闭
+// 分发器被设为异步：同步分发时处理器等待 API 响应会阻塞读取响应的循环
+func Start(t testing.TB, srv *server.WSServer, opts ...Option) *Client {
+	t.Helper()
+	srv.GetDispatcher().SetAsync(true)
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/ws", srv.HandlerWebsocket)
+	ts := httptest.NewServer(r)
+	t.Cleanup(ts.Close)
+
+	c := Connect(t, "ws"+strings.TrimPrefix(ts.URL, "http")+"/ws", opts...)
+
+	// 等待服务器登记连接并获取身份信息，之后的 API 调用不会因未连接失败
+	// 连接时获取身份信息的调用不计入调用记录
+	deadline := time.Now().Add(DefaultTimeout)
+	bot := srv.ForBot(c.selfID)
+	for !bot.IsConnected() || !bot.Self().Identified() {
+		if time.Now().After(deadline) {
+			t.Fatalf("onebottest: server did not register the connection")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	c.Reset()
+	return c
+}
+
+// Connect 连接已运行的 WebSocket 端点（如 ws://127.0.0.1:8080/ws），测试结束时断开
+func Connect(t testing.TB, url string, opts ...Option) *Client {
+	t.Helper()
+	o := options{selfID: DefaultSelfID}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	header := http.Header{}
+	header.Set("X-Self-ID", strconv.FormatInt(o.selfID, 10))
+	if o.token != "" {
+		header.Set("Authorization", "Bearer "+o.token)
+	}
+	conn, _, err := websocket.DefaultDialer.Dial(url, header)
+	if err != nil {
+		t.Fatalf("onebottest: failed to connect to %s: %v", url, err)
+	}
+
+	c := &Client{
+		t:          t,
+		conn:       conn,
+		selfID:     o.selfID,
+		responders: make(map[string]Responder),
+		changed:    make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+	c.defaults()
+	go c.serve()
+	t.Cleanup(c.Close)
+	return c
+}
+
+// defaults 默认响应：发送消息返回递增的 message_id，get_login_info、get_version_info、get_status 返回机器人信息，
+// 其他 action 返回空数据
+func (c *Client) defaults() {
+	send := func(Call) (interface{}, error) {
+		c.mu.Lock()
+		c.messageID++
+		id := c.messageID
+		c.mu.Unlock()
+		return types.SendMessageResponse{MessageID: id}, nil
+	}
+	c.responders[types.ActionSendMsg] = send
+	c.responders[types.ActionSendGroupMsg] = send
+	c.responders[types.ActionSendPrivateMsg] = send
+	c.responders[types.ActionSendGroupForwardMsg] = send
+	c.responders[types.ActionSendPrivateForwardMsg] = send
+	c.responders[types.ActionGetLoginInfo] = func(Call) (interface{}, error) {
+		return types.GetLoginInfoResponse{UserID: c.selfID, Nickname: "onebottest"}, nil
+	}
+	c.responders[types.ActionGetVersionInfo] = func(Call) (interface{}, error) {
+		return types.GetVersionInfoResponse{AppName: "onebottest", AppVersion: "0.0.0", ProtocolVersion: "v11"}, nil
+	}
+	c.responders[types.ActionGetStatus] = func(Call) (interface{}, error) {
+		return types.GetStatusResponse{Online: true, Good: true}, nil
+	}
+}
+
+// SelfID 返回机器人 QQ 号
+func (c *Client) SelfID() int64 {
+	return c.selfID
+}
+
+// Close 断开连接，可以重复调用
+func (c *Client) Close() {
+	c.conn.Close()
+	<-c.done
+}
+
+// ============ 响应设定 ============
+
+// Handle 设定 action 的响应函数，覆盖默认响应
+func (c *Client) Handle(action string, responder Responder) *Client {
+	c.mu.Lock()
+	c.responders[action] = responder
+	c.mu.Unlock()
+	return c
+}
+
+// Respond 设定 action 固定返回 data
+func (c *Client) Respond(action string, data interface{}) *Client {
+	return c.Handle(action, func(Call) (interface{}, error) { return data, nil })
+}
+
+// Fail 设定 action 返回失败状态
+func (c *Client) Fail(action string, retcode int, msg string) *Client {
+	return c.Handle(action, func(Call) (interface{}, error) { return nil, &Error{RetCode: retcode, Message: msg} })
+}
+
+// Ignore 设定 action 不响应，用于测试超时
+func (c *Client) Ignore(action string) *Client {
+	return c.Handle(action, nil)
+}
+
+// serve 读取机器人发出的 API 请求，记录并响应
+func (c *Client) serve() {
+	defer close(c.done)
+	for {
+		_, data, err := c.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		var req struct {
+			Action string          `json:"action"`
+			Params json.RawMessage `json:"params"`
+			Echo   string          `json:"echo"`
+		}
+		if err := json.Unmarshal(data, &req); err != nil {
+			c.t.Errorf("onebottest: invalid request %s: %v", data, err)
+			continue
+		}
+		call := Call{Action: req.Action, Params: req.Params, Echo: req.Echo, Time: time.Now()}
+		if call.Params == nil {
+			call.Params = json.RawMessage("{}")
+		}
+
+		c.mu.Lock()
+		c.calls = append(c.calls, call)
+		close(c.changed)
+		c.changed = make(chan struct{})
+		responder, ok := c.responders[call.Action]
+		c.mu.Unlock()
+
+		if ok && responder == nil {
+			continue // Ignore
+		}
+		resp := types.APIResponse{Status: "ok"}
+		if ok {
+			result, err := responder(call)
+			if err != nil {
+				resp = types.APIResponse{Status: "failed", RetCode: 100, Message: err.Error()}
+				if e, isErr := err.(*Error); isErr {
+					resp.RetCode, resp.Message = e.RetCode, e.Message
+				}
+			} else {
+				resp.Data = result
+			}
+		}
+		c.write(struct {
+			types.APIResponse
+			Echo string `json:"echo"`
+		}{resp, call.Echo})
+	}
+}
+
+func (c *Client) write(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	return c.conn.WriteMessage(websocket.TextMessage, data)
+}
+
+// ============ 注入事件 ============
+
+// Inject 发送事件（*types.MessageEvent、*types.NoticeEvent 等或任意可序列化为 JSON 的值）
+// 类型化事件的 self_id 和 time 为零时使用客户端的 QQ 号和当前时间
+func (c *Client) Inject(evt interface{}) {
+	c.t.Helper()
+	if base := baseOf(evt); base != nil {
+		if base.SelfID == 0 {
+			base.SelfID = c.selfID
+		}
+		if base.Time == 0 {
+			base.Time = time.Now().Unix()
+		}
+	}
+	if err := c.write(evt); err != nil {
+		c.t.Fatalf("onebottest: failed to inject event: %v", err)
+	}
+}
+
+func baseOf(evt interface{}) *types.Event {
+	switch e := evt.(type) {
+	case *types.MessageEvent:
+		return &e.Event
+	case *types.NoticeEvent:
+		return &e.Event
+	case *types.RequestEvent:
+		return &e.Event
+	case *types.MetaEvent:
+		return &e.Event
+	}
+	return nil
+}
+
+// ============ 调用记录和断言 ============
+
+// Calls 返回已记录的所有 API 调用
+func (c *Client) Calls() []Call {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Call(nil), c.calls...)
+}
+
+// CallsTo 返回指定 action 的调用
+func (c *Client) CallsTo(action string) []Call {
+	var calls []Call
+	for _, call := range c.Calls() {
+		if call.Action == action {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// Reset 清空调用记录
+func (c *Client) Reset() {
+	c.mu.Lock()
+	c.calls = nil
+	c.mu.Unlock()
+}
+
+// WaitFor 等待满足 match 的调用，超时返回 false
+// 事件分发可能是异步的，断言处理器的调用前应等待而不是立即检查
+func (c *Client) WaitFor(match func(Call) bool, timeout time.Duration) (Call, bool) {
+	deadline := time.After(timeout)
+	for {
+		c.mu.Lock()
+		calls := append([]Call(nil), c.calls...)
+		changed := c.changed
+		c.mu.Unlock()
+
+		for _, call := range calls {
+			if match(call) {
+				return call, true
+			}
+		}
+
+		select {
+		case <-changed:
+		case <-deadline:
+			return Call{}, false
+		}
+	}
+}
+
+// AssertCalled 断言机器人调用了 action，返回该调用
+func (c *Client) AssertCalled(action string) Call {
+	c.t.Helper()
+	call, ok := c.WaitFor(func(call Call) bool { return call.Action == action }, DefaultTimeout)
+	if !ok {
+		c.t.Errorf("onebottest: expected a call to %s\n%s", action, c.describe())
+	}
+	return call
+}
+
+// AssertGroupMessage 断言机器人向群 groupID 发送了纯文本为 text 的消息
+func (c *Client) AssertGroupMessage(groupID int64, text string) Call {
+	c.t.Helper()
+	call, ok := c.WaitFor(func(call Call) bool {
+		g, _ := call.Target()
+		return isSend(call, types.MessageTypeGroup) && g == groupID && call.Text() == text
+	}, DefaultTimeout)
+	if !ok {
+		c.t.Errorf("onebottest: expected group %d to receive %q\n%s", groupID, text, c.describe())
+	}
+	return call
+}
+
+// AssertPrivateMessage 断言机器人向用户 userID 发送了纯文本为 text 的私聊消息
+func (c *Client) AssertPrivateMessage(userID int64, text string) Call {
+	c.t.Helper()
+	call, ok := c.WaitFor(func(call Call) bool {
+		_, u := call.Target()
+		return isSend(call, types.MessageTypePrivate) && u == userID && call.Text() == text
+	}, DefaultTimeout)
+	if !ok {
+		c.t.Errorf("onebottest: expected user %d to receive %q\n%s", userID, text, c.describe())
+	}
+	return call
+}
+
+// AssertNoCalls 断言在 wait 时间内机器人没有调用 action
+func (c *Client) AssertNoCalls(action string, wait time.Duration) {
+	c.t.Helper()
+	if call, ok := c.WaitFor(func(call Call) bool { return call.Action == action }, wait); ok {
+		c.t.Errorf("onebottest: unexpected call %s", call)
+	}
+}
+
+// isSend 判断调用是否为发送指定类型的消息
+func isSend(call Call, messageType types.MessageType) bool {
+	switch call.Action {
+	case types.ActionSendGroupMsg:
+		return messageType == types.MessageTypeGroup
+	case types.ActionSendPrivateMsg:
+		return messageType == types.MessageTypePrivate
+	case types.ActionSendMsg:
+		var params types.SendMessageParams
+		call.Decode(&params)
+		if params.MessageType == "" {
+			return (params.GroupID != 0) == (messageType == types.MessageTypeGroup)
+		}
+		return params.MessageType == messageType
+	}
+	return false
+}
+
+// describe 列出已记录的调用，用于断言失败信息
+func (c *Client) describe() string {
+	calls := c.Calls()
+	if len(calls) == 0 {
+		return "no API calls were made"
+	}
+	lines := make([]string, len(calls))
+	for i, call := range calls {
+		lines[i] = "  " + call.String()
+	}
+	return "recorded calls:\n" + strings.Join(lines, "\n")
+}
@@ -0,0 +1,170 @@
+package onebottest_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"onebot-go2/internal/server"
+	types "onebot-go2/pkg/const"
+	"onebot-go2/pkg/event"
+	"onebot-go2/pkg/message"
+	"onebot-go2/pkg/onebottest"
+)
+
+// recorder 记录断言失败而不使测试失败，用于验证断言本身
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestScriptedResponses(t *testing.T) {
+	srv := server.NewWSServer("")
+	bot := onebottest.Start(t, srv)
+
+	bot.Respond(types.ActionGetGroupInfo, types.GetGroupInfoResponse{GroupID: 100, GroupName: "测试群", MemberCount: 3})
+	info, err := srv.GetGroupInfo(100, true)
+	if err != nil {
+		t.Fatalf("GetGroupInfo() error = %v", err)
+	}
+	if info.GroupName != "测试群" || info.MemberCount != 3 {
+		t.Errorf("GetGroupInfo() = %+v", info)
+	}
+
+	// Handle 可以根据参数生成响应
+	bot.Handle(types.ActionGetStrangerInfo, func(call onebottest.Call) (interface{}, error) {
+		_, userID := call.Target()
+		return types.GetStrangerInfoResponse{UserID: userID, Nickname: fmt.Sprint("user", userID)}, nil
+	})
+	stranger, err := srv.GetStrangerInfo(10001, true)
+	if err != nil {
+		t.Fatalf("GetStrangerInfo() error = %v", err)
+	}
+	if stranger.Nickname != "user10001" {
+		t.Errorf("GetStrangerInfo() nickname = %q, want user10001", stranger.Nickname)
+	}
+
+	// 默认响应：发送消息返回递增的 message_id
+	first, err := srv.SendGroupMsg(100, message.Text("a"))
+	if err != nil {
+		t.Fatalf("SendGroupMsg() error = %v", err)
+	}
+	second, _ := srv.SendGroupMsg(100, message.Text("b"))
+	if second.MessageID != first.MessageID+1 {
+		t.Errorf("message ids = %d, %d, want consecutive", first.MessageID, second.MessageID)
+	}
+
+	if calls := bot.CallsTo(types.ActionSendGroupMsg); len(calls) != 2 || calls[1].Text() != "b" {
+		t.Errorf("CallsTo(send_group_msg) = %v", calls)
+	}
+	bot.Reset()
+	if calls := bot.Calls(); len(calls) != 0 {
+		t.Errorf("Calls() after Reset = %v", calls)
+	}
+}
+
+func TestInjectedErrors(t *testing.T) {
+	srv := server.NewWSServer("")
+	bot := onebottest.Start(t, srv)
+
+	bot.Fail(types.ActionSetGroupKick, 102, "no permission")
+	resp, err := srv.CallAPI(types.ActionSetGroupKick, types.SetGroupKickParams{GroupID: 100, UserID: 10001})
+	if err == nil || !strings.Contains(err.Error(), "no permission") {
+		t.Fatalf("CallAPI() error = %v, want the injected failure", err)
+	}
+	if resp.Status != "failed" || resp.RetCode != 102 {
+		t.Errorf("response = %+v, want failed with retcode 102", resp)
+	}
+
+	// 响应函数返回普通错误时使用 retcode 100
+	bot.Handle(types.ActionSetGroupBan, func(onebottest.Call) (interface{}, error) {
+		return nil, errors.New("boom")
+	})
+	resp, err = srv.CallAPI(types.ActionSetGroupBan, types.SetGroupBanParams{GroupID: 100, UserID: 10001, Duration: 60})
+	if err == nil || resp.RetCode != 100 || resp.Message != "boom" {
+		t.Errorf("CallAPI() = %+v, %v, want retcode 100 with message boom", resp, err)
+	}
+
+	bot.Ignore(types.ActionGetStatus)
+	srv.SetCallTimeout(50 * time.Millisecond)
+	if _, err := srv.CallAPI(types.ActionGetStatus, nil); !errors.Is(err, server.ErrCallTimeout) {
+		t.Errorf("CallAPI() on ignored action error = %v, want ErrCallTimeout", err)
+	}
+}
+
+func TestInjectAndAssertMessages(t *testing.T) {
+	srv := server.NewWSServer("")
+	selfIDs := make(chan int64, 1)
+	err := event.RegisterFunc(srv.GetDispatcher(), "ping", 0, func(ctx *event.Context[*types.MessageEvent]) error {
+		switch ctx.Event.RawMessage {
+		case "/ping":
+			selfIDs <- ctx.Event.SelfID
+			_, err := ctx.ReplyText("Pong!")
+			return err
+		case "/whisper":
+			_, err := ctx.SendPrivateMsg(ctx.Event.UserID, message.Text("psst"))
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	bot := onebottest.Start(t, srv, onebottest.WithSelfID(20000))
+
+	bot.Inject(event.GroupMessage(123, 10001, "/ping"))
+	call := bot.AssertGroupMessage(123, "Pong!")
+	if groupID, _ := call.Target(); groupID != 123 {
+		t.Errorf("Target() group = %d, want 123", groupID)
+	}
+	// 注入时补全机器人 QQ 号
+	if selfID := <-selfIDs; selfID != 20000 {
+		t.Errorf("injected event self_id = %d, want 20000", selfID)
+	}
+
+	bot.Inject(event.PrivateMessage(10002, "/whisper"))
+	bot.AssertPrivateMessage(10002, "psst")
+
+	bot.Reset()
+	bot.Inject(event.GroupMessage(123, 10001, "hello"))
+	bot.AssertNoCalls(types.ActionSendGroupMsg, 100*time.Millisecond)
+}
+
+func TestAssertionsReportFailures(t *testing.T) {
+	srv := server.NewWSServer("")
+	rec := &recorder{TB: t}
+	bot := onebottest.Start(rec, srv)
+
+	if _, err := srv.SendGroupMsg(123, message.Text("Pong!")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		assert func()
+		failed bool
+	}{
+		{name: "matching group message", assert: func() { bot.AssertGroupMessage(123, "Pong!") }},
+		{name: "wrong group", assert: func() { bot.AssertGroupMessage(124, "Pong!") }, failed: true},
+		{name: "wrong text", assert: func() { bot.AssertGroupMessage(123, "Ping!") }, failed: true},
+		{name: "not a private message", assert: func() { bot.AssertPrivateMessage(123, "Pong!") }, failed: true},
+		{name: "unexpected call", assert: func() { bot.AssertNoCalls(types.ActionSendGroupMsg, 10*time.Millisecond) }, failed: true},
+		{name: "no such call", assert: func() { bot.AssertNoCalls(types.ActionSetGroupKick, 10*time.Millisecond) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec.errors = nil
+			tt.assert()
+			if failed := len(rec.errors) > 0; failed != tt.failed {
+				t.Errorf("assertion failed = %v, want %v: %v", failed, tt.failed, rec.errors)
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+// Package outbound 提供发出消息的拦截器链
+//
+// WSServer 的 SendPrivateMsg、SendGroupMsg、SendMsg、SendGroupForwardMsg、SendPrivateForwardMsg 和 DeleteMsg
+// （包括 Context 便捷方法和管理 API）在调用 OneBot API 之前依次经过注册的拦截器，拦截器可以查看、修改、延迟或拒绝发出的消息：
+//
+//	wsServer.UseOutbound(func(next outbound.SendFunc) outbound.SendFunc {
+//		return func(msg *outbound.Message) (*types.SendMessageResponse, error) {
+//			if msg.IsSend() && mutedGroups[msg.Params.GroupID] {
+//				return nil, outbound.Veto("group is muted")
+//			}
+//			return next(msg)
+//		}
+//	})
+//
+// 通过 CallAPI 调用以上 action（包括管理 API 的 POST /admin/api/:action）同样经过拦截器，
+// params 中的字符串消息按 CQ 码解析为消息段。
+package outbound
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	types "onebot-go2/pkg/const"
+)
+
+// ErrVetoed 消息被拦截器拒绝发送
+var ErrVetoed = errors.New("outgoing message vetoed")
+
+// Veto 返回拒绝发送的错误，调用方可以通过 errors.Is(err, outbound.ErrVetoed) 判断
+func Veto(reason string) error {
+	return fmt.Errorf("%w: %s", ErrVetoed, reason)
+}
+
+// Message 一次发出的消息或撤回
+// 拦截器可以修改 Params（目标和消息内容），修改后的内容会被发送并记录到消息历史
+type Message struct {
+	context.Context
+
+	Action    string                  // send_private_msg、send_group_msg、send_msg、两个合并转发 action 或 delete_msg
+	SelfID    int64                   // 发出消息的机器人，未绑定机器人时为最近建立的连接
+	Params    types.SendMessageParams // 发送的目标和内容，MessageType 总是已填充；合并转发时 Message 为 node 消息段
+	MessageID int32                   // delete_msg 撤回的消息 ID
+}
+
+// IsSend 是否为发送消息（而不是撤回）
+func (m *Message) IsSend() bool {
+	return m.Action != types.ActionDeleteMsg
+}
+
+// IsForward 是否为发送合并转发消息（send_group_forward_msg / send_private_forward_msg）
+func (m *Message) IsForward() bool {
+	return m.Action == types.ActionSendGroupForwardMsg || m.Action == types.ActionSendPrivateForwardMsg
+}
+
+// IsGroup 目标是否为群
+func (m *Message) IsGroup() bool {
+	return m.Params.MessageType == types.MessageTypeGroup
+}
+
+// Target 返回目标群号或用户 QQ 号
+func (m *Message) Target() int64 {
+	if m.IsGroup() {
+		return m.Params.GroupID
+	}
+	return m.Params.UserID
+}
+
+// SendFunc 发送一条消息，撤回时返回的响应为 nil
+type SendFunc func(msg *Message) (*types.SendMessageResponse, error)
+
+// Interceptor 拦截器，与 event.Middleware 类似，不调用 next 即拒绝发送
+type Interceptor func(next SendFunc) SendFunc
+
+// Chain 拦截器链，可以在运行中注册拦截器
+type Chain struct {
+	mu           sync.RWMutex
+	interceptors []Interceptor
+}
+
+// NewChain 创建拦截器链
+func NewChain() *Chain {
+	return &Chain{}
+}
+
+// Use 注册拦截器，先注册的拦截器在外层（先看到消息，最后看到结果）
+func (c *Chain) Use(interceptor Interceptor) *Chain {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.interceptors = append(c.interceptors, interceptor)
+	return c
+}
+
+// Len 返回已注册的拦截器数量
+func (c *Chain) Len() int {
+	if c == nil {
+		return 0
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.interceptors)
+}
+
+// Then 返回经过全部拦截器后调用 final 的发送函数
+func (c *Chain) Then(final SendFunc) SendFunc {
+	if c == nil {
+		return final
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	send := final
+	// 从后向前应用拦截器
+	for i := len(c.interceptors) - 1; i >= 0; i-- {
+		send = c.interceptors[i](send)
+	}
+	return send
+}
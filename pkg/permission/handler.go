@@ -0,0 +1,58 @@
+package permission
+
+import (
+	"onebot-go2/pkg/event"
+)
+
+// Option 权限包装选项
+type Option func(*options)
+
+type options struct {
+	silent bool
+}
+
+// Silent 权限不足时不回复，适用于监听所有消息的处理器
+func Silent() Option {
+	return func(o *options) {
+		o.silent = true
+	}
+}
+
+// guardedHandler 带权限检查的处理器
+type guardedHandler[T any] struct {
+	event.EventHandler[T]
+	manager  *Manager
+	required Level
+	silent   bool
+}
+
+// Require 为处理器附加权限要求，权限不足时跳过处理器并回复拒绝消息
+func Require[T any](m *Manager, required Level, handler event.EventHandler[T], opts ...Option) event.EventHandler[T] {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return &guardedHandler[T]{
+		EventHandler: handler,
+		manager:      m,
+		required:     required,
+		silent:       o.silent,
+	}
+}
+
+// RequireFunc 为处理函数附加权限要求
+func RequireFunc[T any](m *Manager, required Level, name string, priority int, handler event.HandlerFunc[T], opts ...Option) event.EventHandler[T] {
+	return Require(m, required, event.NewSimpleHandler(name, priority, handler), opts...)
+}
+
+func (h *guardedHandler[T]) Handle(ctx *event.Context[T]) error {
+	if Allow(h.manager, ctx, h.required) {
+		return h.EventHandler.Handle(ctx)
+	}
+
+	ctx.Logger().Info("Handler denied", "required", h.required.String())
+	if h.silent {
+		return nil
+	}
+	return Deny(h.manager, ctx, h.required)
+}
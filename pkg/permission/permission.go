@@ -0,0 +1,357 @@
+package permission
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	types "onebot-go2/pkg/const"
+	"onebot-go2/pkg/event"
+)
+
+// Level 权限等级，数值越大权限越高
+type Level int
+
+const (
+	LevelMember     Level = iota // 普通成员（任何人）
+	LevelGroupAdmin              // 群管理员或机器人管理员
+	LevelGroupOwner              // 群主
+	LevelSuperuser               // 超级用户（配置文件中的 admins）
+)
+
+// String 返回权限等级的显示名称
+func (l Level) String() string {
+	switch l {
+	case LevelMember:
+		return "成员"
+	case LevelGroupAdmin:
+		return "管理员"
+	case LevelGroupOwner:
+		return "群主"
+	case LevelSuperuser:
+		return "超级用户"
+	default:
+		return fmt.Sprintf("Level(%d)", int(l))
+	}
+}
+
+// ParseLevel 从名称解析权限等级
+func ParseLevel(name string) (Level, error) {
+	switch name {
+	case "member", "":
+		return LevelMember, nil
+	case "admin":
+		return LevelGroupAdmin, nil
+	case "owner":
+		return LevelGroupOwner, nil
+	case "superuser":
+		return LevelSuperuser, nil
+	default:
+		return LevelMember, fmt.Errorf("unknown permission level %q", name)
+	}
+}
+
+// DefaultDenyMessage 默认的权限不足回复，%s 为所需权限等级
+const DefaultDenyMessage = "权限不足：该操作需要%s权限"
+
+// Manager 权限管理器
+// 超级用户来自配置，群主/群管理员根据 Sender.Role 或群成员信息判断，
+// 机器人管理员可以在运行时按群授予和撤销，通过 LoadAdmins 指定文件后重启保持
+type Manager struct {
+	mu          sync.RWMutex
+	superusers  map[int64]bool
+	botAdmins   map[int64]map[int64]bool // groupID -> userID 集合
+	path        string                   // 机器人管理员的保存路径，为空时不持久化
+	denyMessage string
+	logger      *slog.Logger
+}
+
+// NewManager 创建权限管理器
+func NewManager(superusers []int64) *Manager {
+	m := &Manager{
+		botAdmins:   make(map[int64]map[int64]bool),
+		denyMessage: DefaultDenyMessage,
+		logger:      slog.Default().With("component", "permission"),
+	}
+	m.SetSuperusers(superusers)
+	return m
+}
+
+// SetLogger 设置日志记录器，默认使用创建时的 slog.Default()
+func (m *Manager) SetLogger(logger *slog.Logger) *Manager {
+	m.logger = logger.With("component", "permission")
+	return m
+}
+
+// SetSuperusers 替换超级用户列表
+func (m *Manager) SetSuperusers(superusers []int64) {
+	set := make(map[int64]bool, len(superusers))
+	for _, userID := range superusers {
+		set[userID] = true
+	}
+
+	m.mu.Lock()
+	m.superusers = set
+	m.mu.Unlock()
+}
+
+// IsSuperuser 判断是否为超级用户
+func (m *Manager) IsSuperuser(userID int64) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.superusers[userID]
+}
+
+// SetDenyMessage 设置权限不足时的回复，%s 会被替换为所需权限等级，空字符串表示不回复
+func (m *Manager) SetDenyMessage(message string) {
+	m.mu.Lock()
+	m.denyMessage = message
+	m.mu.Unlock()
+}
+
+// DenyMessage 返回指定等级的权限不足回复，未配置时返回空字符串
+func (m *Manager) DenyMessage(required Level) string {
+	m.mu.RLock()
+	message := m.denyMessage
+	m.mu.RUnlock()
+
+	if !strings.Contains(message, "%s") {
+		return message
+	}
+	return fmt.Sprintf(message, required)
+}
+
+// ============ 机器人管理员 ============
+
+// LoadAdmins 从 path 加载已保存的机器人管理员，之后的授予和撤销都保存到该文件
+// 文件不存在时从空列表开始
+func (m *Manager) LoadAdmins(path string) error {
+	admins := make(map[int64]map[int64]bool)
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return fmt.Errorf("failed to read bot admins: %w", err)
+	default:
+		var saved map[string][]int64
+		if err := json.Unmarshal(data, &saved); err != nil {
+			return fmt.Errorf("failed to parse bot admins %s: %w", path, err)
+		}
+		for group, users := range saved {
+			groupID, err := strconv.ParseInt(group, 10, 64)
+			if err != nil {
+				return fmt.Errorf("failed to parse bot admins %s: invalid group %q", path, group)
+			}
+			for _, userID := range users {
+				if admins[groupID] == nil {
+					admins[groupID] = make(map[int64]bool)
+				}
+				admins[groupID][userID] = true
+			}
+		}
+	}
+
+	m.mu.Lock()
+	m.path = path
+	m.botAdmins = admins
+	m.mu.Unlock()
+	return nil
+}
+
+// GrantAdmin 授予用户在指定群的机器人管理员权限
+// 保存失败时返回错误，权限不变
+func (m *Manager) GrantAdmin(groupID, userID int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	next := cloneAdmins(m.botAdmins)
+	if next[groupID] == nil {
+		next[groupID] = make(map[int64]bool)
+	}
+	next[groupID][userID] = true
+	if err := m.commit(next); err != nil {
+		return err
+	}
+	m.logger.Info("Granted bot admin", "group_id", groupID, "user_id", userID)
+	return nil
+}
+
+// RevokeAdmin 撤销用户在指定群的机器人管理员权限，返回用户之前是否为管理员
+// 保存失败时返回错误，权限不变
+func (m *Manager) RevokeAdmin(groupID, userID int64) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.botAdmins[groupID][userID] {
+		return false, nil
+	}
+	next := cloneAdmins(m.botAdmins)
+	delete(next[groupID], userID)
+	if len(next[groupID]) == 0 {
+		delete(next, groupID)
+	}
+	if err := m.commit(next); err != nil {
+		return false, err
+	}
+	m.logger.Info("Revoked bot admin", "group_id", groupID, "user_id", userID)
+	return true, nil
+}
+
+// commit 保存新的机器人管理员，成功后才替换内存中的状态，调用方需持有锁
+func (m *Manager) commit(next map[int64]map[int64]bool) error {
+	if err := m.save(next); err != nil {
+		return err
+	}
+	m.botAdmins = next
+	return nil
+}
+
+// save 将机器人管理员写入文件，格式为 {"群号": [QQ 号...]}
+// 先写临时文件再重命名，避免写入中断导致文件损坏
+func (m *Manager) save(admins map[int64]map[int64]bool) error {
+	if m.path == "" {
+		return nil
+	}
+
+	saved := make(map[string][]int64, len(admins))
+	for groupID, users := range admins {
+		list := make([]int64, 0, len(users))
+		for userID := range users {
+			list = append(list, userID)
+		}
+		sort.Slice(list, func(i, j int) bool { return list[i] < list[j] })
+		saved[strconv.FormatInt(groupID, 10)] = list
+	}
+	data, err := json.MarshalIndent(saved, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(m.path), 0o755); err != nil {
+		return fmt.Errorf("failed to save bot admins: %w", err)
+	}
+	tmp := m.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to save bot admins: %w", err)
+	}
+	if err := os.Rename(tmp, m.path); err != nil {
+		return fmt.Errorf("failed to save bot admins: %w", err)
+	}
+	return nil
+}
+
+// cloneAdmins 深拷贝机器人管理员
+func cloneAdmins(admins map[int64]map[int64]bool) map[int64]map[int64]bool {
+	out := make(map[int64]map[int64]bool, len(admins))
+	for groupID, users := range admins {
+		out[groupID] = make(map[int64]bool, len(users))
+		for userID := range users {
+			out[groupID][userID] = true
+		}
+	}
+	return out
+}
+
+// IsBotAdmin 判断用户是否为指定群的机器人管理员
+func (m *Manager) IsBotAdmin(groupID, userID int64) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.botAdmins[groupID][userID]
+}
+
+// Admins 返回指定群的机器人管理员列表
+func (m *Manager) Admins(groupID int64) []int64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	result := make([]int64, 0, len(m.botAdmins[groupID]))
+	for userID := range m.botAdmins[groupID] {
+		result = append(result, userID)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i] < result[j] })
+	return result
+}
+
+// ============ 权限判断 ============
+
+// LevelOf 根据群号、用户和群角色计算权限等级
+// role 为 OneBot 的 owner/admin/member，私聊时 groupID 为 0
+func (m *Manager) LevelOf(groupID, userID int64, role string) Level {
+	if m.IsSuperuser(userID) {
+		return LevelSuperuser
+	}
+	switch role {
+	case "owner":
+		return LevelGroupOwner
+	case "admin":
+		return LevelGroupAdmin
+	}
+	if groupID != 0 && m.IsBotAdmin(groupID, userID) {
+		return LevelGroupAdmin
+	}
+	return LevelMember
+}
+
+// Resolve 计算事件发送者的权限等级
+// 消息事件优先使用 Sender.Role，缺失时查询群成员信息
+func Resolve[T any](m *Manager, ctx *event.Context[T]) Level {
+	groupID, userID, role := subject(any(ctx.Event))
+	if userID == 0 {
+		return LevelMember
+	}
+
+	if role == "" && groupID != 0 && !m.IsSuperuser(userID) {
+		if server := ctx.GetServer(); server != nil {
+			if info, err := server.GetGroupMemberInfo(groupID, userID, false); err == nil {
+				role = info.Role
+			} else {
+				ctx.Logger().Warn("Failed to get member info", "action", types.ActionGetGroupMemberInfo, "error", err)
+			}
+		}
+	}
+
+	return m.LevelOf(groupID, userID, role)
+}
+
+// Allow 判断事件发送者是否具有所需权限
+func Allow[T any](m *Manager, ctx *event.Context[T], required Level) bool {
+	if required <= LevelMember {
+		return true
+	}
+	return Resolve(m, ctx) >= required
+}
+
+// Deny 回复权限不足消息（未配置回复或事件不是消息时不回复）
+func Deny[T any](m *Manager, ctx *event.Context[T], required Level) error {
+	text := m.DenyMessage(required)
+	if text == "" {
+		return nil
+	}
+	if _, ok := ctx.GetMessageEvent(); !ok {
+		return nil
+	}
+	_, err := ctx.ReplyText(text)
+	return err
+}
+
+// subject 从事件中提取群号、用户和群角色
+func subject(evt interface{}) (groupID, userID int64, role string) {
+	switch e := evt.(type) {
+	case *types.MessageEvent:
+		if e.MessageType == types.MessageTypeGroup {
+			return e.GroupID, e.UserID, e.Sender.Role
+		}
+		return 0, e.UserID, ""
+	case *types.NoticeEvent:
+		return e.GroupID, e.UserID, ""
+	case *types.RequestEvent:
+		return e.GroupID, e.UserID, ""
+	}
+	return 0, 0, ""
+}
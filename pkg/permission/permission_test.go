@@ -0,0 +1,266 @@
+package permission
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	types "onebot-go2/pkg/const"
+	"onebot-go2/pkg/event"
+)
+
+func TestResolve(t *testing.T) {
+	const (
+		groupID   = 100
+		superuser = 1
+		botAdmin  = 2
+		member    = 3
+	)
+
+	tests := []struct {
+		name       string
+		event      *types.MessageEvent
+		memberRole string // 群成员信息中的角色，为空时不设定成员信息
+		want       Level
+		lookups    int // 期望查询群成员信息的次数
+	}{
+		{
+			name:  "superuser overrides role",
+			event: groupMessage(groupID, superuser, "member"),
+			want:  LevelSuperuser,
+		},
+		{
+			name:  "superuser without role skips lookup",
+			event: groupMessage(groupID, superuser, ""),
+			want:  LevelSuperuser,
+		},
+		{
+			name:  "owner from sender role",
+			event: groupMessage(groupID, member, "owner"),
+			want:  LevelGroupOwner,
+		},
+		{
+			name:  "admin from sender role",
+			event: groupMessage(groupID, member, "admin"),
+			want:  LevelGroupAdmin,
+		},
+		{
+			name:  "bot admin",
+			event: groupMessage(groupID, botAdmin, "member"),
+			want:  LevelGroupAdmin,
+		},
+		{
+			name:  "group owner is not lowered by bot admin",
+			event: groupMessage(groupID, botAdmin, "owner"),
+			want:  LevelGroupOwner,
+		},
+		{
+			name:  "bot admin is per group",
+			event: groupMessage(groupID+1, botAdmin, "member"),
+			want:  LevelMember,
+		},
+		{
+			name:       "missing role is looked up",
+			event:      groupMessage(groupID, member, ""),
+			memberRole: "owner",
+			want:       LevelGroupOwner,
+			lookups:    1,
+		},
+		{
+			name:    "failed lookup falls back to member",
+			event:   groupMessage(groupID, member, ""),
+			want:    LevelMember,
+			lookups: 1,
+		},
+		{
+			name:  "private message ignores bot admin",
+			event: event.PrivateMessage(botAdmin, "hi"),
+			want:  LevelMember,
+		},
+		{
+			name:  "private message from superuser",
+			event: event.PrivateMessage(superuser, "hi"),
+			want:  LevelSuperuser,
+		},
+	}
+
+	m := NewManager([]int64{superuser})
+	if err := m.GrantAdmin(groupID, botAdmin); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts []event.TestOption
+			if tt.memberRole != "" {
+				opts = append(opts, event.WithGroupMember(types.GroupMemberInfo{
+					GroupID: tt.event.GroupID,
+					UserID:  tt.event.UserID,
+					Role:    tt.memberRole,
+				}))
+			}
+			ctx, srv := event.NewTestContext(tt.event, opts...)
+
+			if got := Resolve(m, ctx); got != tt.want {
+				t.Errorf("Resolve() = %v, want %v", got, tt.want)
+			}
+			if got := len(srv.CallsTo(types.ActionGetGroupMemberInfo)); got != tt.lookups {
+				t.Errorf("member info lookups = %d, want %d", got, tt.lookups)
+			}
+		})
+	}
+}
+
+func TestRevokeAdmin(t *testing.T) {
+	m := NewManager(nil)
+	m.GrantAdmin(100, 2)
+	m.GrantAdmin(100, 1)
+
+	if got := m.Admins(100); len(got) != 2 || got[0] != 1 || got[1] != 2 {
+		t.Fatalf("Admins() = %v, want [1 2]", got)
+	}
+	if revoked, err := m.RevokeAdmin(100, 2); !revoked || err != nil {
+		t.Errorf("RevokeAdmin() = %v, %v for an existing admin", revoked, err)
+	}
+	if revoked, err := m.RevokeAdmin(100, 2); revoked || err != nil {
+		t.Errorf("RevokeAdmin() = %v, %v for a revoked admin", revoked, err)
+	}
+	if m.IsBotAdmin(100, 2) {
+		t.Error("IsBotAdmin() = true after revoke")
+	}
+}
+
+func TestAdminsPersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data", "bot_admins.json")
+
+	m := NewManager(nil)
+	if err := m.LoadAdmins(path); err != nil {
+		t.Fatalf("LoadAdmins() on a missing file: %v", err)
+	}
+	for _, admin := range [][2]int64{{100, 2}, {100, 1}, {200, 3}, {300, 4}} {
+		if err := m.GrantAdmin(admin[0], admin[1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := m.RevokeAdmin(300, 4); err != nil {
+		t.Fatal(err)
+	}
+
+	// 模拟重启
+	restarted := NewManager(nil)
+	if err := restarted.LoadAdmins(path); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		groupID int64
+		want    []int64
+	}{
+		{groupID: 100, want: []int64{1, 2}},
+		{groupID: 200, want: []int64{3}},
+		{groupID: 300, want: []int64{}},
+	}
+	for _, tt := range tests {
+		if got := restarted.Admins(tt.groupID); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Admins(%d) after reload = %v, want %v", tt.groupID, got, tt.want)
+		}
+	}
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("temporary file left behind: %v", err)
+	}
+}
+
+func TestAdminsSaveFailure(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bot_admins.json")
+	m := NewManager(nil)
+	if err := m.LoadAdmins(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.GrantAdmin(100, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	// 临时文件路径被目录占用，写入失败
+	if err := os.Mkdir(path+".tmp", 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.GrantAdmin(100, 2); err == nil {
+		t.Error("GrantAdmin() should fail when saving fails")
+	}
+	if m.IsBotAdmin(100, 2) {
+		t.Error("failed grant should not take effect")
+	}
+	if revoked, err := m.RevokeAdmin(100, 1); err == nil || revoked {
+		t.Errorf("RevokeAdmin() = %v, %v; want a save error", revoked, err)
+	}
+	if !m.IsBotAdmin(100, 1) {
+		t.Error("failed revoke should not take effect")
+	}
+}
+
+func TestLoadAdminsInvalid(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{name: "not json", content: "admins"},
+		{name: "invalid group", content: `{"abc": [1]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.name+".json")
+			if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if err := NewManager(nil).LoadAdmins(path); err == nil {
+				t.Error("LoadAdmins() should fail")
+			}
+		})
+	}
+}
+
+func TestRequire(t *testing.T) {
+	m := NewManager(nil)
+	tests := []struct {
+		name    string
+		role    string
+		silent  bool
+		handled bool
+		replies int
+	}{
+		{name: "allowed", role: "admin", handled: true},
+		{name: "denied replies", role: "member", replies: 1},
+		{name: "denied silently", role: "member", silent: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handled := false
+			var opts []Option
+			if tt.silent {
+				opts = append(opts, Silent())
+			}
+			h := RequireFunc(m, LevelGroupAdmin, "test", 0, func(*event.Context[*types.MessageEvent]) error {
+				handled = true
+				return nil
+			}, opts...)
+
+			ctx, srv := event.NewTestContext(groupMessage(100, 3, tt.role))
+			if err := h.Handle(ctx); err != nil {
+				t.Fatalf("Handle() error = %v", err)
+			}
+			if handled != tt.handled {
+				t.Errorf("handled = %v, want %v", handled, tt.handled)
+			}
+			if got := len(srv.Sent()); got != tt.replies {
+				t.Errorf("replies = %d, want %d", got, tt.replies)
+			}
+		})
+	}
+}
+
+func groupMessage(groupID, userID int64, role string) *types.MessageEvent {
+	msg := event.GroupMessage(groupID, userID, "hi")
+	msg.Sender.Role = role
+	return msg
+}
@@ -0,0 +1,356 @@
+package plugin
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"strings"
+	"sync"
+
+	"onebot-go2/pkg/command"
+	types "onebot-go2/pkg/const"
+	"onebot-go2/pkg/event"
+	"onebot-go2/pkg/schedule"
+)
+
+// State 插件状态
+type State int
+
+const (
+	StateRegistered  State = iota // 已注册，未初始化
+	StateInitialized              // 已初始化，未启动
+	StateRunning                  // 运行中
+	StateStopped                  // 已停止
+	StateFailed                   // 初始化或启动失败
+)
+
+func (s State) String() string {
+	switch s {
+	case StateRegistered:
+		return "registered"
+	case StateInitialized:
+		return "initialized"
+	case StateRunning:
+		return "running"
+	case StateStopped:
+		return "stopped"
+	case StateFailed:
+		return "failed"
+	}
+	return fmt.Sprintf("State(%d)", int(s))
+}
+
+// entry 已注册的插件
+type entry struct {
+	plugin  Plugin
+	bot     *Bot
+	section interface{}
+	state   State
+}
+
+// Manager 插件管理器
+// 负责按依赖顺序初始化、启动插件，并在关闭时按相反顺序停止
+type Manager struct {
+	dispatcher *event.Dispatcher
+	server     event.ServerInterface
+	commands   *command.Registry
+	scheduler  *schedule.Scheduler
+	logger     *slog.Logger
+
+	mu       sync.RWMutex
+	entries  map[string]*entry
+	names    []string // 注册顺序
+	order    []string // 依赖顺序，Init 后确定
+	sections map[string]interface{}
+}
+
+// NewManager 创建插件管理器
+func NewManager(dispatcher *event.Dispatcher, server event.ServerInterface) *Manager {
+	return &Manager{
+		dispatcher: dispatcher,
+		server:     server,
+		entries:    make(map[string]*entry),
+		logger:     slog.Default().With("component", "plugin"),
+	}
+}
+
+// SetLogger 设置日志记录器，默认使用创建时的 slog.Default()
+// 插件的 bot.Logger() 在此基础上附加 plugin 字段
+func (m *Manager) SetLogger(logger *slog.Logger) *Manager {
+	m.logger = logger.With("component", "plugin")
+	return m
+}
+
+// SetCommands 设置插件共享的命令注册表，并将 CommandFilter 设置为注册表的过滤器
+// 需要同时按其他条件过滤命令时，设置的过滤器应包含 CommandFilter 的判断（见 Switches.CommandFilter）
+func (m *Manager) SetCommands(registry *command.Registry) *Manager {
+	m.commands = registry
+	registry.SetFilter(m.CommandFilter())
+	return m
+}
+
+// SetScheduler 设置插件共享的定时任务调度器
+func (m *Manager) SetScheduler(scheduler *schedule.Scheduler) *Manager {
+	m.scheduler = scheduler
+	return m
+}
+
+// SetConfig 设置各插件的配置段（config.yaml 中的 plugins），需要在 Init 之前调用
+func (m *Manager) SetConfig(sections map[string]interface{}) *Manager {
+	m.mu.Lock()
+	m.sections = sections
+	m.mu.Unlock()
+	return m
+}
+
+// Register 注册插件，需要在 Init 之前调用
+func (m *Manager) Register(plugins ...Plugin) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.order != nil {
+		return errors.New("plugins already initialized")
+	}
+	for _, p := range plugins {
+		name := p.Name()
+		if name == "" || strings.ContainsAny(name, " \t") {
+			return fmt.Errorf("invalid plugin name %q", name)
+		}
+		if _, exists := m.entries[name]; exists {
+			return fmt.Errorf("plugin %s already registered", name)
+		}
+		m.entries[name] = &entry{plugin: p}
+		m.names = append(m.names, name)
+	}
+	return nil
+}
+
+// Lookup 按名称查找插件
+func (m *Manager) Lookup(name string) (Plugin, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	e, ok := m.entries[name]
+	if !ok {
+		return nil, false
+	}
+	return e.plugin, true
+}
+
+// Names 返回所有插件名称，Init 后按依赖顺序排列
+func (m *Manager) Names() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.order != nil {
+		return append([]string{}, m.order...)
+	}
+	return append([]string{}, m.names...)
+}
+
+// State 返回插件状态
+func (m *Manager) State(name string) (State, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	e, ok := m.entries[name]
+	if !ok {
+		return 0, false
+	}
+	return e.state, true
+}
+
+// Running 判断命令或处理器所属的插件是否在运行
+// 不是由该管理器管理的插件名（包括空名称）视为运行中
+func (m *Manager) Running(name string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	e, ok := m.entries[name]
+	return !ok || e.state == StateRunning
+}
+
+// CommandFilter 返回命令注册表使用的过滤器，所属插件未运行（未启动、已停止或启动失败）的命令当作不存在
+func (m *Manager) CommandFilter() command.Filter {
+	return func(msg *types.MessageEvent, cmd *command.Command) bool {
+		return m.Running(cmd.Plugin)
+	}
+}
+
+// Group 返回插件的处理器分组，插件未初始化时返回 nil
+func (m *Manager) Group(name string) *event.Group {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if e, ok := m.entries[name]; ok && e.bot != nil {
+		return e.bot.group
+	}
+	return nil
+}
+
+// Init 按依赖顺序初始化所有插件
+// 依赖缺失或循环依赖时不初始化任何插件；某个插件初始化失败时返回错误，已初始化的插件保持不变
+func (m *Manager) Init() error {
+	m.mu.Lock()
+	order, err := m.resolve()
+	if err != nil {
+		m.mu.Unlock()
+		return err
+	}
+	m.order = order
+	for _, name := range m.names {
+		m.entries[name].section = m.sections[name]
+	}
+	m.mu.Unlock()
+
+	for _, name := range order {
+		e := m.entries[name]
+		e.bot = &Bot{
+			name:    name,
+			manager: m,
+			// 处理器在插件启动后才接收事件
+			group:  m.dispatcher.Group(name).SetEnabled(false),
+			logger: m.logger.With("plugin", name),
+		}
+		if err := e.plugin.Init(e.bot, NewConfig(e.section)); err != nil {
+			m.setState(e, StateFailed)
+			return fmt.Errorf("plugin %s: init: %w", name, err)
+		}
+		m.setState(e, StateInitialized)
+		m.logger.Info("Initialized plugin", "plugin", name)
+	}
+	return nil
+}
+
+// Start 按依赖顺序启动所有插件
+// 某个插件启动失败时按相反顺序停止已启动的插件并返回错误
+func (m *Manager) Start() error {
+	if m.order == nil {
+		return errors.New("plugins not initialized")
+	}
+	for i, name := range m.order {
+		e := m.entries[name]
+		if state, _ := m.State(name); state != StateInitialized && state != StateStopped {
+			continue
+		}
+		if err := e.plugin.Start(); err != nil {
+			m.setState(e, StateFailed)
+			m.stop(m.order[:i])
+			return fmt.Errorf("plugin %s: start: %w", name, err)
+		}
+		e.bot.group.SetEnabled(true)
+		m.setState(e, StateRunning)
+		m.logger.Info("Started plugin", "plugin", name)
+	}
+	return nil
+}
+
+// Stop 按依赖的相反顺序停止所有运行中的插件，返回所有停止错误
+func (m *Manager) Stop() error {
+	return m.stop(m.order)
+}
+
+func (m *Manager) stop(names []string) error {
+	var errs []error
+	for i := len(names) - 1; i >= 0; i-- {
+		e := m.entries[names[i]]
+		if state, _ := m.State(names[i]); state != StateRunning {
+			continue
+		}
+		e.bot.group.SetEnabled(false)
+		if m.scheduler != nil {
+			m.scheduler.RemovePrefix(names[i] + ".")
+		}
+		if err := e.plugin.Stop(); err != nil {
+			errs = append(errs, fmt.Errorf("plugin %s: stop: %w", names[i], err))
+			m.logger.Error("Failed to stop plugin", "plugin", names[i], "error", err)
+		} else {
+			m.logger.Info("Stopped plugin", "plugin", names[i])
+		}
+		m.setState(e, StateStopped)
+	}
+	return errors.Join(errs...)
+}
+
+// Reload 更新插件配置段，配置变化的插件如果实现了 Reloader 则立即应用
+func (m *Manager) Reload(sections map[string]interface{}) error {
+	m.mu.Lock()
+	m.sections = sections
+	var changed []*entry
+	for _, name := range m.order {
+		e := m.entries[name]
+		if !reflect.DeepEqual(e.section, sections[name]) {
+			e.section = sections[name]
+			changed = append(changed, e)
+		}
+	}
+	m.mu.Unlock()
+
+	var errs []error
+	for _, e := range changed {
+		reloader, ok := e.plugin.(Reloader)
+		if !ok {
+			m.logger.Warn("Plugin config changed, restart required to take effect", "plugin", e.bot.name)
+			continue
+		}
+		if err := reloader.Reload(NewConfig(e.section)); err != nil {
+			errs = append(errs, fmt.Errorf("plugin %s: reload: %w", e.bot.name, err))
+			continue
+		}
+		m.logger.Info("Reloaded plugin config", "plugin", e.bot.name)
+	}
+	return errors.Join(errs...)
+}
+
+func (m *Manager) setState(e *entry, state State) {
+	m.mu.Lock()
+	e.state = state
+	m.mu.Unlock()
+}
+
+// resolve 按依赖关系对插件排序，无依赖关系的插件保持注册顺序
+func (m *Manager) resolve() ([]string, error) {
+	for name := range m.sections {
+		if _, ok := m.entries[name]; !ok {
+			m.logger.Warn("Config for unknown plugin", "plugin", name)
+		}
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	marks := make(map[string]int, len(m.names))
+	order := make([]string, 0, len(m.names))
+
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		switch marks[name] {
+		case visited:
+			return nil
+		case visiting:
+			return fmt.Errorf("circular plugin dependency: %s -> %s", strings.Join(path, " -> "), name)
+		}
+		marks[name] = visiting
+
+		path = append(path[:len(path):len(path)], name)
+		if dep, ok := m.entries[name].plugin.(Dependent); ok {
+			for _, required := range dep.Dependencies() {
+				if _, exists := m.entries[required]; !exists {
+					return fmt.Errorf("plugin %s depends on %s, which is not registered", name, required)
+				}
+				if err := visit(required, path); err != nil {
+					return err
+				}
+			}
+		}
+
+		marks[name] = visited
+		order = append(order, name)
+		return nil
+	}
+
+	for _, name := range m.names {
+		if err := visit(name, nil); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
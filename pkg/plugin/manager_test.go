@@ -0,0 +1,241 @@
+package plugin
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"onebot-go2/pkg/command"
+	types "onebot-go2/pkg/const"
+	"onebot-go2/pkg/event"
+)
+
+// testPlugin 记录生命周期调用的插件
+type testPlugin struct {
+	Base
+	name     string
+	deps     []string
+	startErr error
+	commands []string // Init 中注册的命令
+	log      *[]string
+}
+
+func (p *testPlugin) Name() string           { return p.name }
+func (p *testPlugin) Dependencies() []string { return p.deps }
+
+func (p *testPlugin) Init(bot *Bot, cfg Config) error {
+	*p.log = append(*p.log, "init "+p.name)
+	for _, name := range p.commands {
+		if err := bot.Command(&command.Command{Name: name, Handler: noopCommand}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *testPlugin) Start() error {
+	*p.log = append(*p.log, "start "+p.name)
+	return p.startErr
+}
+
+func (p *testPlugin) Stop() error {
+	*p.log = append(*p.log, "stop "+p.name)
+	return nil
+}
+
+func TestManagerDependencyOrder(t *testing.T) {
+	tests := []struct {
+		name    string
+		plugins map[string][]string // 插件名 -> 依赖
+		names   []string            // 注册顺序
+		want    []string
+		wantErr string
+	}{
+		{
+			name:    "registration order without dependencies",
+			plugins: map[string][]string{"a": nil, "b": nil, "c": nil},
+			names:   []string{"c", "a", "b"},
+			want:    []string{"c", "a", "b"},
+		},
+		{
+			name:    "dependencies first",
+			plugins: map[string][]string{"a": {"b"}, "b": {"c"}, "c": nil},
+			names:   []string{"a", "b", "c"},
+			want:    []string{"c", "b", "a"},
+		},
+		{
+			name:    "shared dependency",
+			plugins: map[string][]string{"a": {"db"}, "b": {"db"}, "db": nil},
+			names:   []string{"a", "b", "db"},
+			want:    []string{"db", "a", "b"},
+		},
+		{
+			name:    "missing dependency",
+			plugins: map[string][]string{"a": {"missing"}},
+			names:   []string{"a"},
+			wantErr: "a depends on missing",
+		},
+		{
+			name:    "cycle",
+			plugins: map[string][]string{"a": {"b"}, "b": {"c"}, "c": {"a"}},
+			names:   []string{"a", "b", "c"},
+			wantErr: "circular plugin dependency: a -> b -> c -> a",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var log []string
+			m := NewManager(event.NewDispatcher(), event.NewTestServer())
+			for _, name := range tt.names {
+				if err := m.Register(&testPlugin{name: name, deps: tt.plugins[name], log: &log}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			err := m.Init()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Init() error = %v, want %q", err, tt.wantErr)
+				}
+				if len(log) != 0 {
+					t.Errorf("no plugin should be initialized, got %v", log)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Init() error = %v", err)
+			}
+			if got := m.Names(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Names() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestManagerLifecycle(t *testing.T) {
+	var log []string
+	m := NewManager(event.NewDispatcher(), event.NewTestServer())
+	err := m.Register(
+		&testPlugin{name: "app", deps: []string{"db"}, log: &log},
+		&testPlugin{name: "db", log: &log},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Register(&testPlugin{name: "db", log: &log}); err == nil {
+		t.Error("Register() should reject duplicate names")
+	}
+
+	if err := m.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if m.Group("app").Enabled() {
+		t.Error("handlers should be disabled before Start")
+	}
+	if err := m.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if !m.Group("app").Enabled() {
+		t.Error("handlers should be enabled after Start")
+	}
+	if err := m.Stop(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"init db", "init app", "start db", "start app", "stop app", "stop db"}
+	if !reflect.DeepEqual(log, want) {
+		t.Errorf("lifecycle = %v, want %v", log, want)
+	}
+	if state, _ := m.State("app"); state != StateStopped {
+		t.Errorf("State(app) = %v, want stopped", state)
+	}
+}
+
+func TestManagerStartFailure(t *testing.T) {
+	var log []string
+	m := NewManager(event.NewDispatcher(), event.NewTestServer())
+	err := m.Register(
+		&testPlugin{name: "a", log: &log},
+		&testPlugin{name: "b", log: &log},
+		&testPlugin{name: "c", startErr: errors.New("boom"), log: &log},
+		&testPlugin{name: "d", log: &log},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Init(); err != nil {
+		t.Fatal(err)
+	}
+	log = nil
+
+	if err := m.Start(); err == nil || !strings.Contains(err.Error(), "plugin c: start") {
+		t.Fatalf("Start() error = %v", err)
+	}
+	// 已启动的插件按相反顺序停止，后面的插件不再启动
+	want := []string{"start a", "start b", "start c", "stop b", "stop a"}
+	if !reflect.DeepEqual(log, want) {
+		t.Errorf("lifecycle = %v, want %v", log, want)
+	}
+	if state, _ := m.State("c"); state != StateFailed {
+		t.Errorf("State(c) = %v, want failed", state)
+	}
+	if state, _ := m.State("d"); state != StateInitialized {
+		t.Errorf("State(d) = %v, want initialized", state)
+	}
+}
+
+func TestManagerCommandsFollowLifecycle(t *testing.T) {
+	var log []string
+	commands := command.NewRegistry("/")
+	m := NewManager(event.NewDispatcher(), event.NewTestServer()).SetCommands(commands)
+	err := m.Register(
+		&testPlugin{name: "a", commands: []string{"ping"}, log: &log},
+		&testPlugin{name: "b", commands: []string{"boom"}, startErr: errors.New("boom"), log: &log},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 不属于插件的命令不受插件状态影响
+	if err := commands.Register(&command.Command{Name: "help", Handler: noopCommand}); err != nil {
+		t.Fatal(err)
+	}
+
+	check := func(step string, want map[string]bool) {
+		t.Helper()
+		for text, ok := range want {
+			args, err := commands.Parse(event.GroupMessage(100, 10001, text))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := args != nil; got != ok {
+				t.Errorf("%s: %s matched = %v, want %v", step, text, got, ok)
+			}
+		}
+	}
+
+	if err := m.Init(); err != nil {
+		t.Fatal(err)
+	}
+	check("before start", map[string]bool{"/ping": false, "/boom": false, "/help": true})
+
+	// b 启动失败，已启动的 a 被停止
+	if err := m.Start(); err == nil {
+		t.Fatal("Start() should fail")
+	}
+	check("after failed start", map[string]bool{"/ping": false, "/boom": false, "/help": true})
+
+	// 再次启动时跳过失败的 b
+	if err := m.Start(); err != nil {
+		t.Fatal(err)
+	}
+	check("running", map[string]bool{"/ping": true, "/boom": false, "/help": true})
+
+	if err := m.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	check("stopped", map[string]bool{"/ping": false, "/boom": false, "/help": true})
+}
+
+func noopCommand(ctx *event.Context[*types.MessageEvent], args *command.Args) error { return nil }
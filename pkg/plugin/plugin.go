@@ -0,0 +1,154 @@
+package plugin
+
+import (
+	"errors"
+	"log/slog"
+
+	"github.com/goccy/go-yaml"
+
+	"onebot-go2/pkg/command"
+	"onebot-go2/pkg/event"
+	"onebot-go2/pkg/schedule"
+	"onebot-go2/pkg/storage"
+)
+
+// Plugin 插件接口
+//
+// 生命周期：Init（注册处理器、读取配置）-> Start（启动后台任务）-> Stop（释放资源）。
+// Init 注册的处理器和命令在 Start 成功后才会收到事件，Stop 前停止接收。
+type Plugin interface {
+	// Name 插件名称，全局唯一，同时也是配置中 plugins 下的配置段名称
+	Name() string
+	// Init 初始化插件，bot 提供插件专属的处理器分组、日志和 API，cfg 为插件的配置段
+	Init(bot *Bot, cfg Config) error
+	// Start 启动插件
+	Start() error
+	// Stop 停止插件
+	Stop() error
+}
+
+// Dependent 声明依赖的插件，依赖会先于本插件初始化和启动，并在本插件之后停止
+type Dependent interface {
+	Dependencies() []string
+}
+
+// Reloader 支持热重载配置的插件；未实现该接口的插件修改配置后需要重启
+type Reloader interface {
+	Reload(cfg Config) error
+}
+
+// Base 插件的空实现，嵌入后只需实现 Name 和需要的生命周期方法
+type Base struct{}
+
+func (Base) Init(bot *Bot, cfg Config) error { return nil }
+func (Base) Start() error                    { return nil }
+func (Base) Stop() error                     { return nil }
+
+// ============ 插件配置 ============
+
+// Config 插件的配置段（config.yaml 中 plugins.<name>）
+type Config struct {
+	raw interface{}
+}
+
+// NewConfig 从配置段的原始值创建插件配置
+func NewConfig(raw interface{}) Config {
+	return Config{raw: raw}
+}
+
+// IsEmpty 判断配置段是否为空
+func (c Config) IsEmpty() bool {
+	return c.raw == nil
+}
+
+// Decode 将配置段解析到 v 中，未出现的字段保持原值，未知字段会返回错误
+func (c Config) Decode(v interface{}) error {
+	if c.raw == nil {
+		return nil
+	}
+	data, err := yaml.Marshal(c.raw)
+	if err != nil {
+		return err
+	}
+	if err := yaml.UnmarshalWithOptions(data, v, yaml.DisallowUnknownField()); err != nil {
+		return errors.New(yaml.FormatError(err, false, false))
+	}
+	return nil
+}
+
+// ============ 插件运行环境 ============
+
+// Bot 插件的运行环境，每个插件一个
+type Bot struct {
+	name    string
+	manager *Manager
+	group   *event.Group
+	logger  *slog.Logger
+}
+
+// Name 返回插件名称
+func (b *Bot) Name() string {
+	return b.name
+}
+
+// Handlers 返回插件专属的处理器分组，插件的处理器应注册到这里
+//
+//	event.Register(bot.Handlers(), handler)
+func (b *Bot) Handlers() *event.Group {
+	return b.group
+}
+
+// Storage 返回插件专属命名空间的持久化存储，与插件处理器中的 ctx.Storage() 相同
+func (b *Bot) Storage() storage.Store {
+	return b.group.Storage()
+}
+
+// Logger 返回带有插件名称的 logger
+func (b *Bot) Logger() *slog.Logger {
+	return b.logger
+}
+
+// API 返回 OneBot API，可在处理器之外（如后台任务）调用
+func (b *Bot) API() event.ServerInterface {
+	return b.manager.server
+}
+
+// Commands 返回共享的命令注册表，未设置时返回 nil
+func (b *Bot) Commands() *command.Registry {
+	return b.manager.commands
+}
+
+// Command 注册命令，命令归属于当前插件，只在插件运行时响应（见 Manager.CommandFilter）
+func (b *Bot) Command(cmd *command.Command) error {
+	if b.manager.commands == nil {
+		return errors.New("command registry not available")
+	}
+	if cmd.Plugin == "" {
+		cmd.Plugin = b.name
+	}
+	return b.manager.commands.Register(cmd)
+}
+
+// Schedule 添加定时任务，任务名自动加上插件名前缀，插件停止时自动移除
+// 任务中的 ctx.Storage() 与插件的存储相同
+func (b *Bot) Schedule(name string, spec schedule.Spec, job schedule.Job, opts ...schedule.JobOption) error {
+	if b.manager.scheduler == nil {
+		return errors.New("scheduler not available")
+	}
+	opts = append([]schedule.JobOption{schedule.WithStorage(b.Storage())}, opts...)
+	return b.manager.scheduler.Add(b.name+"."+name, spec, job, opts...)
+}
+
+// Cron 使用 cron 表达式添加定时任务，见 Schedule
+func (b *Bot) Cron(name, expr string, job schedule.Job, opts ...schedule.JobOption) error {
+	spec, err := schedule.ParseCron(expr)
+	if err != nil {
+		return err
+	}
+	return b.Schedule(name, spec, job, opts...)
+}
+
+// Plugin 返回其他插件实例，用于访问依赖插件提供的功能
+func (b *Bot) Plugin(name string) (Plugin, bool) {
+	return b.manager.Lookup(name)
+}
@@ -0,0 +1,245 @@
+package plugin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"onebot-go2/pkg/command"
+	types "onebot-go2/pkg/const"
+	"onebot-go2/pkg/event"
+)
+
+// ErrProtected 尝试关闭受保护的插件或命令
+var ErrProtected = errors.New("protected from being disabled")
+
+// Kind 开关的对象类型
+type Kind string
+
+const (
+	KindPlugin  Kind = "plugins"
+	KindCommand Kind = "commands"
+)
+
+// Scope 开关的作用范围
+type Scope string
+
+const (
+	ScopeGroup Scope = "groups" // 某个群
+	ScopeUser  Scope = "users"  // 某个用户（所有群和私聊）
+)
+
+// switchState 持久化的开关状态：范围 -> 群号/QQ 号 -> 对象类型 -> 名称 -> 是否启用
+type switchState map[Scope]map[int64]map[Kind]map[string]bool
+
+// Switches 按群/按用户的插件和命令开关
+//
+// 判断顺序：用户设置 > 群设置 > 默认启用。
+// 状态保存在 JSON 文件中，重启后保持。
+type Switches struct {
+	path string
+
+	mu        sync.RWMutex
+	state     switchState
+	protected map[Kind]map[string]bool
+}
+
+// NewSwitches 创建开关并从 path 加载已保存的状态，path 为空时不持久化
+func NewSwitches(path string) (*Switches, error) {
+	s := &Switches{
+		path:      path,
+		state:     switchState{},
+		protected: map[Kind]map[string]bool{},
+	}
+	if path == "" {
+		return s, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plugin switches: %w", err)
+	}
+	if err := json.Unmarshal(data, &s.state); err != nil {
+		return nil, fmt.Errorf("failed to parse plugin switches %s: %w", path, err)
+	}
+	if s.state == nil {
+		s.state = switchState{}
+	}
+	return s, nil
+}
+
+// Protect 将插件或命令标记为不可关闭（如提供 /plugin 命令的插件）
+func (s *Switches) Protect(kind Kind, names ...string) *Switches {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.protected[kind] == nil {
+		s.protected[kind] = make(map[string]bool)
+	}
+	for _, name := range names {
+		s.protected[kind][name] = true
+	}
+	return s
+}
+
+// IsProtected 判断插件或命令是否不可关闭
+func (s *Switches) IsProtected(kind Kind, name string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.protected[kind][name]
+}
+
+// Set 设置开关并保存；id 为群号或 QQ 号
+// 保存失败时返回错误，开关保持原来的状态
+func (s *Switches) Set(scope Scope, id int64, kind Kind, name string, enabled bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !enabled && s.protected[kind][name] {
+		return fmt.Errorf("%s: %w", name, ErrProtected)
+	}
+
+	next := s.state.clone()
+	if next[scope] == nil {
+		next[scope] = make(map[int64]map[Kind]map[string]bool)
+	}
+	if next[scope][id] == nil {
+		next[scope][id] = make(map[Kind]map[string]bool)
+	}
+	if next[scope][id][kind] == nil {
+		next[scope][id][kind] = make(map[string]bool)
+	}
+	next[scope][id][kind][name] = enabled
+
+	return s.commit(next)
+}
+
+// Reset 清除开关设置，恢复为上级范围的状态
+// 保存失败时返回错误，开关保持原来的状态
+func (s *Switches) Reset(scope Scope, id int64, kind Kind, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.state[scope][id][kind][name]; !ok {
+		return nil
+	}
+	next := s.state.clone()
+	delete(next[scope][id][kind], name)
+	return s.commit(next)
+}
+
+// Enabled 判断插件或命令对群 groupID 中的用户 userID 是否启用，groupID 为 0 表示私聊或非群事件
+func (s *Switches) Enabled(groupID, userID int64, kind Kind, name string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.protected[kind][name] {
+		return true
+	}
+	if userID != 0 {
+		if enabled, ok := s.state[ScopeUser][userID][kind][name]; ok {
+			return enabled
+		}
+	}
+	if groupID != 0 {
+		if enabled, ok := s.state[ScopeGroup][groupID][kind][name]; ok {
+			return enabled
+		}
+	}
+	return true
+}
+
+// Disabled 返回在指定范围内被关闭的名称（已排序）
+func (s *Switches) Disabled(scope Scope, id int64, kind Kind) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var names []string
+	for name, enabled := range s.state[scope][id][kind] {
+		if !enabled {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// GroupFilter 返回分发器使用的分组过滤器，按事件的群和用户判断插件是否启用
+func (s *Switches) GroupFilter() event.GroupFilter {
+	return func(group *event.Group, evt interface{}) bool {
+		groupID, userID := event.SubjectOf(evt)
+		return s.Enabled(groupID, userID, KindPlugin, group.Name())
+	}
+}
+
+// CommandFilter 返回命令注册表使用的过滤器，命令所属插件和命令本身都启用时才响应
+// plugins 不为空时还要求命令所属插件正在运行，开关无法让已停止插件的命令重新响应
+func (s *Switches) CommandFilter(plugins *Manager) command.Filter {
+	return func(msg *types.MessageEvent, cmd *command.Command) bool {
+		if plugins != nil && !plugins.Running(cmd.Plugin) {
+			return false
+		}
+		groupID, userID := event.SubjectOf(msg)
+		if cmd.Plugin != "" && !s.Enabled(groupID, userID, KindPlugin, cmd.Plugin) {
+			return false
+		}
+		return s.Enabled(groupID, userID, KindCommand, cmd.Name)
+	}
+}
+
+// commit 保存新状态，成功后才替换内存中的状态，调用方需持有锁
+func (s *Switches) commit(next switchState) error {
+	if err := s.save(next); err != nil {
+		return err
+	}
+	s.state = next
+	return nil
+}
+
+// save 将状态写入文件
+// 先写临时文件再重命名，避免写入中断导致文件损坏
+func (s *Switches) save(state switchState) error {
+	if s.path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return fmt.Errorf("failed to save plugin switches: %w", err)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to save plugin switches: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("failed to save plugin switches: %w", err)
+	}
+	return nil
+}
+
+// clone 深拷贝开关状态
+func (st switchState) clone() switchState {
+	out := make(switchState, len(st))
+	for scope, ids := range st {
+		out[scope] = make(map[int64]map[Kind]map[string]bool, len(ids))
+		for id, kinds := range ids {
+			out[scope][id] = make(map[Kind]map[string]bool, len(kinds))
+			for kind, names := range kinds {
+				out[scope][id][kind] = make(map[string]bool, len(names))
+				for name, enabled := range names {
+					out[scope][id][kind][name] = enabled
+				}
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,185 @@
+package plugin
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"onebot-go2/pkg/command"
+	"onebot-go2/pkg/event"
+)
+
+func TestSwitchesEnabled(t *testing.T) {
+	const (
+		group = 100
+		user  = 200
+	)
+	type setting struct {
+		scope   Scope
+		id      int64
+		enabled bool
+	}
+
+	tests := []struct {
+		name     string
+		settings []setting
+		groupID  int64
+		want     bool
+	}{
+		{name: "enabled by default", groupID: group, want: true},
+		{name: "group off", settings: []setting{{ScopeGroup, group, false}}, groupID: group, want: false},
+		{name: "other group", settings: []setting{{ScopeGroup, group + 1, false}}, groupID: group, want: true},
+		{name: "user off", settings: []setting{{ScopeUser, user, false}}, groupID: group, want: false},
+		{name: "user off in private", settings: []setting{{ScopeUser, user, false}}, want: false},
+		{name: "group off ignored in private", settings: []setting{{ScopeGroup, group, false}}, want: true},
+		{
+			name:     "user overrides group",
+			settings: []setting{{ScopeGroup, group, false}, {ScopeUser, user, true}},
+			groupID:  group,
+			want:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewSwitches("")
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, st := range tt.settings {
+				if err := s.Set(st.scope, st.id, KindPlugin, "echo", st.enabled); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got := s.Enabled(tt.groupID, user, KindPlugin, "echo"); got != tt.want {
+				t.Errorf("Enabled() = %v, want %v", got, tt.want)
+			}
+			if !s.Enabled(tt.groupID, user, KindCommand, "echo") {
+				t.Error("plugin switches should not affect commands with the same name")
+			}
+		})
+	}
+}
+
+func TestSwitchesProtected(t *testing.T) {
+	s, _ := NewSwitches("")
+	s.Protect(KindPlugin, "admin")
+
+	if err := s.Set(ScopeGroup, 100, KindPlugin, "admin", false); !errors.Is(err, ErrProtected) {
+		t.Errorf("Set() error = %v, want ErrProtected", err)
+	}
+	if err := s.Set(ScopeGroup, 100, KindPlugin, "admin", true); err != nil {
+		t.Errorf("enabling a protected plugin: %v", err)
+	}
+	if !s.Enabled(100, 0, KindPlugin, "admin") {
+		t.Error("protected plugin should stay enabled")
+	}
+}
+
+func TestSwitchesPersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "switches", "state.json")
+	s, err := NewSwitches(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Set(ScopeGroup, 100, KindPlugin, "echo", false); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Set(ScopeGroup, 100, KindCommand, "ping", false); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Reset(ScopeGroup, 100, KindCommand, "ping"); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := NewSwitches(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Enabled(100, 0, KindPlugin, "echo") {
+		t.Error("saved switch should be restored")
+	}
+	if !loaded.Enabled(100, 0, KindCommand, "ping") {
+		t.Error("reset switch should not be restored")
+	}
+}
+
+func TestSwitchesSaveFailure(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	s, err := NewSwitches(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Set(ScopeGroup, 100, KindPlugin, "echo", false); err != nil {
+		t.Fatal(err)
+	}
+
+	// 临时文件的位置被目录占用，写入必然失败
+	if err := os.Mkdir(path+".tmp", 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Set(ScopeGroup, 100, KindPlugin, "echo", true); err == nil {
+		t.Fatal("Set() should fail when saving fails")
+	}
+	if s.Enabled(100, 0, KindPlugin, "echo") {
+		t.Error("failed Set() should keep the previous state")
+	}
+	if err := s.Set(ScopeGroup, 200, KindPlugin, "echo", false); err == nil {
+		t.Fatal("Set() should fail when saving fails")
+	}
+	if !s.Enabled(200, 0, KindPlugin, "echo") {
+		t.Error("failed Set() should not add new switches")
+	}
+	if err := s.Reset(ScopeGroup, 100, KindPlugin, "echo"); err == nil {
+		t.Fatal("Reset() should fail when saving fails")
+	}
+	if got := s.Disabled(ScopeGroup, 100, KindPlugin); len(got) != 1 || got[0] != "echo" {
+		t.Errorf("Disabled() = %v, want [echo]", got)
+	}
+}
+
+func TestSwitchesCommandFilter(t *testing.T) {
+	var log []string
+	commands := command.NewRegistry("/")
+	m := NewManager(event.NewDispatcher(), event.NewTestServer()).SetCommands(commands)
+	if err := m.Register(&testPlugin{name: "echo", commands: []string{"ping"}, log: &log}); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Init(); err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewSwitches("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	commands.SetFilter(s.CommandFilter(m))
+
+	steps := []struct {
+		name   string
+		action func() error
+		want   bool
+	}{
+		{name: "switched on before start", action: func() error { return s.Set(ScopeGroup, 100, KindPlugin, "echo", true) }, want: false},
+		{name: "running", action: m.Start, want: true},
+		{name: "plugin off in group", action: func() error { return s.Set(ScopeGroup, 100, KindPlugin, "echo", false) }, want: false},
+		{name: "plugin on in group", action: func() error { return s.Set(ScopeGroup, 100, KindPlugin, "echo", true) }, want: true},
+		{name: "command off in group", action: func() error { return s.Set(ScopeGroup, 100, KindCommand, "ping", false) }, want: false},
+		{name: "command reset", action: func() error { return s.Reset(ScopeGroup, 100, KindCommand, "ping") }, want: true},
+		{name: "stopped", action: m.Stop, want: false},
+		{name: "switched on after stop", action: func() error { return s.Set(ScopeGroup, 100, KindPlugin, "echo", true) }, want: false},
+	}
+	for _, step := range steps {
+		if err := step.action(); err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		args, err := commands.Parse(event.GroupMessage(100, 10001, "/ping"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := args != nil; got != step.want {
+			t.Errorf("%s: matched = %v, want %v", step.name, got, step.want)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// DefaultIdleTTL 默认的空闲桶回收时间
+const DefaultIdleTTL = 10 * time.Minute
+
+// DefaultMaxKeys 默认最多保存的桶数量
+const DefaultMaxKeys = 10000
+
+// bucket 单个 key 的令牌桶
+type bucket struct {
+	tokens   float64
+	last     time.Time // 上次补充令牌的时间
+	notified bool      // 本轮冷却是否已经提示过
+}
+
+// Limiter 按 key 区分的令牌桶限流器
+// 每个 key 的桶容量为 burst，按 rate 匀速补充，空闲的桶会被定期回收；
+// 桶数量达到 maxKeys 时回收空闲的桶，仍然已满时淘汰最久未使用的桶
+type Limiter struct {
+	rate      float64 // 每秒补充的令牌数
+	burst     float64
+	unlimited bool // per 不大于 0 时不限流
+	idleTTL   time.Duration
+	maxKeys   int
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+// NewLimiter 创建限流器，每个 key 在 per 时间内最多允许 n 次
+// per 不大于 0 表示不限流，所有请求都放行
+func NewLimiter(n int, per time.Duration) *Limiter {
+	if n <= 0 {
+		n = 1
+	}
+	l := &Limiter{
+		burst:     float64(n),
+		unlimited: per <= 0,
+		idleTTL:   DefaultIdleTTL,
+		maxKeys:   DefaultMaxKeys,
+		buckets:   make(map[string]*bucket),
+		lastSweep: time.Now(),
+	}
+	if !l.unlimited {
+		l.rate = float64(n) / per.Seconds()
+	}
+	return l
+}
+
+// SetIdleTTL 设置空闲桶回收时间
+func (l *Limiter) SetIdleTTL(ttl time.Duration) *Limiter {
+	l.mu.Lock()
+	l.idleTTL = ttl
+	l.mu.Unlock()
+	return l
+}
+
+// SetMaxKeys 设置最多保存的桶数量，0 表示不限制
+func (l *Limiter) SetMaxKeys(n int) *Limiter {
+	l.mu.Lock()
+	l.maxKeys = n
+	l.mu.Unlock()
+	return l
+}
+
+// Result 限流判断结果
+type Result struct {
+	Allowed    bool          // 是否放行
+	RetryAfter time.Duration // 被拒绝时距离下一个令牌的时间
+	Notify     bool          // 被拒绝时是否为本轮冷却的首次拒绝（用于避免重复提示）
+}
+
+// Allow 尝试为 key 消耗一个令牌
+func (l *Limiter) Allow(key string) Result {
+	if l.unlimited {
+		return Result{Allowed: true}
+	}
+	now := time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweep(now, false)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		if l.maxKeys > 0 && len(l.buckets) >= l.maxKeys {
+			l.evict(now)
+		}
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	} else {
+		b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+		b.last = now
+	}
+
+	if b.tokens >= 1 {
+		b.tokens--
+		b.notified = false
+		return Result{Allowed: true}
+	}
+
+	wait := time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+	notify := !b.notified
+	b.notified = true
+	return Result{Allowed: false, RetryAfter: wait, Notify: notify}
+}
+
+// Reset 清除 key 的限流状态
+func (l *Limiter) Reset(key string) {
+	l.mu.Lock()
+	delete(l.buckets, key)
+	l.mu.Unlock()
+}
+
+// Len 返回当前保存的桶数量
+func (l *Limiter) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.buckets)
+}
+
+// sweep 回收长时间未使用且已经回满的桶，调用方需持有锁
+// force 为 false 时每个 idleTTL 最多回收一次
+func (l *Limiter) sweep(now time.Time, force bool) {
+	if l.idleTTL <= 0 || (!force && now.Sub(l.lastSweep) < l.idleTTL) {
+		return
+	}
+	l.lastSweep = now
+
+	// 桶回满之前回收会提前重置冷却
+	idle := max(l.idleTTL, time.Duration(l.burst/l.rate*float64(time.Second)))
+	for key, b := range l.buckets {
+		if now.Sub(b.last) >= idle {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// evict 桶数量达到上限时腾出空间：先回收空闲的桶，仍然已满时淘汰最久未使用的桶，调用方需持有锁
+// 被淘汰的 key 的冷却会被重置
+func (l *Limiter) evict(now time.Time) {
+	l.sweep(now, true)
+	for len(l.buckets) >= l.maxKeys {
+		var oldestKey string
+		var oldest time.Time
+		for key, b := range l.buckets {
+			if oldestKey == "" || b.last.Before(oldest) {
+				oldestKey, oldest = key, b.last
+			}
+		}
+		delete(l.buckets, oldestKey)
+	}
+}
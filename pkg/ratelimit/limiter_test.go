@@ -0,0 +1,167 @@
+package ratelimit
+
+import (
+	"math"
+	"strconv"
+	"testing"
+	"time"
+
+	"onebot-go2/pkg/event"
+)
+
+func TestLimiterAllow(t *testing.T) {
+	tests := []struct {
+		name string
+		n    int
+		per  time.Duration
+		want []bool
+	}{
+		{name: "burst", n: 3, per: time.Hour, want: []bool{true, true, true, false, false}},
+		{name: "single", n: 1, per: time.Hour, want: []bool{true, false}},
+		{name: "non-positive n is one", n: 0, per: time.Hour, want: []bool{true, false}},
+		{name: "zero window is unlimited", n: 1, per: 0, want: []bool{true, true, true}},
+		{name: "negative window is unlimited", n: 1, per: -time.Second, want: []bool{true, true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewLimiter(tt.n, tt.per)
+			for i, want := range tt.want {
+				result := l.Allow("k")
+				if result.Allowed != want {
+					t.Fatalf("call %d: Allowed = %v, want %v", i, result.Allowed, want)
+				}
+				if !result.Allowed {
+					if result.RetryAfter <= 0 || result.RetryAfter > tt.per {
+						t.Errorf("call %d: RetryAfter = %v, want in (0, %v]", i, result.RetryAfter, tt.per)
+					}
+					if math.IsNaN(float64(result.RetryAfter)) {
+						t.Errorf("call %d: RetryAfter is NaN", i)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestLimiterNotifyOncePerCooldown(t *testing.T) {
+	l := NewLimiter(1, time.Hour)
+	want := []Result{
+		{Allowed: true},
+		{Allowed: false, Notify: true},
+		{Allowed: false, Notify: false},
+	}
+	for i, w := range want {
+		got := l.Allow("k")
+		if got.Allowed != w.Allowed || got.Notify != w.Notify {
+			t.Errorf("call %d: got Allowed=%v Notify=%v, want Allowed=%v Notify=%v", i, got.Allowed, got.Notify, w.Allowed, w.Notify)
+		}
+	}
+}
+
+func TestLimiterRefill(t *testing.T) {
+	l := NewLimiter(2, time.Minute)
+	l.Allow("k")
+	l.Allow("k")
+	if l.Allow("k").Allowed {
+		t.Fatal("bucket should be empty")
+	}
+
+	// 回拨上次补充时间，模拟经过 30 秒（补充 1 个令牌）
+	l.mu.Lock()
+	l.buckets["k"].last = l.buckets["k"].last.Add(-30 * time.Second)
+	l.mu.Unlock()
+
+	if !l.Allow("k").Allowed {
+		t.Error("one token should be refilled after half the window")
+	}
+	if l.Allow("k").Allowed {
+		t.Error("only one token should be refilled")
+	}
+	if !l.Allow("other").Allowed {
+		t.Error("keys should not share buckets")
+	}
+}
+
+func TestLimiterMaxKeys(t *testing.T) {
+	l := NewLimiter(1, time.Hour).SetMaxKeys(3)
+	for i := 0; i < 10; i++ {
+		l.Allow(strconv.Itoa(i))
+		if got := l.Len(); got > 3 {
+			t.Fatalf("after %d keys: Len() = %d, want <= 3", i+1, got)
+		}
+	}
+	// 最久未使用的 key 被淘汰，冷却随之重置
+	if !l.Allow("0").Allowed {
+		t.Error("evicted key should start with a full bucket")
+	}
+	if l.Allow("9").Allowed {
+		t.Error("recent key should still be limited")
+	}
+}
+
+func TestRuleKeys(t *testing.T) {
+	group := event.GroupMessage(100, 200, "hi")
+	private := event.PrivateMessage(200, "hi")
+	tests := []struct {
+		name string
+		key  KeyFunc
+		evt  interface{}
+		want string
+	}{
+		{name: "per user", key: PerUser, evt: group, want: "user:200"},
+		{name: "per group", key: PerGroup, evt: group, want: "group:100"},
+		{name: "per group in private", key: PerGroup, evt: private, want: "user:200"},
+		{name: "per group user", key: PerGroupUser, evt: group, want: "group:100:user:200"},
+		{name: "per group user in private", key: PerGroupUser, evt: private, want: "user:200"},
+		{name: "global", key: Global, evt: private, want: "global"},
+		{name: "unknown event", key: PerUser, evt: "x", want: ""},
+	}
+	for _, tt := range tests {
+		if got := tt.key(tt.evt); got != tt.want {
+			t.Errorf("%s: key = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	sw := NewSwitch(NewRule(1, time.Hour, PerUser))
+	mw := sw.Middleware()
+
+	var handled int
+	next := func(*event.Context[interface{}]) error {
+		handled++
+		return nil
+	}
+
+	// 同一事件经过多个处理器只计数一次
+	ctx, _ := event.NewTestContext[interface{}](event.GroupMessage(100, 200, "hi"))
+	for i := 0; i < 3; i++ {
+		if err := mw(next)(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if handled != 3 || ctx.IsAborted() {
+		t.Fatalf("first event: handled = %d, aborted = %v; want 3, false", handled, ctx.IsAborted())
+	}
+
+	ctx, srv := event.NewTestContext[interface{}](event.GroupMessage(100, 200, "hi"))
+	if err := mw(next)(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if handled != 3 || !ctx.IsAborted() {
+		t.Errorf("second event: handled = %d, aborted = %v; want 3, true", handled, ctx.IsAborted())
+	}
+	if got := srv.SentTexts(); len(got) != 1 {
+		t.Errorf("replies = %v, want one cooldown message", got)
+	}
+
+	// 清空规则后直接放行
+	sw.Set(nil)
+	ctx, _ = event.NewTestContext[interface{}](event.GroupMessage(100, 200, "hi"))
+	if err := mw(next)(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if handled != 4 {
+		t.Errorf("after Set(nil): handled = %d, want 4", handled)
+	}
+}
@@ -0,0 +1,218 @@
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	types "onebot-go2/pkg/const"
+	"onebot-go2/pkg/event"
+)
+
+// DefaultMessage 默认的冷却提示，%d 为剩余秒数
+const DefaultMessage = "冷却中，请 %d 秒后再试"
+
+// KeyFunc 从事件计算限流 key，返回空字符串表示不限流
+type KeyFunc func(evt interface{}) string
+
+// PerUser 按用户限流
+func PerUser(evt interface{}) string {
+	if userID := userOf(evt); userID != 0 {
+		return "user:" + strconv.FormatInt(userID, 10)
+	}
+	return ""
+}
+
+// PerGroup 按群限流，私聊按用户限流
+func PerGroup(evt interface{}) string {
+	if groupID := groupOf(evt); groupID != 0 {
+		return "group:" + strconv.FormatInt(groupID, 10)
+	}
+	return PerUser(evt)
+}
+
+// PerGroupUser 按群内的每个用户分别限流
+func PerGroupUser(evt interface{}) string {
+	user := PerUser(evt)
+	if groupID := groupOf(evt); groupID != 0 && user != "" {
+		return "group:" + strconv.FormatInt(groupID, 10) + ":" + user
+	}
+	return user
+}
+
+// Global 所有事件共用一个桶；用作命令冷却时即为按命令限流
+func Global(evt interface{}) string {
+	return "global"
+}
+
+// Rule 限流规则：限流器 + key 计算方式 + 拒绝时的行为
+type Rule struct {
+	Limiter *Limiter
+	Key     KeyFunc
+	Message string // 冷却提示，%d 为剩余秒数，为空时使用 DefaultMessage
+	Silent  bool   // 被限流时直接丢弃，不回复
+}
+
+// Option 限流规则选项
+type Option func(*Rule)
+
+// WithMessage 设置冷却提示
+func WithMessage(message string) Option {
+	return func(r *Rule) {
+		r.Message = message
+	}
+}
+
+// Silent 被限流时不回复
+func Silent() Option {
+	return func(r *Rule) {
+		r.Silent = true
+	}
+}
+
+// NewRule 创建限流规则：每个 key 在 per 时间内最多 n 次
+func NewRule(n int, per time.Duration, key KeyFunc, opts ...Option) *Rule {
+	rule := &Rule{
+		Limiter: NewLimiter(n, per),
+		Key:     key,
+	}
+	for _, opt := range opts {
+		opt(rule)
+	}
+	return rule
+}
+
+// Check 判断事件是否放行，scope 用于区分共用同一规则的不同命令
+func (r *Rule) Check(evt interface{}, scope string) Result {
+	key := r.Key(evt)
+	if key == "" {
+		return Result{Allowed: true}
+	}
+	if scope != "" {
+		key = scope + ":" + key
+	}
+	return r.Limiter.Allow(key)
+}
+
+// Reject 回复冷却提示；同一轮冷却只提示一次
+func Reject[T any](r *Rule, ctx *event.Context[T], result Result) error {
+	if r.Silent || !result.Notify {
+		return nil
+	}
+	if _, ok := ctx.GetMessageEvent(); !ok {
+		return nil
+	}
+
+	message := r.Message
+	if message == "" {
+		message = DefaultMessage
+	}
+	seconds := int(math.Ceil(result.RetryAfter.Seconds()))
+	_, err := ctx.ReplyText(fmt.Sprintf(message, seconds))
+	return err
+}
+
+// Middleware 限流中间件
+// 被限流的事件会中止后续处理器；同一事件经过多个处理器时只计数一次
+func Middleware(r *Rule) event.Middleware {
+	metaKey := fmt.Sprintf("ratelimit:%p", r)
+
+	return func(next event.HandlerFunc[interface{}]) event.HandlerFunc[interface{}] {
+		return func(ctx *event.Context[interface{}]) error {
+			if allowed, ok := ctx.Get(metaKey); ok {
+				if allowed.(bool) {
+					return next(ctx)
+				}
+				ctx.Abort()
+				return nil
+			}
+
+			result := r.Check(ctx.Event, "")
+			ctx.Set(metaKey, result.Allowed)
+			if result.Allowed {
+				return next(ctx)
+			}
+
+			ctx.Logger().Info("Event rate limited", "key", r.Key(ctx.Event), "retry_after", result.RetryAfter)
+			ctx.Abort()
+			return Reject(r, ctx, result)
+		}
+	}
+}
+
+func userOf(evt interface{}) int64 {
+	switch e := evt.(type) {
+	case *types.MessageEvent:
+		return e.UserID
+	case *types.NoticeEvent:
+		return e.UserID
+	case *types.RequestEvent:
+		return e.UserID
+	}
+	return 0
+}
+
+func groupOf(evt interface{}) int64 {
+	switch e := evt.(type) {
+	case *types.MessageEvent:
+		if e.MessageType == types.MessageTypeGroup {
+			return e.GroupID
+		}
+	case *types.NoticeEvent:
+		return e.GroupID
+	case *types.RequestEvent:
+		return e.GroupID
+	}
+	return 0
+}
+
+// Switch 可在运行时替换的限流规则
+// 中间件始终注册，规则为空时直接放行，适用于配置热重载
+type Switch struct {
+	current atomic.Pointer[switchRule]
+}
+
+// switchRule 当前规则和为它构造好的中间件，替换规则时一起替换
+type switchRule struct {
+	rule       *Rule
+	middleware event.Middleware
+}
+
+// NewSwitch 创建规则开关，rule 为空表示不限流
+func NewSwitch(rule *Rule) *Switch {
+	s := &Switch{}
+	s.Set(rule)
+	return s
+}
+
+// Set 替换当前规则，rule 为空表示不限流
+func (s *Switch) Set(rule *Rule) {
+	if rule == nil {
+		s.current.Store(nil)
+		return
+	}
+	s.current.Store(&switchRule{rule: rule, middleware: Middleware(rule)})
+}
+
+// Rule 返回当前规则
+func (s *Switch) Rule() *Rule {
+	if current := s.current.Load(); current != nil {
+		return current.rule
+	}
+	return nil
+}
+
+// Middleware 返回使用当前规则的限流中间件
+func (s *Switch) Middleware() event.Middleware {
+	return func(next event.HandlerFunc[interface{}]) event.HandlerFunc[interface{}] {
+		return func(ctx *event.Context[interface{}]) error {
+			current := s.current.Load()
+			if current == nil {
+				return next(ctx)
+			}
+			return current.middleware(next)(ctx)
+		}
+	}
+}
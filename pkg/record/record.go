@@ -0,0 +1,184 @@
+package record
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// 帧方向
+const (
+	DirIn  = "in"  // OneBot 客户端 -> 机器人：事件和 API 响应
+	DirOut = "out" // 机器人 -> OneBot 客户端：API 请求
+)
+
+// Redacted 被脱敏的字符串、对象和数组字段的替换值
+// 数字字段替换为 0、布尔字段替换为 false，重放时响应仍能解析为原来的类型（如 get_credentials 的 csrf_token）
+const Redacted = "[REDACTED]"
+
+// DefaultRedact 默认脱敏的字段名（get_cookies、get_csrf_token、get_credentials 的返回值等）
+var DefaultRedact = []string{"cookies", "token", "csrf_token", "access_token"}
+
+// Frame 录制的一帧 WebSocket 消息
+type Frame struct {
+	Time   time.Time       `json:"time"`
+	Dir    string          `json:"dir"` // in 或 out
+	SelfID int64           `json:"self_id,omitempty"`
+	Data   json.RawMessage `json:"data"`
+}
+
+// Recorder 将收发的每一帧以 JSON Lines 格式追加到文件，每行一帧
+type Recorder struct {
+	mu     sync.Mutex
+	file   *os.File
+	redact map[string]bool
+	closed bool
+}
+
+// NewRecorder 打开（或创建）录制文件，redact 中的字段名在写入前被替换为 [REDACTED]
+func NewRecorder(path string, redact []string) (*Recorder, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create record directory: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open record file: %w", err)
+	}
+	r := &Recorder{file: file, redact: make(map[string]bool, len(redact))}
+	for _, key := range redact {
+		r.redact[key] = true
+	}
+	return r, nil
+}
+
+// Record 记录一帧，接收者为 nil 时不做任何事
+func (r *Recorder) Record(dir string, selfID int64, data []byte) error {
+	if r == nil {
+		return nil
+	}
+	frame := Frame{Time: time.Now(), Dir: dir, SelfID: selfID, Data: Redact(data, r.redact)}
+	line, err := json.Marshal(frame)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return os.ErrClosed
+	}
+	_, err = r.file.Write(line)
+	return err
+}
+
+// Close 关闭录制文件
+func (r *Recorder) Close() error {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+	return r.file.Close()
+}
+
+// Redact 将 JSON 中任意层级名为 keys 的字段脱敏（替换值见 Redacted）
+// data 不是合法 JSON 时作为字符串保存，保证录制文件每行都是合法 JSON
+func Redact(data []byte, keys map[string]bool) json.RawMessage {
+	if !json.Valid(data) {
+		quoted, _ := json.Marshal(string(data))
+		return quoted
+	}
+	if len(keys) == 0 {
+		return append(json.RawMessage(nil), data...)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber() // 保留 QQ 号、消息 ID 等大整数的精度
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return append(json.RawMessage(nil), data...)
+	}
+	if !redactValue(value, keys) {
+		return append(json.RawMessage(nil), data...)
+	}
+	out, err := json.Marshal(value)
+	if err != nil {
+		return append(json.RawMessage(nil), data...)
+	}
+	return out
+}
+
+// redactValue 原地脱敏，返回是否有字段被替换
+func redactValue(value interface{}, keys map[string]bool) bool {
+	changed := false
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			if keys[key] {
+				v[key] = redacted(child)
+				changed = true
+				continue
+			}
+			if redactValue(child, keys) {
+				changed = true
+			}
+		}
+	case []interface{}:
+		for _, child := range v {
+			if redactValue(child, keys) {
+				changed = true
+			}
+		}
+	}
+	return changed
+}
+
+// redacted 返回与原值 JSON 类型相同的替换值
+func redacted(value interface{}) interface{} {
+	switch value.(type) {
+	case json.Number:
+		return json.Number("0")
+	case bool:
+		return false
+	case nil:
+		return nil
+	}
+	return Redacted
+}
+
+// Load 读取录制文件
+func Load(path string) ([]Frame, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open recording: %w", err)
+	}
+	defer file.Close()
+
+	var frames []Frame
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var frame Frame
+		if err := json.Unmarshal(scanner.Bytes(), &frame); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		frames = append(frames, frame)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read recording: %w", err)
+	}
+	return frames, nil
+}
@@ -0,0 +1,150 @@
+package record_test
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"onebot-go2/internal/server"
+	types "onebot-go2/pkg/const"
+	"onebot-go2/pkg/event"
+	"onebot-go2/pkg/onebottest"
+	"onebot-go2/pkg/record"
+)
+
+const secretCookies = "uin=o10000; skey=@secret"
+
+// newBot 创建注册了测试处理器的 WSServer：收到 "/login" 时获取凭证并回复，
+// 处理器得到的凭证在回复发送完成后写入 creds
+func newBot(t *testing.T, creds chan<- string) *server.WSServer {
+	t.Helper()
+	srv := server.NewWSServer("")
+	err := event.RegisterFunc(srv.GetDispatcher(), "login", 0, func(ctx *event.Context[*types.MessageEvent]) error {
+		if ctx.Event.RawMessage != "/login" {
+			return nil
+		}
+		resp, err := ctx.Bot().GetCredentials("qun.qq.com")
+		if err != nil {
+			creds <- err.Error()
+			return err
+		}
+		if _, err := ctx.ReplyText("ok"); err != nil {
+			return err
+		}
+		creds <- fmt.Sprintf("cookies=%s csrf=%d", resp.Cookies, resp.Token)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return srv
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "records", "session.jsonl")
+
+	// 录制：假客户端返回真实凭证
+	recorder, err := record.NewRecorder(path, record.DefaultRedact)
+	if err != nil {
+		t.Fatal(err)
+	}
+	creds := make(chan string, 1)
+	srv := newBot(t, creds)
+	srv.SetRecorder(recorder)
+	bot := onebottest.Start(t, srv)
+	bot.Respond(types.ActionGetCredentials, types.GetCredentialsResponse{Cookies: secretCookies, Token: 123456})
+
+	bot.Inject(event.GroupMessage(100, 10001, "hello"))
+	bot.Inject(event.GroupMessage(100, 10001, "/login"))
+	// 回复的响应被录制后才关闭连接
+	if got, want := <-creds, "cookies="+secretCookies+" csrf=123456"; got != want {
+		t.Fatalf("recorded credentials = %q, want %q", got, want)
+	}
+	bot.AssertGroupMessage(100, "ok")
+	bot.Close()
+	if err := recorder.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"skey=@secret", "123456"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("recording contains %q", secret)
+		}
+	}
+	frames, err := record.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var events int
+	for _, frame := range frames {
+		if frame.SelfID != onebottest.DefaultSelfID {
+			t.Errorf("frame self_id = %d, want %d", frame.SelfID, onebottest.DefaultSelfID)
+		}
+		if frame.Dir == record.DirIn && strings.Contains(string(frame.Data), `"post_type"`) {
+			events++
+		}
+	}
+	if events != 2 {
+		t.Errorf("recorded %d events, want 2", events)
+	}
+
+	// 重放：新的机器人只连接重放器，API 响应全部来自录制
+	srv = newBot(t, creds)
+	srv.GetDispatcher().SetAsync(true)
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/ws", srv.HandlerWebsocket)
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	result, err := record.NewReplayer(frames).SetSpeed(0).SetIdle(200*time.Millisecond).
+		Run(ctx, "ws"+strings.TrimPrefix(ts.URL, "http")+"/ws")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Events != 2 || len(result.Unmatched) != 0 {
+		t.Errorf("replay result = %s", result.Summary())
+	}
+	// 处理器得到脱敏后的凭证
+	select {
+	case got := <-creds:
+		if want := "cookies=" + record.Redacted + " csrf=0"; got != want {
+			t.Errorf("replayed credentials = %q, want %q", got, want)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("handler did not run during replay")
+	}
+}
+
+func TestRedact(t *testing.T) {
+	keys := map[string]bool{"token": true, "csrf_token": true, "cookies": true}
+	tests := []struct {
+		in, want string
+	}{
+		{in: `{"a":1}`, want: `{"a":1}`},
+		{in: `{"data":{"cookies":"c","csrf_token":123,"token":true}}`, want: `{"data":{"cookies":"[REDACTED]","csrf_token":0,"token":false}}`},
+		{in: `[{"token":{"x":1}},{"token":null}]`, want: `[{"token":"[REDACTED]"},{"token":null}]`},
+		// 未脱敏时保留原文，脱敏时保留大整数精度
+		{in: `{"user_id": 9007199254740993}`, want: `{"user_id": 9007199254740993}`},
+		{in: `{"user_id":9007199254740993,"token":"t"}`, want: `{"token":"[REDACTED]","user_id":9007199254740993}`},
+		{in: `not json`, want: `"not json"`},
+	}
+	for _, tt := range tests {
+		if got := string(record.Redact([]byte(tt.in), keys)); got != tt.want {
+			t.Errorf("Redact(%s) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
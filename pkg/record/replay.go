@@ -0,0 +1,332 @@
+package record
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Replayer 作为 OneBot 客户端连接机器人，按录制顺序重放收到的事件
+// 机器人发出的 API 请求按 action 和参数匹配录制的请求，返回当时的响应，无需真实的 OneBot 实现
+type Replayer struct {
+	frames []Frame
+	speed  float64
+	token  string
+	idle   time.Duration
+	logger *slog.Logger
+}
+
+// Result 重放结果
+type Result struct {
+	Events    int      // 重放的事件数
+	Calls     int      // 机器人发出的 API 请求数
+	Unmatched []string // 没有录制响应的 API 请求（action）
+}
+
+// recordedCall 录制的一次 API 调用
+type recordedCall struct {
+	response map[string]interface{}
+	used     bool
+}
+
+// NewReplayer 创建重放器
+func NewReplayer(frames []Frame) *Replayer {
+	return &Replayer{
+		frames: frames,
+		speed:  1,
+		idle:   time.Second,
+		logger: slog.Default().With("component", "replay"),
+	}
+}
+
+// SetSpeed 设置重放速度：1 为原始速度，2 为两倍速，0 为不等待（尽快重放）
+func (r *Replayer) SetSpeed(speed float64) *Replayer {
+	r.speed = speed
+	return r
+}
+
+// SetToken 设置连接机器人使用的 access token
+func (r *Replayer) SetToken(token string) *Replayer {
+	r.token = token
+	return r
+}
+
+// SetIdle 设置事件发送完毕后的等待时间，超过该时间没有新的 API 请求即结束，默认 1 秒
+func (r *Replayer) SetIdle(idle time.Duration) *Replayer {
+	r.idle = idle
+	return r
+}
+
+// SetLogger 设置日志记录器
+func (r *Replayer) SetLogger(logger *slog.Logger) *Replayer {
+	r.logger = logger.With("component", "replay")
+	return r
+}
+
+// Run 连接 url（如 ws://127.0.0.1:8080/ws）并重放，录制中的每个机器人（self_id）使用一个连接
+func (r *Replayer) Run(ctx context.Context, url string) (*Result, error) {
+	byKey, byAction := r.index()
+	result := &Result{}
+	var resultMu sync.Mutex
+	var lastActivity atomic.Int64
+	lastActivity.Store(time.Now().UnixNano())
+
+	// answer 为机器人的请求返回录制的响应
+	answer := func(selfID int64, request []byte) []byte {
+		var req struct {
+			Action string          `json:"action"`
+			Params json.RawMessage `json:"params"`
+			Echo   string          `json:"echo"`
+		}
+		if err := json.Unmarshal(request, &req); err != nil || req.Echo == "" {
+			return nil
+		}
+		lastActivity.Store(time.Now().UnixNano())
+
+		resultMu.Lock()
+		defer resultMu.Unlock()
+		result.Calls++
+		call := take(byKey[callKey(selfID, req.Action, req.Params)])
+		if call == nil {
+			call = take(byAction[callKey(selfID, req.Action, nil)])
+		}
+		if call == nil {
+			result.Unmatched = append(result.Unmatched, req.Action)
+			r.logger.Warn("No recorded response", "action", req.Action, "self_id", selfID)
+			resp, _ := json.Marshal(map[string]interface{}{
+				"status": "failed", "retcode": 1404, "message": "no recorded response", "echo": req.Echo,
+			})
+			return resp
+		}
+		call.response["echo"] = req.Echo
+		resp, _ := json.Marshal(call.response)
+		return resp
+	}
+
+	// 每个机器人一个连接
+	conns := make(map[int64]*replayConn)
+	defer func() {
+		for _, c := range conns {
+			c.conn.Close()
+		}
+	}()
+	var readers sync.WaitGroup
+	for _, frame := range r.frames {
+		if _, ok := conns[frame.SelfID]; ok {
+			continue
+		}
+		conn, err := r.dial(ctx, url, frame.SelfID)
+		if err != nil {
+			return nil, err
+		}
+		c := &replayConn{conn: conn}
+		conns[frame.SelfID] = c
+		readers.Add(1)
+		go func(selfID int64) {
+			defer readers.Done()
+			for {
+				_, data, err := c.conn.ReadMessage()
+				if err != nil {
+					return
+				}
+				if resp := answer(selfID, data); resp != nil {
+					c.write(resp)
+				}
+			}
+		}(frame.SelfID)
+	}
+
+	// 按原始间隔发送事件
+	var prev time.Time
+	for _, frame := range r.frames {
+		if frame.Dir != DirIn || !isEvent(frame.Data) {
+			continue
+		}
+		if !prev.IsZero() && r.speed > 0 {
+			delay := time.Duration(float64(frame.Time.Sub(prev)) / r.speed)
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+				return result, ctx.Err()
+			}
+		}
+		prev = frame.Time
+		lastActivity.Store(time.Now().UnixNano())
+		if err := conns[frame.SelfID].write(frame.Data); err != nil {
+			return result, fmt.Errorf("failed to send event: %w", err)
+		}
+		resultMu.Lock()
+		result.Events++
+		resultMu.Unlock()
+	}
+
+	// 等待处理器发出的 API 请求结束
+	for time.Since(time.Unix(0, lastActivity.Load())) < r.idle {
+		select {
+		case <-time.After(r.idle / 10):
+		case <-ctx.Done():
+			return result, ctx.Err()
+		}
+	}
+	for _, c := range conns {
+		c.conn.Close()
+	}
+	readers.Wait()
+
+	resultMu.Lock()
+	defer resultMu.Unlock()
+	return result, nil
+}
+
+// dial 连接机器人，机器人刚启动时重试
+func (r *Replayer) dial(ctx context.Context, url string, selfID int64) (*websocket.Conn, error) {
+	header := http.Header{}
+	if r.token != "" {
+		header.Set("Authorization", "Bearer "+r.token)
+	}
+	if selfID != 0 {
+		header.Set("X-Self-ID", strconv.FormatInt(selfID, 10))
+	}
+
+	var lastErr error
+	for attempt := 0; attempt < 50; attempt++ {
+		conn, _, err := websocket.DefaultDialer.DialContext(ctx, url, header)
+		if err == nil {
+			return conn, nil
+		}
+		lastErr = err
+		select {
+		case <-time.After(100 * time.Millisecond):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	return nil, fmt.Errorf("failed to connect to %s: %w", url, lastErr)
+}
+
+// index 建立录制的 API 调用索引：按 action + 参数，以及只按 action（参数不同时的后备）
+func (r *Replayer) index() (byKey, byAction map[string][]*recordedCall) {
+	type request struct {
+		selfID int64
+		action string
+		params json.RawMessage
+	}
+	requests := make(map[string]request)
+	var echoes []string
+	responses := make(map[string]map[string]interface{})
+
+	for _, frame := range r.frames {
+		var msg struct {
+			Action string          `json:"action"`
+			Params json.RawMessage `json:"params"`
+			Echo   string          `json:"echo"`
+		}
+		if json.Unmarshal(frame.Data, &msg) != nil || msg.Echo == "" {
+			continue
+		}
+		switch frame.Dir {
+		case DirOut:
+			requests[msg.Echo] = request{selfID: frame.SelfID, action: msg.Action, params: msg.Params}
+			echoes = append(echoes, msg.Echo)
+		case DirIn:
+			var resp map[string]interface{}
+			decoder := json.NewDecoder(bytes.NewReader(frame.Data))
+			decoder.UseNumber()
+			if decoder.Decode(&resp) == nil {
+				responses[msg.Echo] = resp
+			}
+		}
+	}
+
+	byKey = make(map[string][]*recordedCall)
+	byAction = make(map[string][]*recordedCall)
+	for _, echo := range echoes {
+		resp, ok := responses[echo]
+		if !ok {
+			continue // 录制时超时，没有响应
+		}
+		req := requests[echo]
+		call := &recordedCall{response: resp}
+		key := callKey(req.selfID, req.action, req.params)
+		byKey[key] = append(byKey[key], call)
+		actionKey := callKey(req.selfID, req.action, nil)
+		byAction[actionKey] = append(byAction[actionKey], call)
+	}
+	return byKey, byAction
+}
+
+// take 返回第一个未使用的录制调用
+func take(calls []*recordedCall) *recordedCall {
+	for _, call := range calls {
+		if !call.used {
+			call.used = true
+			return call
+		}
+	}
+	return nil
+}
+
+// callKey 由 self_id、action 和规范化后的参数（对象键排序）组成
+func callKey(selfID int64, action string, params json.RawMessage) string {
+	key := strconv.FormatInt(selfID, 10) + "/" + action
+	if params == nil {
+		return key
+	}
+	var value interface{}
+	decoder := json.NewDecoder(bytes.NewReader(params))
+	decoder.UseNumber()
+	if decoder.Decode(&value) != nil {
+		return key + "/" + string(params)
+	}
+	canonical, _ := json.Marshal(value)
+	return key + "/" + string(canonical)
+}
+
+// isEvent 判断收到的帧是否为事件（而非 API 响应）
+func isEvent(data json.RawMessage) bool {
+	var probe struct {
+		PostType string `json:"post_type"`
+	}
+	return json.Unmarshal(data, &probe) == nil && probe.PostType != ""
+}
+
+// replayConn 重放连接，事件发送和响应可能并发写
+type replayConn struct {
+	conn *websocket.Conn
+	mu   sync.Mutex
+}
+
+func (c *replayConn) write(data []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.conn.WriteMessage(websocket.TextMessage, data)
+}
+
+// Summary 返回结果摘要，未匹配的 action 按出现次数排序
+func (res *Result) Summary() string {
+	counts := make(map[string]int)
+	for _, action := range res.Unmatched {
+		counts[action]++
+	}
+	actions := make([]string, 0, len(counts))
+	for action := range counts {
+		actions = append(actions, action)
+	}
+	sort.Slice(actions, func(i, j int) bool { return counts[actions[i]] > counts[actions[j]] })
+
+	summary := fmt.Sprintf("%d events, %d API calls, %d unmatched", res.Events, res.Calls, len(res.Unmatched))
+	for _, action := range actions {
+		summary += fmt.Sprintf("\n  %s x%d", action, counts[action])
+	}
+	return summary
+}
@@ -0,0 +1,278 @@
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Spec 调度规则，返回 after 之后的下一次执行时间，零值表示不再执行
+type Spec interface {
+	Next(after time.Time) time.Time
+}
+
+// ============ 固定间隔 ============
+
+type every time.Duration
+
+// Every 每隔 d 执行一次
+func Every(d time.Duration) Spec {
+	if d < time.Second {
+		d = time.Second
+	}
+	return every(d)
+}
+
+func (e every) Next(after time.Time) time.Time {
+	return after.Add(time.Duration(e))
+}
+
+func (e every) String() string {
+	return "every " + time.Duration(e).String()
+}
+
+// ============ 一次性定时 ============
+
+type once time.Time
+
+// At 在 t 执行一次
+func At(t time.Time) Spec {
+	return once(t)
+}
+
+// After 在 d 之后执行一次
+func After(d time.Duration) Spec {
+	return once(time.Now().Add(d))
+}
+
+func (o once) Next(after time.Time) time.Time {
+	if t := time.Time(o); after.Before(t) {
+		return t
+	}
+	return time.Time{}
+}
+
+func (o once) String() string {
+	return "at " + time.Time(o).Format(time.DateTime)
+}
+
+// ============ Cron 表达式 ============
+
+// Cron 标准 5 字段 cron 表达式：分 时 日 月 周
+type Cron struct {
+	expr   string
+	minute uint64
+	hour   uint64
+	dom    uint64
+	month  uint64
+	dow    uint64
+	anyDom bool
+	anyDow bool
+	loc    *time.Location // 为空时使用调度器的时区
+}
+
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var monthNames = map[string]int{
+	"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+	"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+}
+
+var dowNames = map[string]int{
+	"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+}
+
+// ParseCron 解析 cron 表达式
+//
+// 支持 *、列表（1,15）、范围（1-5）、步长（*/10、8-18/2）、月份和星期的英文缩写（JAN、MON），
+// 星期中 0 和 7 都表示周日；也支持 @hourly、@daily、@weekly、@monthly、@yearly。
+// 表达式前可以加 "TZ=Asia/Shanghai " 指定时区。
+// 日和周都不是 * 时，满足其一即执行（与标准 cron 一致）。
+func ParseCron(expr string) (*Cron, error) {
+	c := &Cron{expr: expr}
+	spec := strings.TrimSpace(expr)
+
+	if strings.HasPrefix(spec, "TZ=") || strings.HasPrefix(spec, "CRON_TZ=") {
+		tz, rest, _ := strings.Cut(spec, " ")
+		_, name, _ := strings.Cut(tz, "=")
+		loc, err := time.LoadLocation(name)
+		if err != nil {
+			return nil, fmt.Errorf("cron %q: invalid time zone %q", expr, name)
+		}
+		c.loc = loc
+		spec = strings.TrimSpace(rest)
+	}
+	if replaced, ok := descriptors[strings.ToLower(spec)]; ok {
+		spec = replaced
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron %q: expected 5 fields (minute hour day month weekday), got %d", expr, len(fields))
+	}
+
+	var err error
+	parse := func(i int, name string, min, max int, names map[string]int) uint64 {
+		if err != nil {
+			return 0
+		}
+		var bits uint64
+		bits, err = parseField(fields[i], min, max, names)
+		if err != nil {
+			err = fmt.Errorf("cron %q: %s: %w", expr, name, err)
+		}
+		return bits
+	}
+	c.minute = parse(0, "minute", 0, 59, nil)
+	c.hour = parse(1, "hour", 0, 23, nil)
+	c.dom = parse(2, "day of month", 1, 31, nil)
+	c.month = parse(3, "month", 1, 12, monthNames)
+	c.dow = parse(4, "day of week", 0, 7, dowNames)
+	if err != nil {
+		return nil, err
+	}
+
+	// 7 也表示周日
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1
+	}
+	c.anyDom = fields[2] == "*" || fields[2] == "?"
+	c.anyDow = fields[4] == "*" || fields[4] == "?"
+	return c, nil
+}
+
+// MustParseCron 解析 cron 表达式，失败时 panic
+func MustParseCron(expr string) *Cron {
+	c, err := ParseCron(expr)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+func parseField(field string, min, max int, names map[string]int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+
+		lo, hi := min, max
+		if rangePart != "*" && rangePart != "?" {
+			start, end, isRange := strings.Cut(rangePart, "-")
+			var err error
+			if lo, err = parseValue(start, names); err != nil {
+				return 0, err
+			}
+			hi = lo
+			if isRange {
+				if hi, err = parseValue(end, names); err != nil {
+					return 0, err
+				}
+			} else if hasStep {
+				hi = max
+			}
+		}
+
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepPart)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step %q", stepPart)
+			}
+			step = n
+		}
+
+		if lo < min || hi > max || lo > hi {
+			return 0, fmt.Errorf("%q out of range %d-%d", part, min, max)
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+func parseValue(s string, names map[string]int) (int, error) {
+	if v, ok := names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", s)
+	}
+	return v, nil
+}
+
+// Location 返回表达式中指定的时区，未指定时返回 nil
+func (c *Cron) Location() *time.Location {
+	return c.loc
+}
+
+func (c *Cron) String() string {
+	return c.expr
+}
+
+// Next 返回 after 之后（不含）的下一次执行时间，使用 after 所在时区（表达式指定了时区时使用该时区）
+func (c *Cron) Next(after time.Time) time.Time {
+	loc := after.Location()
+	if c.loc != nil {
+		loc = c.loc
+	}
+	t := after.In(loc)
+	t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, loc).Add(time.Minute)
+
+	// 超过 5 年仍未匹配（如 2 月 30 日）时放弃
+	yearLimit := t.Year() + 5
+
+wrap:
+	if t.Year() > yearLimit {
+		return time.Time{}
+	}
+
+	for c.month&(1<<uint(t.Month())) == 0 {
+		t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		if t.Month() == time.January {
+			goto wrap
+		}
+	}
+
+	for !c.dayMatches(t) {
+		t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		if t.Day() == 1 {
+			goto wrap
+		}
+	}
+
+	for c.hour&(1<<uint(t.Hour())) == 0 {
+		t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+		if t.Hour() == 0 {
+			goto wrap
+		}
+	}
+
+	for c.minute&(1<<uint(t.Minute())) == 0 {
+		t = t.Add(time.Minute)
+		if t.Minute() == 0 {
+			goto wrap
+		}
+	}
+
+	return t
+}
+
+func (c *Cron) dayMatches(t time.Time) bool {
+	domMatch := c.dom&(1<<uint(t.Day())) != 0
+	dowMatch := c.dow&(1<<uint(t.Weekday())) != 0
+	if c.anyDom || c.anyDow {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
@@ -0,0 +1,113 @@
+package schedule
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseCronErrors(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{expr: "* * * *", want: "expected 5 fields"},
+		{expr: "* * * * * *", want: "expected 5 fields"},
+		{expr: "60 * * * *", want: "minute"},
+		{expr: "* 24 * * *", want: "hour"},
+		{expr: "* * 0 * *", want: "day of month"},
+		{expr: "* * * 13 *", want: "month"},
+		{expr: "* * * * 8", want: "day of week"},
+		{expr: "*/0 * * * *", want: "invalid step"},
+		{expr: "5-1 * * * *", want: "out of range"},
+		{expr: "* * * foo *", want: "invalid value"},
+		{expr: "TZ=Nowhere/City 0 8 * * *", want: "invalid time zone"},
+	}
+	for _, tt := range tests {
+		_, err := ParseCron(tt.expr)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("ParseCron(%q) error = %v, want %q", tt.expr, err, tt.want)
+		}
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	shanghai, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		t.Skip("time zone data not available")
+	}
+	at := func(s string) time.Time {
+		t.Helper()
+		v, err := time.ParseInLocation("2006-01-02 15:04", s, time.UTC)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+
+	tests := []struct {
+		expr  string
+		after string
+		want  string // 为空表示不再执行
+	}{
+		{expr: "* * * * *", after: "2024-01-01 10:00", want: "2024-01-01 10:01"},
+		{expr: "30 8 * * *", after: "2024-01-01 08:30", want: "2024-01-02 08:30"},
+		{expr: "30 8 * * *", after: "2024-01-01 08:29", want: "2024-01-01 08:30"},
+		{expr: "*/15 * * * *", after: "2024-01-01 10:16", want: "2024-01-01 10:30"},
+		{expr: "0 8-18/4 * * *", after: "2024-01-01 12:00", want: "2024-01-01 16:00"},
+		{expr: "0 0,12 * * *", after: "2024-01-01 12:00", want: "2024-01-02 00:00"},
+		{expr: "0 9 * * MON-FRI", after: "2024-01-05 10:00", want: "2024-01-08 09:00"}, // 周五之后是周一
+		{expr: "0 0 * * 7", after: "2024-01-01 00:00", want: "2024-01-07 00:00"},       // 7 表示周日
+		{expr: "0 0 1 JAN *", after: "2024-01-01 00:00", want: "2025-01-01 00:00"},
+		{expr: "0 0 31 * *", after: "2024-01-31 00:00", want: "2024-03-31 00:00"},  // 跳过没有 31 日的月份
+		{expr: "0 0 29 2 *", after: "2024-03-01 00:00", want: "2028-02-29 00:00"},  // 闰年
+		{expr: "0 0 1 * MON", after: "2024-01-01 00:00", want: "2024-01-08 00:00"}, // 日和周满足其一即可
+		{expr: "0 0 30 2 *", after: "2024-01-01 00:00"},                            // 永不匹配
+		{expr: "@hourly", after: "2024-01-01 10:59", want: "2024-01-01 11:00"},
+		{expr: "@weekly", after: "2024-01-01 00:00", want: "2024-01-07 00:00"},
+		{expr: "@monthly", after: "2024-12-15 00:00", want: "2025-01-01 00:00"},
+		{expr: "TZ=Asia/Shanghai 0 8 * * *", after: "2024-01-01 00:00", want: "2024-01-02 00:00"}, // 上海 8 点即 UTC 0 点
+	}
+
+	for _, tt := range tests {
+		c, err := ParseCron(tt.expr)
+		if err != nil {
+			t.Errorf("ParseCron(%q): %v", tt.expr, err)
+			continue
+		}
+		got := c.Next(at(tt.after))
+		if tt.want == "" {
+			if !got.IsZero() {
+				t.Errorf("%q after %s = %v, want zero", tt.expr, tt.after, got)
+			}
+			continue
+		}
+		if !got.Equal(at(tt.want)) {
+			t.Errorf("%q after %s = %v, want %s UTC", tt.expr, tt.after, got.UTC(), tt.want)
+		}
+	}
+
+	if c := MustParseCron("TZ=Asia/Shanghai @daily"); c.Location().String() != shanghai.String() {
+		t.Errorf("Location() = %v, want %v", c.Location(), shanghai)
+	}
+}
+
+func TestEveryAndOnce(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		spec  Spec
+		after time.Time
+		want  time.Time
+	}{
+		{name: "every", spec: Every(time.Minute), after: base, want: base.Add(time.Minute)},
+		{name: "every has a minimum", spec: Every(time.Millisecond), after: base, want: base.Add(time.Second)},
+		{name: "at before", spec: At(base), after: base.Add(-time.Second), want: base},
+		{name: "at passed", spec: At(base), after: base},
+	}
+	for _, tt := range tests {
+		if got := tt.spec.Next(tt.after); !got.Equal(tt.want) {
+			t.Errorf("%s: Next() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
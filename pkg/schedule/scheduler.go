@@ -0,0 +1,395 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"onebot-go2/pkg/event"
+	"onebot-go2/pkg/storage"
+)
+
+// Tick 定时任务触发信息，作为任务 Context 的事件
+type Tick struct {
+	Job       string    // 任务名称
+	Scheduled time.Time // 计划执行时间
+	Missed    int       // 断线期间错过的次数（仅 CatchUp 策略）
+}
+
+// Job 定时任务函数，ctx 提供与事件处理器相同的 API（SendGroupMsg、BanAllGroupMembers 等）
+// Stop 时 ctx 会被取消
+type Job func(ctx *event.Context[*Tick]) error
+
+// MissPolicy 机器人未连接时到期任务的处理方式
+type MissPolicy int
+
+const (
+	// Skip 跳过本次执行（默认）
+	Skip MissPolicy = iota
+	// CatchUp 重新连接后补执行一次，Tick.Missed 为错过的次数
+	CatchUp
+	// Always 无论是否连接都执行，适用于不调用 API 的任务
+	Always
+)
+
+// JobOption 任务选项
+type JobOption func(*job)
+
+// OnMiss 设置未连接时的处理方式
+func OnMiss(policy MissPolicy) JobOption {
+	return func(j *job) {
+		j.policy = policy
+	}
+}
+
+// InLocation 设置任务的时区，默认使用调度器的时区
+func InLocation(loc *time.Location) JobOption {
+	return func(j *job) {
+		j.loc = loc
+	}
+}
+
+// WithStorage 设置任务中 ctx.Storage() 返回的存储
+func WithStorage(store storage.Store) JobOption {
+	return func(j *job) {
+		j.storage = store
+	}
+}
+
+// job 已添加的任务
+type job struct {
+	name    string
+	spec    Spec
+	run     Job
+	policy  MissPolicy
+	loc     *time.Location
+	storage storage.Store
+
+	next    time.Time // 下一次计划执行时间，零值表示不再执行
+	prev    time.Time // 上一次执行时间
+	missed  int       // 待补执行的次数
+	running bool
+}
+
+// JobInfo 任务状态
+type JobInfo struct {
+	Name   string
+	Spec   string
+	Next   time.Time
+	Prev   time.Time
+	Missed int
+}
+
+// Scheduler 定时任务调度器
+// 支持 cron 表达式、固定间隔和一次性定时；Stop 时取消任务 Context 并等待运行中的任务结束
+type Scheduler struct {
+	server event.ServerInterface
+	now    func() time.Time
+	logger *slog.Logger
+
+	mu      sync.Mutex
+	loc     *time.Location
+	jobs    map[string]*job
+	storage storage.Store
+	wake    chan struct{}
+	ctx     context.Context
+	cancel  context.CancelFunc
+	done    chan struct{}
+	wg      sync.WaitGroup
+}
+
+// New 创建调度器，server 用于任务中调用 API 和判断连接状态
+func New(server event.ServerInterface) *Scheduler {
+	return &Scheduler{
+		server: server,
+		now:    time.Now,
+		logger: slog.Default().With("component", "scheduler"),
+		loc:    time.Local,
+		jobs:   make(map[string]*job),
+		wake:   make(chan struct{}, 1),
+	}
+}
+
+// SetLocation 设置默认时区，需要在添加任务之前调用
+func (s *Scheduler) SetLocation(loc *time.Location) *Scheduler {
+	s.mu.Lock()
+	s.loc = loc
+	s.mu.Unlock()
+	return s
+}
+
+// SetLogger 设置日志记录器，默认使用创建时的 slog.Default()
+// 任务中 ctx.Logger() 在此基础上附加 job 字段
+func (s *Scheduler) SetLogger(logger *slog.Logger) *Scheduler {
+	s.mu.Lock()
+	s.logger = logger.With("component", "scheduler")
+	s.mu.Unlock()
+	return s
+}
+
+// SetStorage 设置任务默认使用的存储
+func (s *Scheduler) SetStorage(store storage.Store) *Scheduler {
+	s.mu.Lock()
+	s.storage = store
+	s.mu.Unlock()
+	return s
+}
+
+// Add 添加任务，同名任务已存在时返回错误
+func (s *Scheduler) Add(name string, spec Spec, run Job, opts ...JobOption) error {
+	if name == "" || spec == nil || run == nil {
+		return errors.New("job name, spec and func are required")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.jobs[name]; exists {
+		return fmt.Errorf("job %s already exists", name)
+	}
+	j := &job{name: name, spec: spec, run: run, loc: s.loc, storage: s.storage}
+	for _, opt := range opts {
+		opt(j)
+	}
+	j.next = j.nextAfter(s.now())
+	if j.next.IsZero() {
+		return fmt.Errorf("job %s will never run", name)
+	}
+	s.jobs[name] = j
+	s.notify()
+
+	s.logger.Info("Added job", "job", name, "spec", fmt.Sprint(spec), "next", j.next.Format(time.DateTime))
+	return nil
+}
+
+// Cron 使用 cron 表达式添加任务
+func (s *Scheduler) Cron(name, expr string, run Job, opts ...JobOption) error {
+	spec, err := ParseCron(expr)
+	if err != nil {
+		return err
+	}
+	return s.Add(name, spec, run, opts...)
+}
+
+// Remove 移除任务，不影响正在运行的本次执行
+func (s *Scheduler) Remove(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.jobs[name]; !ok {
+		return false
+	}
+	delete(s.jobs, name)
+	s.notify()
+	s.logger.Info("Removed job", "job", name)
+	return true
+}
+
+// RemovePrefix 移除名称以 prefix 开头的所有任务
+func (s *Scheduler) RemovePrefix(prefix string) int {
+	s.mu.Lock()
+	var names []string
+	for name := range s.jobs {
+		if strings.HasPrefix(name, prefix) {
+			names = append(names, name)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, name := range names {
+		s.Remove(name)
+	}
+	return len(names)
+}
+
+// Jobs 返回所有任务的状态，按下一次执行时间排序（不再执行的任务在最后）
+func (s *Scheduler) Jobs() []JobInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	infos := make([]JobInfo, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		infos = append(infos, JobInfo{
+			Name:   j.name,
+			Spec:   fmt.Sprint(j.spec),
+			Next:   j.next,
+			Prev:   j.prev,
+			Missed: j.missed,
+		})
+	}
+	sort.Slice(infos, func(i, k int) bool {
+		if infos[i].Next.IsZero() != infos[k].Next.IsZero() {
+			return infos[k].Next.IsZero()
+		}
+		return infos[i].Next.Before(infos[k].Next)
+	})
+	return infos
+}
+
+// Start 启动调度器
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.done != nil {
+		return
+	}
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	s.done = make(chan struct{})
+	go s.loop()
+	s.logger.Info("Started", "jobs", len(s.jobs), "time_zone", s.loc.String())
+}
+
+// Stop 停止调度器，取消运行中任务的 Context 并等待其结束
+func (s *Scheduler) Stop() {
+	s.mu.Lock()
+	if s.done == nil {
+		s.mu.Unlock()
+		return
+	}
+	s.cancel()
+	done := s.done
+	s.mu.Unlock()
+
+	<-done
+	s.wg.Wait()
+
+	s.mu.Lock()
+	s.done = nil
+	s.mu.Unlock()
+	s.logger.Info("Stopped")
+}
+
+// notify 唤醒调度循环重新计算等待时间，调用方需持有锁
+func (s *Scheduler) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// catchUpInterval 有待补执行的任务时检查连接状态的间隔
+const catchUpInterval = time.Second
+
+func (s *Scheduler) loop() {
+	defer close(s.done)
+
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+
+	for {
+		timer.Reset(s.tick())
+
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-s.wake:
+		case <-timer.C:
+		}
+	}
+}
+
+// tick 执行到期任务，返回到下一个任务的等待时间
+func (s *Scheduler) tick() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	connected := s.server == nil || s.server.IsConnected()
+	wait := time.Hour
+	pending := false
+
+	for name, j := range s.jobs {
+		if !j.next.IsZero() && !now.Before(j.next) {
+			scheduled := j.next
+			switch {
+			case connected || j.policy == Always:
+				s.start(j, scheduled, j.missed)
+				j.missed = 0
+			case j.policy == CatchUp:
+				j.missed++
+				j.prev = scheduled
+				s.logger.Warn("Job missed (bot disconnected), will catch up after reconnecting", "job", name)
+			default:
+				s.logger.Warn("Job skipped (bot disconnected)", "job", name)
+			}
+
+			// 从计划时间计算下一次，保持固定节奏；落后太多时（如系统休眠）从当前时间计算
+			j.next = j.nextAfter(scheduled)
+			if !j.next.IsZero() && !j.next.After(now) {
+				j.next = j.nextAfter(now)
+			}
+		} else if j.missed > 0 && connected {
+			// 断线期间错过的任务，重新连接后补执行一次
+			s.start(j, j.prev, j.missed)
+			j.missed = 0
+		}
+
+		if j.missed > 0 {
+			pending = true
+		}
+		if j.next.IsZero() {
+			if j.missed == 0 && !j.running {
+				delete(s.jobs, name)
+				s.logger.Info("Job finished", "job", name)
+			}
+			continue
+		}
+		if d := j.next.Sub(now); d < wait {
+			wait = d
+		}
+	}
+
+	if pending && wait > catchUpInterval {
+		wait = catchUpInterval
+	}
+	return max(wait, 0)
+}
+
+// start 在新的 goroutine 中执行任务，上一次执行未结束时跳过，调用方需持有锁
+func (s *Scheduler) start(j *job, scheduled time.Time, missed int) {
+	if j.running {
+		s.logger.Warn("Job is still running, skipping run", "job", j.name, "scheduled", scheduled.Format(time.DateTime))
+		return
+	}
+	j.running = true
+	j.prev = scheduled
+
+	logger := s.logger.With("job", j.name)
+	ctx := event.NewContext(s.ctx, &Tick{Job: j.name, Scheduled: scheduled, Missed: missed}).WithLogger(logger)
+	if s.server != nil {
+		ctx.WithServer(s.server)
+	}
+	if j.storage != nil {
+		ctx.WithStorage(j.storage)
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer func() {
+			if r := recover(); r != nil {
+				logger.Error("Panic in job", "panic", r)
+			}
+			s.mu.Lock()
+			j.running = false
+			s.notify()
+			s.mu.Unlock()
+		}()
+
+		start := time.Now()
+		if err := j.run(ctx); err != nil {
+			logger.Error("Job failed", "duration", time.Since(start), "error", err)
+			return
+		}
+		logger.Info("Job done", "duration", time.Since(start))
+	}()
+}
+
+// nextAfter 在任务时区中计算下一次执行时间
+func (j *job) nextAfter(t time.Time) time.Time {
+	return j.spec.Next(t.In(j.loc))
+}
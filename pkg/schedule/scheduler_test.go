@@ -0,0 +1,196 @@
+package schedule
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"onebot-go2/pkg/event"
+)
+
+// testServer 可以切换连接状态的 TestServer
+type testServer struct {
+	*event.TestServer
+	connected atomic.Bool
+}
+
+func (s *testServer) IsConnected() bool {
+	return s.connected.Load()
+}
+
+// testScheduler 创建不启动调度循环的调度器，测试中通过 advance 手动推进时间并执行 tick
+type testScheduler struct {
+	*Scheduler
+	server *testServer
+	now    time.Time
+
+	mu    sync.Mutex
+	ticks []Tick
+}
+
+func newTestScheduler(t *testing.T) *testScheduler {
+	t.Helper()
+	ts := &testScheduler{
+		server: &testServer{TestServer: event.NewTestServer()},
+		now:    time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	ts.server.connected.Store(true)
+	ts.Scheduler = New(ts.server).SetLocation(time.UTC)
+	ts.Scheduler.now = func() time.Time { return ts.now }
+	ts.Scheduler.ctx = context.Background()
+	return ts
+}
+
+func (ts *testScheduler) record(ctx *event.Context[*Tick]) error {
+	ts.mu.Lock()
+	ts.ticks = append(ts.ticks, *ctx.Event)
+	ts.mu.Unlock()
+	return nil
+}
+
+// advance 推进时间并执行一次 tick，等待启动的任务结束
+func (ts *testScheduler) advance(d time.Duration) {
+	ts.now = ts.now.Add(d)
+	ts.tick()
+	ts.wg.Wait()
+}
+
+func (ts *testScheduler) runs() []Tick {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	return append([]Tick(nil), ts.ticks...)
+}
+
+func TestMissPolicy(t *testing.T) {
+	type step struct {
+		advance   time.Duration
+		connected bool
+		runs      int // 累计执行次数
+		missed    int // 最近一次执行的 Tick.Missed
+	}
+
+	tests := []struct {
+		name   string
+		policy MissPolicy
+		steps  []step
+	}{
+		{
+			name:   "skip",
+			policy: Skip,
+			steps: []step{
+				{advance: time.Minute, connected: true, runs: 1},
+				{advance: time.Minute, connected: false, runs: 1},
+				{advance: time.Minute, connected: false, runs: 1},
+				{advance: time.Second, connected: true, runs: 1}, // 重新连接后不补执行
+				{advance: time.Minute, connected: true, runs: 2},
+			},
+		},
+		{
+			name:   "catch up",
+			policy: CatchUp,
+			steps: []step{
+				{advance: time.Minute, connected: true, runs: 1},
+				{advance: time.Minute, connected: false, runs: 1},
+				{advance: time.Minute, connected: false, runs: 1},
+				{advance: time.Second, connected: true, runs: 2, missed: 2}, // 补执行一次，记录错过的次数
+				{advance: time.Minute, connected: true, runs: 3},
+			},
+		},
+		{
+			name:   "always",
+			policy: Always,
+			steps: []step{
+				{advance: time.Minute, connected: false, runs: 1},
+				{advance: time.Minute, connected: false, runs: 2},
+				{advance: time.Second, connected: true, runs: 2},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := newTestScheduler(t)
+			if err := ts.Add("job", Every(time.Minute), ts.record, OnMiss(tt.policy)); err != nil {
+				t.Fatal(err)
+			}
+
+			for i, st := range tt.steps {
+				ts.server.connected.Store(st.connected)
+				ts.advance(st.advance)
+
+				runs := ts.runs()
+				if len(runs) != st.runs {
+					t.Fatalf("step %d: runs = %d, want %d", i, len(runs), st.runs)
+				}
+				if last := runs[len(runs)-1]; last.Missed != st.missed {
+					t.Errorf("step %d: Missed = %d, want %d", i, last.Missed, st.missed)
+				}
+			}
+		})
+	}
+}
+
+func TestSchedulerKeepsCadence(t *testing.T) {
+	ts := newTestScheduler(t)
+	start := ts.now
+	if err := ts.Add("job", Every(time.Minute), ts.record); err != nil {
+		t.Fatal(err)
+	}
+
+	// 晚 10 秒执行时，下一次仍按计划时间计算
+	ts.advance(time.Minute + 10*time.Second)
+	if next := ts.Jobs()[0].Next; !next.Equal(start.Add(2 * time.Minute)) {
+		t.Errorf("Next = %v, want %v", next, start.Add(2*time.Minute))
+	}
+
+	// 落后多个周期时（如系统休眠）从当前时间计算，只执行一次
+	ts.advance(time.Hour)
+	if got := len(ts.runs()); got != 2 {
+		t.Errorf("runs = %d, want 2", got)
+	}
+	if next := ts.Jobs()[0].Next; !next.After(ts.now) {
+		t.Errorf("Next = %v, want after %v", next, ts.now)
+	}
+	if scheduled := ts.runs()[1].Scheduled; !scheduled.Equal(start.Add(2 * time.Minute)) {
+		t.Errorf("Scheduled = %v, want %v", scheduled, start.Add(2*time.Minute))
+	}
+}
+
+func TestSchedulerOneShot(t *testing.T) {
+	ts := newTestScheduler(t)
+	if err := ts.Add("once", At(ts.now.Add(time.Minute)), ts.record); err != nil {
+		t.Fatal(err)
+	}
+	if err := ts.Add("past", At(ts.now.Add(-time.Minute)), ts.record); err == nil {
+		t.Error("Add() should reject a job that will never run")
+	}
+	if err := ts.Add("once", Every(time.Minute), ts.record); err == nil {
+		t.Error("Add() should reject duplicate names")
+	}
+
+	ts.advance(time.Minute)
+	ts.advance(time.Second) // 执行结束后移除
+	if got := len(ts.runs()); got != 1 {
+		t.Errorf("runs = %d, want 1", got)
+	}
+	if jobs := ts.Jobs(); len(jobs) != 0 {
+		t.Errorf("Jobs() = %v, want finished job removed", jobs)
+	}
+}
+
+func TestSchedulerRemovePrefix(t *testing.T) {
+	ts := newTestScheduler(t)
+	for _, name := range []string{"weather.daily", "weather.hourly", "news.daily"} {
+		if err := ts.Cron(name, "@hourly", ts.record); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := ts.RemovePrefix("weather."); n != 2 {
+		t.Errorf("RemovePrefix() = %d, want 2", n)
+	}
+	if jobs := ts.Jobs(); len(jobs) != 1 || jobs[0].Name != "news.daily" {
+		t.Errorf("Jobs() = %v, want [news.daily]", jobs)
+	}
+}
@@ -0,0 +1,326 @@
+package storage
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// DefaultCompactThreshold 日志中的无效记录超过该数量且多于有效记录时自动压缩
+const DefaultCompactThreshold = 1000
+
+// record 日志中的一条记录
+type record struct {
+	Op      string `json:"op"` // set, del
+	Key     string `json:"k"`
+	Value   []byte `json:"v,omitempty"` // base64
+	Expires int64  `json:"e,omitempty"` // 过期时间（Unix 纳秒），0 表示永不过期
+}
+
+// File 基于追加日志的文件存储，无需外部服务
+//
+// 每次写入追加一条记录，启动时重放日志恢复数据；
+// 覆盖和删除产生的无效记录过多时自动压缩（重写为当前数据的快照）。
+type File struct {
+	path      string
+	threshold int
+
+	mu      sync.Mutex
+	table   table
+	file    *os.File
+	writer  *bufio.Writer
+	records int // 日志中的记录数
+	closed  bool
+}
+
+// OpenFile 打开（或创建）文件存储
+func OpenFile(path string) (*File, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create storage directory: %w", err)
+	}
+
+	f := &File{
+		path:      path,
+		threshold: DefaultCompactThreshold,
+		table:     newTable(),
+	}
+	corrupted, err := f.load()
+	if err != nil {
+		return nil, err
+	}
+
+	// 日志损坏（如写入时进程被杀）时立即压缩，丢弃损坏的记录
+	if corrupted > 0 {
+		slog.Warn("Skipped corrupted records, compacting", "component", "storage", "path", path, "corrupted", corrupted)
+		if err := f.compact(); err != nil {
+			return nil, err
+		}
+		return f, nil
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open storage: %w", err)
+	}
+	f.file = file
+	f.writer = bufio.NewWriter(file)
+	return f, nil
+}
+
+// SetCompactThreshold 设置自动压缩阈值，<= 0 表示不自动压缩
+func (f *File) SetCompactThreshold(n int) *File {
+	f.mu.Lock()
+	f.threshold = n
+	f.mu.Unlock()
+	return f
+}
+
+// load 重放日志，返回损坏的记录数
+func (f *File) load() (int, error) {
+	file, err := os.Open(f.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to open storage: %w", err)
+	}
+	defer file.Close()
+
+	corrupted := 0
+	now := f.table.now()
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		var rec record
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			corrupted++
+			continue
+		}
+		f.records++
+
+		switch rec.Op {
+		case "set":
+			it := item{value: rec.Value}
+			if rec.Expires != 0 {
+				it.expires = time.Unix(0, rec.Expires)
+			}
+			if it.expired(now) {
+				delete(f.table.items, rec.Key)
+			} else {
+				f.table.items[rec.Key] = it
+			}
+		case "del":
+			delete(f.table.items, rec.Key)
+		default:
+			corrupted++
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, fmt.Errorf("failed to read storage %s: %w", f.path, err)
+	}
+	return corrupted, nil
+}
+
+// append 写入一条记录并刷新到文件，调用方需持有锁
+func (f *File) append(rec record) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	if _, err := f.writer.Write(data); err != nil {
+		return err
+	}
+	if err := f.writer.Flush(); err != nil {
+		return err
+	}
+	f.records++
+	return nil
+}
+
+func (f *File) setRecord(key string, it item) record {
+	rec := record{Op: "set", Key: key, Value: it.value}
+	if !it.expires.IsZero() {
+		rec.Expires = it.expires.UnixNano()
+	}
+	return rec
+}
+
+// maybeCompact 无效记录过多时压缩，调用方需持有锁
+func (f *File) maybeCompact() {
+	live := len(f.table.items)
+	if f.threshold <= 0 || f.records-live < f.threshold || f.records-live < live {
+		return
+	}
+	if err := f.compact(); err != nil {
+		slog.Error("Failed to compact storage", "component", "storage", "path", f.path, "error", err)
+	}
+}
+
+// compact 将当前数据写入新文件并替换日志，调用方需持有锁
+func (f *File) compact() error {
+	tmp := f.path + ".compact"
+	file, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to compact storage: %w", err)
+	}
+	writer := bufio.NewWriter(file)
+
+	records := 0
+	now := f.table.now()
+	for key, it := range f.table.items {
+		if it.expired(now) {
+			delete(f.table.items, key)
+			continue
+		}
+		data, err := json.Marshal(f.setRecord(key, it))
+		if err != nil {
+			file.Close()
+			return err
+		}
+		writer.Write(append(data, '\n'))
+		records++
+	}
+	if err := writer.Flush(); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to compact storage: %w", err)
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to compact storage: %w", err)
+	}
+	if err := os.Rename(tmp, f.path); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to compact storage: %w", err)
+	}
+
+	if f.file != nil {
+		f.file.Close()
+	}
+	// 重命名后 file 指向新的日志文件，继续以追加方式写入
+	f.file = file
+	f.writer = bufio.NewWriter(file)
+	f.records = records
+	slog.Debug("Compacted storage", "component", "storage", "path", f.path, "records", records)
+	return nil
+}
+
+// Compact 手动压缩日志
+func (f *File) Compact() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return ErrClosed
+	}
+	return f.compact()
+}
+
+func (f *File) Get(key string) ([]byte, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return nil, ErrClosed
+	}
+	it, ok := f.table.get(key)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return append([]byte{}, it.value...), nil
+}
+
+func (f *File) Set(key string, value []byte, ttl time.Duration) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return ErrClosed
+	}
+
+	it := item{value: append([]byte{}, value...), expires: f.table.expiry(ttl)}
+	if err := f.append(f.setRecord(key, it)); err != nil {
+		return err
+	}
+	f.table.items[key] = it
+	f.maybeCompact()
+	return nil
+}
+
+func (f *File) Delete(key string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return ErrClosed
+	}
+
+	if _, ok := f.table.items[key]; !ok {
+		return nil
+	}
+	if err := f.append(record{Op: "del", Key: key}); err != nil {
+		return err
+	}
+	delete(f.table.items, key)
+	f.maybeCompact()
+	return nil
+}
+
+func (f *File) List(prefix string) ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return nil, ErrClosed
+	}
+	return f.table.list(prefix), nil
+}
+
+func (f *File) Update(key string, ttl time.Duration, fn UpdateFunc) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return ErrClosed
+	}
+
+	next, err := f.table.update(key, ttl, fn)
+	if err != nil {
+		return err
+	}
+	if next.value == nil {
+		if _, ok := f.table.items[key]; !ok {
+			return nil
+		}
+		if err := f.append(record{Op: "del", Key: key}); err != nil {
+			return err
+		}
+		delete(f.table.items, key)
+	} else {
+		if err := f.append(f.setRecord(key, next)); err != nil {
+			return err
+		}
+		f.table.items[key] = next
+	}
+	f.maybeCompact()
+	return nil
+}
+
+// Close 刷新并关闭日志文件
+func (f *File) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return nil
+	}
+	f.closed = true
+	if err := f.writer.Flush(); err != nil {
+		f.file.Close()
+		return err
+	}
+	if err := f.file.Sync(); err != nil {
+		f.file.Close()
+		return err
+	}
+	return f.file.Close()
+}
@@ -0,0 +1,164 @@
+package storage
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// item 存储的值
+type item struct {
+	value   []byte
+	expires time.Time // 零值表示永不过期
+}
+
+func (i item) expired(now time.Time) bool {
+	return !i.expires.IsZero() && !now.Before(i.expires)
+}
+
+// table 内存中的键值表，Memory 和 File 共用；调用方负责加锁
+type table struct {
+	items map[string]item
+	now   func() time.Time
+}
+
+func newTable() table {
+	return table{items: make(map[string]item), now: time.Now}
+}
+
+func (t *table) get(key string) (item, bool) {
+	it, ok := t.items[key]
+	if !ok {
+		return item{}, false
+	}
+	if it.expired(t.now()) {
+		delete(t.items, key)
+		return item{}, false
+	}
+	return it, true
+}
+
+// expiry 根据 ttl 计算过期时间
+func (t *table) expiry(ttl time.Duration) time.Time {
+	if ttl <= 0 {
+		return time.Time{}
+	}
+	return t.now().Add(ttl)
+}
+
+func (t *table) list(prefix string) []string {
+	now := t.now()
+	keys := make([]string, 0)
+	for key, it := range t.items {
+		if it.expired(now) {
+			delete(t.items, key)
+			continue
+		}
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// update 计算 Update 后的新值，返回 nil 的 item.value 表示删除
+func (t *table) update(key string, ttl time.Duration, fn UpdateFunc) (item, error) {
+	old, exists := t.get(key)
+	var current []byte
+	if exists {
+		current = append([]byte{}, old.value...)
+	}
+	value, err := fn(current, exists)
+	if err != nil || value == nil {
+		return item{}, err
+	}
+
+	next := item{value: append([]byte{}, value...), expires: t.expiry(ttl)}
+	if ttl == KeepTTL && exists {
+		next.expires = old.expires
+	}
+	return next, nil
+}
+
+// ============ 内存存储 ============
+
+// Memory 内存存储，进程退出后数据丢失，适用于测试或不需要持久化的场景
+type Memory struct {
+	mu     sync.Mutex
+	table  table
+	closed bool
+}
+
+// NewMemory 创建内存存储
+func NewMemory() *Memory {
+	return &Memory{table: newTable()}
+}
+
+func (m *Memory) Get(key string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return nil, ErrClosed
+	}
+	it, ok := m.table.get(key)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return append([]byte{}, it.value...), nil
+}
+
+func (m *Memory) Set(key string, value []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return ErrClosed
+	}
+	m.table.items[key] = item{value: append([]byte{}, value...), expires: m.table.expiry(ttl)}
+	return nil
+}
+
+func (m *Memory) Delete(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return ErrClosed
+	}
+	delete(m.table.items, key)
+	return nil
+}
+
+func (m *Memory) List(prefix string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return nil, ErrClosed
+	}
+	return m.table.list(prefix), nil
+}
+
+func (m *Memory) Update(key string, ttl time.Duration, fn UpdateFunc) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return ErrClosed
+	}
+	next, err := m.table.update(key, ttl, fn)
+	if err != nil {
+		return err
+	}
+	if next.value == nil {
+		delete(m.table.items, key)
+	} else {
+		m.table.items[key] = next
+	}
+	return nil
+}
+
+func (m *Memory) Close() error {
+	m.mu.Lock()
+	m.closed = true
+	m.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,124 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+// ErrNotFound key 不存在或已过期
+var ErrNotFound = errors.New("storage: key not found")
+
+// ErrClosed 存储已关闭
+var ErrClosed = errors.New("storage: closed")
+
+// KeepTTL 用于 Update，保留 key 原有的过期时间
+const KeepTTL time.Duration = -1
+
+// UpdateFunc 原子更新函数
+// value 为当前值，exists 表示 key 是否存在；返回 nil 表示删除该 key，返回错误时不做修改
+type UpdateFunc func(value []byte, exists bool) ([]byte, error)
+
+// Store 键值存储接口
+// ttl 为 0 表示永不过期；过期的 key 对 Get/List/Update 不可见
+type Store interface {
+	// Get 获取值，不存在时返回 ErrNotFound
+	Get(key string) ([]byte, error)
+	// Set 设置值
+	Set(key string, value []byte, ttl time.Duration) error
+	// Delete 删除 key，key 不存在时不返回错误
+	Delete(key string) error
+	// List 列出以 prefix 开头的 key（已排序）
+	List(prefix string) ([]string, error)
+	// Update 原子地读取并修改 key，ttl 为 KeepTTL 时保留原有过期时间
+	Update(key string, ttl time.Duration, fn UpdateFunc) error
+	// Close 关闭存储
+	Close() error
+}
+
+// ============ 命名空间 ============
+
+// namespaced 为所有 key 加上前缀的存储
+type namespaced struct {
+	store  Store
+	prefix string
+}
+
+// Namespace 返回 key 自动加上 name 前缀的存储，各插件使用独立的命名空间
+// 关闭命名空间不会关闭底层存储
+func Namespace(store Store, name string) Store {
+	return &namespaced{store: store, prefix: name + "/"}
+}
+
+func (n *namespaced) Get(key string) ([]byte, error) {
+	return n.store.Get(n.prefix + key)
+}
+
+func (n *namespaced) Set(key string, value []byte, ttl time.Duration) error {
+	return n.store.Set(n.prefix+key, value, ttl)
+}
+
+func (n *namespaced) Delete(key string) error {
+	return n.store.Delete(n.prefix + key)
+}
+
+func (n *namespaced) List(prefix string) ([]string, error) {
+	keys, err := n.store.List(n.prefix + prefix)
+	if err != nil {
+		return nil, err
+	}
+	for i, key := range keys {
+		keys[i] = strings.TrimPrefix(key, n.prefix)
+	}
+	return keys, nil
+}
+
+func (n *namespaced) Update(key string, ttl time.Duration, fn UpdateFunc) error {
+	return n.store.Update(n.prefix+key, ttl, fn)
+}
+
+func (n *namespaced) Close() error {
+	return nil
+}
+
+// ============ JSON 辅助函数 ============
+
+// GetJSON 获取并解析 JSON 值
+func GetJSON[T any](s Store, key string) (T, error) {
+	var value T
+	data, err := s.Get(key)
+	if err != nil {
+		return value, err
+	}
+	err = json.Unmarshal(data, &value)
+	return value, err
+}
+
+// SetJSON 将值序列化为 JSON 后保存
+func SetJSON(s Store, key string, value interface{}, ttl time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return s.Set(key, data, ttl)
+}
+
+// UpdateJSON 原子地更新 JSON 值，key 不存在时 fn 收到零值
+//
+//	storage.UpdateJSON(store, "count", storage.KeepTTL, func(n int) (int, error) { return n + 1, nil })
+func UpdateJSON[T any](s Store, key string, ttl time.Duration, fn func(value T) (T, error)) error {
+	return s.Update(key, ttl, func(data []byte, exists bool) ([]byte, error) {
+		var value T
+		if exists {
+			if err := json.Unmarshal(data, &value); err != nil {
+				return nil, err
+			}
+		}
+		value, err := fn(value)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(value)
+	})
+}
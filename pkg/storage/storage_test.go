@@ -0,0 +1,374 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// stores 返回需要测试的存储实现
+func stores(t *testing.T) map[string]Store {
+	t.Helper()
+	file, err := OpenFile(filepath.Join(t.TempDir(), "data.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { file.Close() })
+	return map[string]Store{"memory": NewMemory(), "file": file}
+}
+
+func TestStore(t *testing.T) {
+	appendByte := func(b byte) UpdateFunc {
+		return func(value []byte, exists bool) ([]byte, error) {
+			return append(value, b), nil
+		}
+	}
+
+	tests := []struct {
+		name  string
+		run   func(s Store) error
+		key   string
+		want  string // 为空表示期望 ErrNotFound
+		other func(t *testing.T, s Store)
+	}{
+		{
+			name: "set and get",
+			run:  func(s Store) error { return s.Set("a", []byte("1"), 0) },
+			key:  "a", want: "1",
+		},
+		{
+			name: "overwrite",
+			run: func(s Store) error {
+				s.Set("a", []byte("1"), 0)
+				return s.Set("a", []byte("2"), 0)
+			},
+			key: "a", want: "2",
+		},
+		{
+			name: "delete",
+			run: func(s Store) error {
+				s.Set("a", []byte("1"), 0)
+				return s.Delete("a")
+			},
+			key: "a",
+		},
+		{
+			name: "delete missing",
+			run:  func(s Store) error { return s.Delete("missing") },
+			key:  "missing",
+		},
+		{
+			name: "update creates and appends",
+			run: func(s Store) error {
+				s.Update("a", 0, appendByte('x'))
+				return s.Update("a", 0, appendByte('y'))
+			},
+			key: "a", want: "xy",
+		},
+		{
+			name: "update returning nil deletes",
+			run: func(s Store) error {
+				s.Set("a", []byte("1"), 0)
+				return s.Update("a", 0, func([]byte, bool) ([]byte, error) { return nil, nil })
+			},
+			key: "a",
+		},
+		{
+			name: "update error keeps value",
+			run: func(s Store) error {
+				s.Set("a", []byte("1"), 0)
+				err := s.Update("a", 0, func([]byte, bool) ([]byte, error) { return []byte("2"), errors.New("abort") })
+				if err == nil {
+					return errors.New("expected update error")
+				}
+				return nil
+			},
+			key: "a", want: "1",
+		},
+		{
+			name: "namespace",
+			run:  func(s Store) error { return Namespace(s, "plugin").Set("a", []byte("1"), 0) },
+			key:  "plugin/a", want: "1",
+			other: func(t *testing.T, s Store) {
+				keys, _ := Namespace(s, "plugin").List("")
+				if !reflect.DeepEqual(keys, []string{"a"}) {
+					t.Errorf("namespaced List() = %v, want [a]", keys)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		for name, s := range stores(t) {
+			t.Run(tt.name+"/"+name, func(t *testing.T) {
+				if err := tt.run(s); err != nil {
+					t.Fatal(err)
+				}
+				got, err := s.Get(tt.key)
+				if tt.want == "" {
+					if !errors.Is(err, ErrNotFound) {
+						t.Errorf("Get(%q) = %q, %v; want ErrNotFound", tt.key, got, err)
+					}
+				} else if err != nil || string(got) != tt.want {
+					t.Errorf("Get(%q) = %q, %v; want %q", tt.key, got, err, tt.want)
+				}
+				if tt.other != nil {
+					tt.other(t, s)
+				}
+			})
+		}
+	}
+}
+
+func TestStoreExpiry(t *testing.T) {
+	for name, s := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			now := time.Unix(1000, 0)
+			clock := func() time.Time { return now }
+			switch s := s.(type) {
+			case *Memory:
+				s.table.now = clock
+			case *File:
+				s.table.now = clock
+			}
+
+			s.Set("short", []byte("1"), time.Minute)
+			s.Set("long", []byte("1"), time.Hour)
+			s.Set("forever", []byte("1"), 0)
+			s.Update("long", KeepTTL, func(v []byte, _ bool) ([]byte, error) { return append(v, '2'), nil })
+
+			now = now.Add(30 * time.Minute)
+			keys, _ := s.List("")
+			if want := []string{"forever", "long"}; !reflect.DeepEqual(keys, want) {
+				t.Errorf("List() = %v, want %v", keys, want)
+			}
+			if _, err := s.Get("short"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("expired key: err = %v, want ErrNotFound", err)
+			}
+
+			now = now.Add(time.Hour)
+			if _, err := s.Get("long"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("KeepTTL should keep the original expiry, err = %v", err)
+			}
+		})
+	}
+}
+
+func TestStoreClosed(t *testing.T) {
+	for name, s := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			s.Close()
+			if err := s.Set("a", []byte("1"), 0); !errors.Is(err, ErrClosed) {
+				t.Errorf("Set() after Close = %v, want ErrClosed", err)
+			}
+		})
+	}
+}
+
+func TestFileReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.log")
+	f, err := OpenFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Set("keep", []byte("1"), 0)
+	f.Set("overwrite", []byte("old"), 0)
+	f.Set("overwrite", []byte("new"), 0)
+	f.Set("deleted", []byte("1"), 0)
+	f.Delete("deleted")
+	f.Set("expired", []byte("1"), time.Nanosecond)
+	f.Update("counter", 0, func(v []byte, _ bool) ([]byte, error) { return append(v, 'a'), nil })
+	f.Update("counter", 0, func(v []byte, _ bool) ([]byte, error) { return append(v, 'b'), nil })
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err = OpenFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	tests := []struct {
+		key  string
+		want string // 为空表示不存在
+	}{
+		{key: "keep", want: "1"},
+		{key: "overwrite", want: "new"},
+		{key: "deleted"},
+		{key: "expired"},
+		{key: "counter", want: "ab"},
+	}
+	for _, tt := range tests {
+		got, err := f.Get(tt.key)
+		if tt.want == "" {
+			if !errors.Is(err, ErrNotFound) {
+				t.Errorf("Get(%q) = %q, %v; want ErrNotFound", tt.key, got, err)
+			}
+			continue
+		}
+		if err != nil || string(got) != tt.want {
+			t.Errorf("Get(%q) = %q, %v; want %q", tt.key, got, err, tt.want)
+		}
+	}
+}
+
+func TestFileRecovery(t *testing.T) {
+	tests := []struct {
+		name  string
+		log   string
+		want  map[string]string
+		lines int // 打开后日志的行数
+	}{
+		{
+			name:  "truncated last record",
+			log:   `{"op":"set","k":"a","v":"MQ=="}` + "\n" + `{"op":"set","k":"b","v":`,
+			want:  map[string]string{"a": "1"},
+			lines: 1,
+		},
+		{
+			name: "garbage in the middle",
+			log: `{"op":"set","k":"a","v":"MQ=="}` + "\n" + "not json\n" +
+				`{"op":"set","k":"b","v":"Mg=="}` + "\n",
+			want:  map[string]string{"a": "1", "b": "2"},
+			lines: 2,
+		},
+		{
+			name:  "unknown op",
+			log:   `{"op":"set","k":"a","v":"MQ=="}` + "\n" + `{"op":"incr","k":"a"}` + "\n",
+			want:  map[string]string{"a": "1"},
+			lines: 1,
+		},
+		{
+			name:  "clean log is not rewritten",
+			log:   `{"op":"set","k":"a","v":"MQ=="}` + "\n" + `{"op":"del","k":"a"}` + "\n",
+			want:  map[string]string{},
+			lines: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "data.log")
+			if err := os.WriteFile(path, []byte(tt.log), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			f, err := OpenFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+
+			keys, _ := f.List("")
+			got := make(map[string]string, len(keys))
+			for _, key := range keys {
+				value, _ := f.Get(key)
+				got[key] = string(value)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("data = %v, want %v", got, tt.want)
+			}
+			if n := countLines(t, path); n != tt.lines {
+				t.Errorf("log has %d lines, want %d", n, tt.lines)
+			}
+
+			// 恢复后可以继续写入
+			if err := f.Set("after", []byte("1"), 0); err != nil {
+				t.Fatal(err)
+			}
+			if n := countLines(t, path); n != tt.lines+1 {
+				t.Errorf("log has %d lines after write, want %d", n, tt.lines+1)
+			}
+		})
+	}
+}
+
+func TestFileCompaction(t *testing.T) {
+	tests := []struct {
+		name      string
+		threshold int
+		writes    int
+		maxLines  int
+	}{
+		{name: "auto compact", threshold: 10, writes: 100, maxLines: 12},
+		{name: "disabled", threshold: 0, writes: 100, maxLines: 103},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "data.log")
+			f, err := OpenFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			f.SetCompactThreshold(tt.threshold)
+
+			f.Set("stable", []byte("s"), 0)
+			f.Set("gone", []byte("g"), 0)
+			for i := 0; i < tt.writes; i++ {
+				f.Set("hot", []byte(strconv.Itoa(i)), 0)
+			}
+			f.Delete("gone")
+
+			if n := countLines(t, path); n > tt.maxLines {
+				t.Errorf("log has %d lines, want at most %d", n, tt.maxLines)
+			}
+			if err := f.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			f, err = OpenFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+			if got, _ := f.Get("hot"); string(got) != strconv.Itoa(tt.writes-1) {
+				t.Errorf("hot = %q after reopen, want %d", got, tt.writes-1)
+			}
+			if got, _ := f.Get("stable"); string(got) != "s" {
+				t.Errorf("stable = %q after reopen", got)
+			}
+			if _, err := f.Get("gone"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("deleted key survived compaction: %v", err)
+			}
+		})
+	}
+}
+
+func TestFileManualCompact(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.log")
+	f, err := OpenFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	f.SetCompactThreshold(0)
+	for i := 0; i < 5; i++ {
+		f.Set("k", []byte(strconv.Itoa(i)), 0)
+	}
+	if err := f.Compact(); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	if n := countLines(t, path); n != 1 || !strings.Contains(string(data), `"k":"k"`) {
+		t.Errorf("compacted log = %q, want a single record", data)
+	}
+	if _, err := os.Stat(path + ".compact"); !os.IsNotExist(err) {
+		t.Errorf("temporary compaction file left behind: %v", err)
+	}
+}
+
+func countLines(t *testing.T, path string) int {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bytes.Count(data, []byte("\n"))
+}
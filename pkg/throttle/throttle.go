@@ -0,0 +1,417 @@
+// Package throttle 按账号和群限制发出消息的速率，避免突发发送触发风控
+//
+// 发送前在每个账号（机器人）的队列中排队，满足以下限制时放行：
+//   - 每个账号在窗口内最多发送的消息数、两条消息的最小间隔（附加随机抖动）
+//   - 每个群在窗口内最多发送的消息数
+//
+// 同时可以放行时高优先级的消息先发送，排队过长或等待超时的消息被丢弃（ErrDropped）。
+// 通过 Interceptor 注册为发出消息的拦截器后，调用方在放行并发送完成后得到消息 ID。
+package throttle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	types "onebot-go2/pkg/const"
+	"onebot-go2/pkg/event"
+	"onebot-go2/pkg/outbound"
+)
+
+// ErrDropped 消息在排队时被丢弃（队列已满、等待超时或 context 取消）
+var ErrDropped = errors.New("outgoing message dropped by throttle")
+
+// 排队结果，用于统计
+const (
+	ResultImmediate = "immediate" // 无需等待
+	ResultDelayed   = "delayed"   // 等待后发送
+	ResultDropped   = "dropped"   // 被丢弃
+)
+
+// Priority 发送优先级，同时可以放行时优先级高的消息先发送
+type Priority int
+
+const (
+	PriorityLow    Priority = -1 // 广播、定时消息
+	PriorityNormal Priority = 0  // 默认
+	PriorityHigh   Priority = 1  // 管理员回复等需要及时送达的消息
+)
+
+// String 返回优先级名称
+func (p Priority) String() string {
+	switch {
+	case p > PriorityNormal:
+		return "high"
+	case p < PriorityNormal:
+		return "low"
+	default:
+		return "normal"
+	}
+}
+
+type priorityKey struct{}
+
+// WithPriority 返回携带发送优先级的 context
+// 通过绑定该 context 的 Server 发出的消息按该优先级排队
+func WithPriority(ctx context.Context, priority Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, priority)
+}
+
+// PriorityOf 返回 ctx 中的发送优先级，未设置时为 PriorityNormal
+func PriorityOf(ctx context.Context) Priority {
+	if ctx == nil {
+		return PriorityNormal
+	}
+	priority, _ := ctx.Value(priorityKey{}).(Priority)
+	return priority
+}
+
+// Middleware 返回按事件设置发送优先级的中间件，处理器回复的消息按 priority 返回的优先级排队
+func Middleware(priority func(ctx *event.Context[interface{}]) Priority) event.Middleware {
+	return func(next event.HandlerFunc[interface{}]) event.HandlerFunc[interface{}] {
+		return func(ctx *event.Context[interface{}]) error {
+			if p := priority(ctx); p != PriorityNormal {
+				ctx.Context = WithPriority(ctx.Context, p)
+			}
+			return next(ctx)
+		}
+	}
+}
+
+// Config 限流配置，限制数为 0 表示不限制
+type Config struct {
+	Enabled       bool
+	AccountLimit  int // 每个账号在 AccountWindow 内最多发送的消息数
+	AccountWindow time.Duration
+	GroupLimit    int // 每个群在 GroupWindow 内最多发送的消息数
+	GroupWindow   time.Duration
+	MinInterval   time.Duration // 同一账号两条消息的最小间隔
+	Jitter        time.Duration // 在最小间隔上随机增加 [0, Jitter)
+	MaxWait       time.Duration // 最长排队时间，超过时丢弃，0 表示不限制
+	QueueSize     int           // 每个账号最多排队的消息数，超过时丢弃新消息，0 表示不限制
+}
+
+// Stats 排队统计
+type Stats struct {
+	Immediate  uint64        `json:"immediate"`   // 无需等待的消息数
+	Delayed    uint64        `json:"delayed"`     // 等待后发送的消息数
+	Dropped    uint64        `json:"dropped"`     // 被丢弃的消息数
+	TotalDelay time.Duration `json:"total_delay"` // 等待后发送的消息的总等待时间
+	Queued     int           `json:"queued"`      // 当前排队的消息数
+}
+
+// Throttle 发出消息的限流器
+type Throttle struct {
+	mu       sync.Mutex
+	config   Config
+	accounts map[int64]*account
+	stats    Stats
+	seq      uint64
+	observer func(result string, wait time.Duration)
+}
+
+// account 一个账号的发送记录和等待队列
+type account struct {
+	sent    []time.Time           // 窗口内的发送时间
+	groups  map[int64][]time.Time // 每个群窗口内的发送时间
+	next    time.Time             // 最小间隔限制的下一次最早发送时间
+	queue   []*request
+	changed chan struct{} // 每次放行或移除请求时关闭并替换，唤醒其他等待者重新判断
+}
+
+// request 一条排队的消息
+type request struct {
+	priority Priority
+	groupID  int64 // 私聊为 0
+	seq      uint64
+}
+
+// New 创建限流器
+func New(config Config) *Throttle {
+	return &Throttle{
+		config:   config,
+		accounts: make(map[int64]*account),
+	}
+}
+
+// SetConfig 替换限流配置，用于热重载；已排队的消息按新配置判断
+func (t *Throttle) SetConfig(config Config) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.config = config
+	for _, acc := range t.accounts {
+		acc.notify()
+	}
+}
+
+// SetObserver 设置每条消息排队结果的回调，用于导出指标
+func (t *Throttle) SetObserver(observer func(result string, wait time.Duration)) *Throttle {
+	t.mu.Lock()
+	t.observer = observer
+	t.mu.Unlock()
+	return t
+}
+
+// Stats 返回排队统计
+func (t *Throttle) Stats() Stats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	stats := t.stats
+	for _, acc := range t.accounts {
+		stats.Queued += len(acc.queue)
+	}
+	return stats
+}
+
+// Interceptor 返回发出消息的拦截器，撤回消息不受限制
+func (t *Throttle) Interceptor() outbound.Interceptor {
+	return func(next outbound.SendFunc) outbound.SendFunc {
+		return func(msg *outbound.Message) (*types.SendMessageResponse, error) {
+			if msg.IsSend() {
+				var groupID int64
+				if msg.IsGroup() {
+					groupID = msg.Params.GroupID
+				}
+				if err := t.Wait(msg, msg.SelfID, groupID, PriorityOf(msg)); err != nil {
+					return nil, err
+				}
+			}
+			return next(msg)
+		}
+	}
+}
+
+// Wait 排队等待发送一条消息，放行后返回 nil，调用方应立即发送
+// groupID 为 0 表示私聊，只受账号限制
+func (t *Throttle) Wait(ctx context.Context, selfID, groupID int64, priority Priority) error {
+	start := time.Now()
+	t.mu.Lock()
+	if !t.config.Enabled {
+		t.mu.Unlock()
+		return nil
+	}
+	acc := t.account(selfID)
+	if t.config.QueueSize > 0 && len(acc.queue) >= t.config.QueueSize {
+		t.finish(ResultDropped, 0)
+		t.mu.Unlock()
+		return fmt.Errorf("%w: queue is full (%d messages)", ErrDropped, t.config.QueueSize)
+	}
+	t.seq++
+	req := &request{priority: priority, groupID: groupID, seq: t.seq}
+	acc.queue = append(acc.queue, req)
+
+	for {
+		now := time.Now()
+		if !t.config.Enabled {
+			// 排队期间被热重载关闭
+			acc.remove(req)
+			t.mu.Unlock()
+			return nil
+		}
+		if t.config.MaxWait > 0 && now.Sub(start) >= t.config.MaxWait {
+			acc.remove(req)
+			t.finish(ResultDropped, 0)
+			t.mu.Unlock()
+			return fmt.Errorf("%w: waited longer than %s", ErrDropped, t.config.MaxWait)
+		}
+
+		readyAt := t.readyAt(acc, req, now)
+		if !readyAt.After(now) && acc.first(t, now) == req {
+			acc.remove(req)
+			t.grant(acc, groupID, now)
+			result := ResultImmediate
+			if now.Sub(start) > time.Millisecond {
+				result = ResultDelayed
+			}
+			t.finish(result, now.Sub(start))
+			t.mu.Unlock()
+			return nil
+		}
+
+		// 等待到自己可以放行的时间、其他请求被放行或 context 取消
+		wait := readyAt.Sub(now)
+		if wait <= 0 {
+			wait = t.config.MinInterval + t.config.Jitter + 10*time.Millisecond
+		}
+		if t.config.MaxWait > 0 && start.Add(t.config.MaxWait).Sub(now) < wait {
+			wait = start.Add(t.config.MaxWait).Sub(now)
+		}
+		changed := acc.changed
+		t.mu.Unlock()
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-changed:
+			timer.Stop()
+		case <-ctx.Done():
+			timer.Stop()
+			t.mu.Lock()
+			acc.remove(req)
+			t.finish(ResultDropped, 0)
+			t.mu.Unlock()
+			return fmt.Errorf("%w: %v", ErrDropped, ctx.Err())
+		}
+		t.mu.Lock()
+	}
+}
+
+// account 返回账号的发送记录，调用时需持有 t.mu
+func (t *Throttle) account(selfID int64) *account {
+	acc, ok := t.accounts[selfID]
+	if !ok {
+		acc = &account{groups: make(map[int64][]time.Time), changed: make(chan struct{})}
+		t.accounts[selfID] = acc
+	}
+	return acc
+}
+
+// readyAt 返回请求最早可以放行的时间，调用时需持有 t.mu
+func (t *Throttle) readyAt(acc *account, req *request, now time.Time) time.Time {
+	ready := acc.next
+	acc.sent = prune(acc.sent, now, t.config.AccountWindow)
+	if at := windowReady(acc.sent, t.config.AccountLimit, t.config.AccountWindow); at.After(ready) {
+		ready = at
+	}
+	if at := t.groupReady(acc, req.groupID, now); at.After(ready) {
+		ready = at
+	}
+	return ready
+}
+
+// groupReady 返回群限制允许发送的最早时间，调用时需持有 t.mu
+func (t *Throttle) groupReady(acc *account, groupID int64, now time.Time) time.Time {
+	if groupID == 0 {
+		return time.Time{}
+	}
+	stamps := prune(acc.groups[groupID], now, t.config.GroupWindow)
+	if len(stamps) == 0 {
+		delete(acc.groups, groupID)
+		return time.Time{}
+	}
+	acc.groups[groupID] = stamps
+	return windowReady(stamps, t.config.GroupLimit, t.config.GroupWindow)
+}
+
+// first 返回群限制已满足的请求中优先级最高、最早排队的请求，账号限制满足时它应当被放行
+func (acc *account) first(t *Throttle, now time.Time) *request {
+	var best *request
+	for _, req := range acc.queue {
+		if t.groupReady(acc, req.groupID, now).After(now) {
+			continue
+		}
+		if best == nil || req.priority > best.priority || (req.priority == best.priority && req.seq < best.seq) {
+			best = req
+		}
+	}
+	return best
+}
+
+// grant 记录一次发送，调用时需持有 t.mu
+func (t *Throttle) grant(acc *account, groupID int64, now time.Time) {
+	if t.config.AccountLimit > 0 {
+		acc.sent = append(acc.sent, now)
+	}
+	if groupID != 0 && t.config.GroupLimit > 0 {
+		acc.groups[groupID] = append(acc.groups[groupID], now)
+	}
+	acc.next = now.Add(t.config.MinInterval)
+	if t.config.Jitter > 0 {
+		acc.next = acc.next.Add(rand.N(t.config.Jitter))
+	}
+	acc.notify()
+}
+
+// finish 记录排队结果，调用时需持有 t.mu
+func (t *Throttle) finish(result string, wait time.Duration) {
+	switch result {
+	case ResultImmediate:
+		t.stats.Immediate++
+	case ResultDelayed:
+		t.stats.Delayed++
+		t.stats.TotalDelay += wait
+	case ResultDropped:
+		t.stats.Dropped++
+	}
+	if t.observer != nil {
+		t.observer(result, wait)
+	}
+}
+
+// remove 从队列中移除请求
+func (acc *account) remove(req *request) {
+	for i, r := range acc.queue {
+		if r == req {
+			acc.queue = append(acc.queue[:i], acc.queue[i+1:]...)
+			break
+		}
+	}
+	acc.notify()
+}
+
+// notify 唤醒所有等待者重新判断
+func (acc *account) notify() {
+	close(acc.changed)
+	acc.changed = make(chan struct{})
+}
+
+// prune 删除窗口之外的发送时间
+func prune(stamps []time.Time, now time.Time, window time.Duration) []time.Time {
+	i := 0
+	for i < len(stamps) && !now.Before(stamps[i].Add(window)) {
+		i++
+	}
+	return stamps[i:]
+}
+
+// windowReady 返回窗口限制允许下一次发送的最早时间，limit 为 0 表示不限制
+func windowReady(stamps []time.Time, limit int, window time.Duration) time.Time {
+	if limit <= 0 || len(stamps) < limit {
+		return time.Time{}
+	}
+	return stamps[len(stamps)-limit].Add(window)
+}
+
+// Pending 异步发送的消息，排队期间调用方可以继续处理，之后等待消息 ID
+type Pending struct {
+	done chan struct{}
+	resp *types.SendMessageResponse
+	err  error
+}
+
+// Go 在新的 goroutine 中调用 send（如 ctx.SendGroupMsg），立即返回
+func Go(send func() (*types.SendMessageResponse, error)) *Pending {
+	p := &Pending{done: make(chan struct{})}
+	go func() {
+		defer close(p.done)
+		p.resp, p.err = send()
+	}()
+	return p
+}
+
+// Done 返回发送完成（包括失败和被丢弃）时关闭的 channel
+func (p *Pending) Done() <-chan struct{} {
+	return p.done
+}
+
+// Wait 等待发送完成并返回响应，ctx 取消时返回 ctx 的错误，消息仍会继续排队
+func (p *Pending) Wait(ctx context.Context) (*types.SendMessageResponse, error) {
+	select {
+	case <-p.done:
+		return p.resp, p.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// MessageID 等待发送完成并返回消息 ID
+func (p *Pending) MessageID() (int32, error) {
+	<-p.done
+	if p.err != nil {
+		return 0, p.err
+	}
+	return p.resp.MessageID, nil
+}
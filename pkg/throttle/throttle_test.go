@@ -0,0 +1,229 @@
+package throttle
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// queued 等待直到排队的消息数达到 n
+func queued(t *testing.T, th *Throttle, n int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for th.Stats().Queued < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %d queued messages, have %d", n, th.Stats().Queued)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestPriorityOrder(t *testing.T) {
+	type send struct {
+		name     string
+		groupID  int64
+		priority Priority
+	}
+
+	tests := []struct {
+		name   string
+		config Config
+		sends  []send // 按顺序排队，第一条消息之后的都需要等待
+		want   []string
+	}{
+		{
+			name:   "priority lanes",
+			config: Config{Enabled: true, MinInterval: 20 * time.Millisecond},
+			sends: []send{
+				{name: "low", priority: PriorityLow},
+				{name: "normal", priority: PriorityNormal},
+				{name: "high", priority: PriorityHigh},
+			},
+			want: []string{"high", "normal", "low"},
+		},
+		{
+			name:   "fifo within a lane",
+			config: Config{Enabled: true, MinInterval: 20 * time.Millisecond},
+			sends: []send{
+				{name: "a", priority: PriorityNormal},
+				{name: "b", priority: PriorityNormal},
+				{name: "c", priority: PriorityHigh},
+				{name: "d", priority: PriorityNormal},
+			},
+			want: []string{"c", "a", "b", "d"},
+		},
+		{
+			name:   "blocked group does not hold back others",
+			config: Config{Enabled: true, MinInterval: 20 * time.Millisecond, GroupLimit: 1, GroupWindow: time.Hour},
+			sends: []send{
+				{name: "group 1 again", groupID: 1, priority: PriorityHigh},
+				{name: "group 2", groupID: 2},
+				{name: "private"},
+			},
+			want: []string{"group 2", "private"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			th := New(tt.config)
+			// 第一条消息占用最小间隔（和群 1 的配额），之后的消息都需要排队
+			if err := th.Wait(context.Background(), 1, 1, PriorityNormal); err != nil {
+				t.Fatal(err)
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			var mu sync.Mutex
+			var order []string
+			var wg sync.WaitGroup
+			for i, s := range tt.sends {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					if err := th.Wait(ctx, 1, s.groupID, s.priority); err != nil {
+						return
+					}
+					mu.Lock()
+					order = append(order, s.name)
+					mu.Unlock()
+				}()
+				queued(t, th, i+1)
+			}
+
+			deadline := time.Now().Add(2 * time.Second)
+			for {
+				mu.Lock()
+				n := len(order)
+				mu.Unlock()
+				if n >= len(tt.want) || time.Now().After(deadline) {
+					break
+				}
+				time.Sleep(5 * time.Millisecond)
+			}
+			cancel()
+			wg.Wait()
+
+			if !reflect.DeepEqual(order, tt.want) {
+				t.Errorf("order = %v, want %v", order, tt.want)
+			}
+		})
+	}
+}
+
+func TestDropped(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		ctx    func() (context.Context, context.CancelFunc)
+	}{
+		{
+			name:   "max wait",
+			config: Config{Enabled: true, MinInterval: time.Hour, MaxWait: 20 * time.Millisecond},
+			ctx:    func() (context.Context, context.CancelFunc) { return context.WithCancel(context.Background()) },
+		},
+		{
+			name:   "context canceled",
+			config: Config{Enabled: true, MinInterval: time.Hour},
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), 20*time.Millisecond)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			th := New(tt.config)
+			if err := th.Wait(context.Background(), 1, 0, PriorityNormal); err != nil {
+				t.Fatal(err)
+			}
+			ctx, cancel := tt.ctx()
+			defer cancel()
+			if err := th.Wait(ctx, 1, 0, PriorityNormal); !errors.Is(err, ErrDropped) {
+				t.Fatalf("Wait() error = %v, want ErrDropped", err)
+			}
+			stats := th.Stats()
+			if stats.Immediate != 1 || stats.Dropped != 1 || stats.Queued != 0 {
+				t.Errorf("Stats() = %+v, want 1 immediate, 1 dropped, none queued", stats)
+			}
+		})
+	}
+}
+
+func TestQueueFull(t *testing.T) {
+	th := New(Config{Enabled: true, MinInterval: time.Hour, QueueSize: 1})
+	th.Wait(context.Background(), 1, 0, PriorityNormal)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- th.Wait(ctx, 1, 0, PriorityNormal) }()
+	queued(t, th, 1)
+
+	if err := th.Wait(context.Background(), 1, 0, PriorityHigh); !errors.Is(err, ErrDropped) {
+		t.Errorf("Wait() on a full queue = %v, want ErrDropped", err)
+	}
+	// 其他账号有独立的队列
+	if err := th.Wait(context.Background(), 2, 0, PriorityNormal); err != nil {
+		t.Errorf("Wait() for another account = %v", err)
+	}
+	cancel()
+	<-done
+}
+
+func TestDisableWhileQueued(t *testing.T) {
+	config := Config{Enabled: true, MinInterval: time.Hour}
+	th := New(config)
+	th.Wait(context.Background(), 1, 0, PriorityNormal)
+
+	done := make(chan error)
+	go func() { done <- th.Wait(context.Background(), 1, 0, PriorityNormal) }()
+	queued(t, th, 1)
+
+	config.Enabled = false
+	th.SetConfig(config)
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Wait() = %v after disabling", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("queued message was not released after disabling")
+	}
+}
+
+func TestAccountWindow(t *testing.T) {
+	th := New(Config{Enabled: true, AccountLimit: 2, AccountWindow: 50 * time.Millisecond})
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := th.Wait(context.Background(), 1, 0, PriorityNormal); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("third message sent after %v, want it to wait for the window", elapsed)
+	}
+	if stats := th.Stats(); stats.Immediate != 2 || stats.Delayed != 1 {
+		t.Errorf("Stats() = %+v, want 2 immediate and 1 delayed", stats)
+	}
+}
+
+func TestPriorityOf(t *testing.T) {
+	tests := []struct {
+		ctx  context.Context
+		want Priority
+	}{
+		{ctx: nil, want: PriorityNormal},
+		{ctx: context.Background(), want: PriorityNormal},
+		{ctx: WithPriority(context.Background(), PriorityHigh), want: PriorityHigh},
+		{ctx: WithPriority(context.Background(), PriorityLow), want: PriorityLow},
+	}
+	for _, tt := range tests {
+		if got := PriorityOf(tt.ctx); got != tt.want {
+			t.Errorf("PriorityOf() = %v, want %v", got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package trace
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// FileExporter 将 span 以 JSON Lines 格式追加到文件，每行一个 span
+// 按 trace_id 分组即可还原一次交互的完整路径：事件 -> 处理器 -> API 调用
+type FileExporter struct {
+	mu     sync.Mutex
+	file   *os.File
+	closed bool
+}
+
+// NewFileExporter 打开（或创建）导出文件
+func NewFileExporter(path string) (*FileExporter, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create trace directory: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open trace file: %w", err)
+	}
+	return &FileExporter{file: file}, nil
+}
+
+func (e *FileExporter) Export(span SpanData) error {
+	data, err := json.Marshal(span)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.closed {
+		return os.ErrClosed
+	}
+	_, err = e.file.Write(data)
+	return err
+}
+
+func (e *FileExporter) Close() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.closed {
+		return nil
+	}
+	e.closed = true
+	return e.file.Close()
+}
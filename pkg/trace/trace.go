@@ -0,0 +1,177 @@
+package trace
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// SpanData 已结束的 span，交给 Exporter 导出
+type SpanData struct {
+	TraceID  string                 `json:"trace_id"`
+	SpanID   string                 `json:"span_id"`
+	ParentID string                 `json:"parent_id,omitempty"`
+	Name     string                 `json:"name"`
+	Start    time.Time              `json:"start"`
+	Duration time.Duration          `json:"duration_ns"`
+	Attrs    map[string]interface{} `json:"attrs,omitempty"`
+	Error    string                 `json:"error,omitempty"`
+}
+
+// Exporter 导出已结束的 span
+type Exporter interface {
+	// Export 导出一个 span，需要支持并发调用
+	Export(span SpanData) error
+	// Close 刷新并关闭
+	Close() error
+}
+
+// Tracer 创建 span 并在结束时交给 Exporter
+// nil Tracer 和未设置 Exporter 的 Tracer 仍然会分配 trace/span ID（用于日志关联），只是不导出
+type Tracer struct {
+	exporter Exporter
+}
+
+// NewTracer 创建 Tracer，exporter 为空表示只分配 ID 不导出
+func NewTracer(exporter Exporter) *Tracer {
+	return &Tracer{exporter: exporter}
+}
+
+// Span 一段操作（事件分发、处理器调用、API 调用）
+// 同一事件产生的所有 span 共享 TraceID
+type Span struct {
+	tracer *Tracer
+
+	mu    sync.Mutex
+	data  SpanData
+	ended bool
+}
+
+type spanKey struct{}
+
+// Start 开始一个 span，ctx 中已有 span 时作为其子 span，否则开始新的 trace
+// attrs 为键值对，与 slog 的参数形式相同
+func (t *Tracer) Start(ctx context.Context, name string, attrs ...interface{}) (context.Context, *Span) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	span := &Span{tracer: t, data: SpanData{
+		SpanID: newID(8),
+		Name:   name,
+		Start:  time.Now(),
+	}}
+	if parent := FromContext(ctx); parent != nil {
+		span.data.TraceID = parent.data.TraceID
+		span.data.ParentID = parent.data.SpanID
+	} else {
+		span.data.TraceID = newID(16)
+	}
+	span.SetAttrs(attrs...)
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// FromContext 返回 ctx 中当前的 span，没有时返回 nil
+func FromContext(ctx context.Context) *Span {
+	if ctx == nil {
+		return nil
+	}
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// TraceIDFrom 返回 ctx 中的 trace ID，没有时返回空字符串
+func TraceIDFrom(ctx context.Context) string {
+	return FromContext(ctx).TraceID()
+}
+
+// TraceID 返回 trace ID，span 为 nil 时返回空字符串
+func (s *Span) TraceID() string {
+	if s == nil {
+		return ""
+	}
+	return s.data.TraceID
+}
+
+// SpanID 返回 span ID，span 为 nil 时返回空字符串
+func (s *Span) SpanID() string {
+	if s == nil {
+		return ""
+	}
+	return s.data.SpanID
+}
+
+// SetAttrs 设置属性，attrs 为键值对；span 结束后调用不做任何事
+func (s *Span) SetAttrs(attrs ...interface{}) {
+	if s == nil || len(attrs) == 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ended {
+		return
+	}
+	if s.data.Attrs == nil {
+		s.data.Attrs = make(map[string]interface{}, len(attrs)/2)
+	}
+	for i := 0; i+1 < len(attrs); i += 2 {
+		key, ok := attrs[i].(string)
+		if !ok {
+			key = fmt.Sprint(attrs[i])
+		}
+		s.data.Attrs[key] = attrs[i+1]
+	}
+}
+
+// SetError 记录错误，err 为 nil 或 span 已结束时不做任何事
+func (s *Span) SetError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	if !s.ended {
+		s.data.Error = err.Error()
+	}
+	s.mu.Unlock()
+}
+
+// End 结束 span 并导出，重复调用只有第一次生效
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.Duration = time.Since(s.data.Start)
+	// 导出的 SpanData 使用属性的副本，Exporter 序列化时不与 Span 共享 map
+	data := s.data
+	if s.data.Attrs != nil {
+		data.Attrs = make(map[string]interface{}, len(s.data.Attrs))
+		for key, value := range s.data.Attrs {
+			data.Attrs[key] = value
+		}
+	}
+	s.mu.Unlock()
+
+	if s.tracer != nil && s.tracer.exporter != nil {
+		s.tracer.exporter.Export(data)
+	}
+}
+
+// newID 生成 n 字节的随机 ID（十六进制）
+func newID(n int) string {
+	b := make([]byte, n)
+	for i := 0; i < n; i += 8 {
+		v := rand.Uint64()
+		for j := 0; j < 8 && i+j < n; j++ {
+			b[i+j] = byte(v >> (8 * j))
+		}
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,219 @@
+package trace
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// memoryExporter 在内存中保存导出的 span
+type memoryExporter struct {
+	mu    sync.Mutex
+	spans []SpanData
+}
+
+func (e *memoryExporter) Export(span SpanData) error {
+	// 与 FileExporter 一样在导出时序列化属性，用于 -race 检查
+	if _, err := json.Marshal(span); err != nil {
+		return err
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = append(e.spans, span)
+	return nil
+}
+
+func (e *memoryExporter) Close() error {
+	return nil
+}
+
+func (e *memoryExporter) exported() []SpanData {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]SpanData(nil), e.spans...)
+}
+
+func TestSpanParentChild(t *testing.T) {
+	exporter := &memoryExporter{}
+	tracer := NewTracer(exporter)
+
+	ctx, root := tracer.Start(context.Background(), "event", "post_type", "message")
+	childCtx, child := tracer.Start(ctx, "handler", "handler", "echo")
+	_, grandchild := tracer.Start(childCtx, "api", "action", "send_group_msg")
+	_, other := tracer.Start(context.Background(), "event")
+
+	if TraceIDFrom(childCtx) != root.TraceID() || FromContext(childCtx) != child {
+		t.Errorf("context does not carry the child span")
+	}
+	if len(root.TraceID()) != 32 || len(root.SpanID()) != 16 {
+		t.Errorf("ids = %q/%q, want 16 and 8 bytes in hex", root.TraceID(), root.SpanID())
+	}
+	if other.TraceID() == root.TraceID() {
+		t.Error("a span without a parent joined an existing trace")
+	}
+
+	grandchild.SetError(errors.New("timeout"))
+	grandchild.End()
+	child.End()
+	root.End()
+
+	spans := exporter.exported()
+	if len(spans) != 3 {
+		t.Fatalf("exported %d spans, want 3", len(spans))
+	}
+	byName := make(map[string]SpanData)
+	for _, span := range spans {
+		byName[span.Name] = span
+		if span.TraceID != root.TraceID() {
+			t.Errorf("%s trace id = %s, want %s", span.Name, span.TraceID, root.TraceID())
+		}
+	}
+	tests := []struct {
+		name, parent, attr string
+		value              interface{}
+	}{
+		{name: "event", parent: "", attr: "post_type", value: "message"},
+		{name: "handler", parent: root.SpanID(), attr: "handler", value: "echo"},
+		{name: "api", parent: child.SpanID(), attr: "action", value: "send_group_msg"},
+	}
+	for _, tt := range tests {
+		span := byName[tt.name]
+		if span.ParentID != tt.parent {
+			t.Errorf("%s parent = %q, want %q", tt.name, span.ParentID, tt.parent)
+		}
+		if span.Attrs[tt.attr] != tt.value {
+			t.Errorf("%s attrs = %v, want %s=%v", tt.name, span.Attrs, tt.attr, tt.value)
+		}
+	}
+	if byName["api"].Error != "timeout" {
+		t.Errorf("api error = %q, want timeout", byName["api"].Error)
+	}
+}
+
+func TestSpanEndOnce(t *testing.T) {
+	exporter := &memoryExporter{}
+	_, span := NewTracer(exporter).Start(context.Background(), "event", "a", 1)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			span.End()
+		}()
+	}
+	wg.Wait()
+
+	// 结束后的修改不影响已导出的数据
+	span.SetAttrs("a", 2, "late", true)
+	span.SetError(errors.New("late"))
+	span.End()
+
+	spans := exporter.exported()
+	if len(spans) != 1 {
+		t.Fatalf("exported %d spans, want 1", len(spans))
+	}
+	if got := spans[0]; len(got.Attrs) != 1 || got.Attrs["a"] != 1 || got.Error != "" {
+		t.Errorf("exported span = %+v, want only the attributes set before End", got)
+	}
+}
+
+func TestSpanAttrsCopied(t *testing.T) {
+	exporter := &memoryExporter{}
+	tracer := NewTracer(exporter)
+	ctx, root := tracer.Start(context.Background(), "event", "k", "v")
+
+	// 子 span 在并发设置属性时结束：结束后的 SetAttrs 被忽略，Exporter 序列化属性时在 -race 下不能有竞争
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, span := tracer.Start(ctx, "handler")
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				for j := 0; j < 100; j++ {
+					span.SetAttrs("j", j)
+				}
+			}()
+			span.End()
+			<-done
+		}()
+	}
+	wg.Wait()
+	root.End()
+
+	spans := exporter.exported()
+	if len(spans) != 9 {
+		t.Fatalf("exported %d spans, want 9", len(spans))
+	}
+	// 修改导出的属性不影响 span
+	spans[len(spans)-1].Attrs["k"] = "changed"
+	root.mu.Lock()
+	defer root.mu.Unlock()
+	if root.data.Attrs["k"] != "v" {
+		t.Error("exported attributes share the span's map")
+	}
+}
+
+func TestNilTracer(t *testing.T) {
+	var tracer *Tracer
+	ctx, span := tracer.Start(context.Background(), "event")
+	if span.TraceID() == "" || TraceIDFrom(ctx) != span.TraceID() {
+		t.Error("nil tracer did not assign a trace id")
+	}
+	span.SetAttrs("k", "v")
+	span.End()
+
+	var nilSpan *Span
+	nilSpan.SetAttrs("k", "v")
+	nilSpan.End()
+	if nilSpan.TraceID() != "" || TraceIDFrom(context.Background()) != "" {
+		t.Error("missing span has a trace id")
+	}
+}
+
+func TestFileExporter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "traces", "spans.jsonl")
+	exporter, err := NewFileExporter(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tracer := NewTracer(exporter)
+	ctx, root := tracer.Start(context.Background(), "event")
+	_, child := tracer.Start(ctx, "handler", "handler", "echo")
+	child.End()
+	root.End()
+
+	if err := exporter.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := exporter.Close(); err != nil {
+		t.Errorf("second Close() error = %v", err)
+	}
+	if err := exporter.Export(SpanData{}); !errors.Is(err, os.ErrClosed) {
+		t.Errorf("Export() after Close error = %v, want os.ErrClosed", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("file has %d lines, want 2:\n%s", len(lines), data)
+	}
+	var span SpanData
+	if err := json.Unmarshal([]byte(lines[0]), &span); err != nil {
+		t.Fatal(err)
+	}
+	if span.Name != "handler" || span.ParentID != root.SpanID() || span.TraceID != root.TraceID() || span.Attrs["handler"] != "echo" {
+		t.Errorf("first line = %s", lines[0])
+	}
+}